@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/dirty"
+	"github.com/ryuhei/randomuser-go/internal/export"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+func main() {
+	var (
+		count    = flag.Int("count", 100, "生成するユーザー数")
+		seed     = flag.Int64("seed", 0, "シード値。0 の場合は現在時刻から決める")
+		nat      = flag.String("nat", "", "国籍コード (例: US)")
+		gender   = flag.String("gender", "", "性別 (male または female)")
+		inc      = flag.String("inc", "", "追加で生成する項目をカンマ区切りで指定 (例: finance)")
+		asOf     = flag.String("as-of", "", "カードの有効期限などの基準日 (YYYY-MM-DD)。省略時は今日")
+		ipRanges = flag.String("ip-ranges", "", "IP アドレスを選ぶ CIDR をカンマ区切りで指定。省略時は文書用のアドレス")
+		patterns = flag.String("email-patterns", "", "メールアドレスの書式をカンマ区切りで指定 (例: {first}.{last}:3,{f}{last})")
+		domains  = flag.String("email-domains", "", "メールアドレスのドメインをカンマ区切りで指定 (例: example.com:3,例え.テスト)")
+		dirtyArg = flag.String("dirty", "", "破損させるユーザーの割合 (例: 0.05 や 0.05,email:0.2)")
+		manifest = flag.String("dirty-manifest", "", "加えた破損を NDJSON で書き出すファイル")
+		dupRate  = flag.Float64("duplicates", 0, "近似重複のレコードを作るユーザーの割合 (0 から 1)")
+		variants = flag.Int("variants", 0, "選ばれたユーザー1人から作る近似重複のレコードの数。0 の場合は 2")
+		minAge   = flag.Int("min-age", 18, "年齢の下限")
+		maxAge   = flag.Int("max-age", 97, "年齢の上限 (120 まで)")
+		state    = flag.String("state", "", "住所の州")
+		city     = flag.String("city", "", "住所の市区町村")
+		lastName = flag.String("last-name-starts-with", "", "姓の先頭の文字列")
+		profile  = flag.String("profile", "", "生成するユーザーの傾向 (edge: 境界値を持つユーザー)")
+		popFile  = flag.String("population", "", "母集団の指定 (JSON または YAML) のファイル。指定した場合は --count の代わりに size の人数を生成する")
+		format   = flag.String("format", export.FormatJSON, "出力形式 ("+strings.Join(export.Formats(), ", ")+")")
+		out      = flag.String("out", "-", "出力先のファイル。- の場合は標準出力")
+		shards   = flag.Int("shard", 1, "出力を分割するファイル数。2 以上の場合は --out が必要")
+		table    = flag.String("table", "", "SQL の出力先テーブル名")
+		dialect  = flag.String("dialect", export.DialectPostgres, "SQL の方言 ("+strings.Join(export.SQLDialects(), ", ")+")")
+		layout   = flag.String("layout", export.LayoutNormalized, "SQL のテーブル構成 (normalized, flat)")
+		batch    = flag.Int("batch", 100, "SQL の1つの INSERT 文にまとめる行数")
+		rowGroup = flag.Int("row-group", 10000, "Parquet の1つの行グループ、Arrow の1つのレコードバッチにまとめる行数")
+		index    = flag.String("index", "users", "Elasticsearch / OpenSearch の出力先インデックス名")
+		baseDN   = flag.String("base-dn", "ou=people,dc=example,dc=com", "LDIF のエントリを置くベース DN")
+		mapping  = flag.String("mapping", "", "Elasticsearch / OpenSearch のインデックスのマッピングを書き出すファイル")
+		portrait = flag.String("portrait-base-url", "", "顔写真の転送用エンドポイントの URL (例: http://localhost:8080/portraits)。省略時は10分間有効な署名付き URL")
+		dataDir  = flag.String("data", filepath.Join("internal", "data"), "データセットのディレクトリ")
+		progress = flag.Bool("progress", true, "進捗を標準エラー出力に表示する")
+	)
+	flag.Parse()
+
+	var population *generator.Population
+	if *popFile != "" {
+		data, err := os.ReadFile(*popFile)
+		if err != nil {
+			log.Fatalf("%s の読み込みに失敗: %v", *popFile, err)
+		}
+		spec, err := generator.ParsePopulation(data)
+		if err != nil {
+			log.Fatalf("%s: %v", *popFile, err)
+		}
+		population = &spec
+		*count = spec.Size
+	}
+	if *count < 0 {
+		log.Fatalf("--count は 0 以上を指定してください: %d", *count)
+	}
+	if *shards < 1 {
+		log.Fatalf("--shard は 1 以上を指定してください: %d", *shards)
+	}
+	if *shards > 1 && *out == "-" {
+		log.Fatalf("--shard を指定する場合は --out も指定してください")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	if *mapping != "" {
+		if err := writeMapping(*mapping); err != nil {
+			log.Fatalf("マッピングの書き出しに失敗: %v", err)
+		}
+	}
+
+	gen := &generator.Generator{}
+	if err := gen.LoadGeneratorsFrom(*dataDir, ""); err != nil {
+		log.Fatalf("ジェネレーターの読み込みに失敗: %v", err)
+	}
+
+	w := &shardWriter{
+		out:    *out,
+		shards: *shards,
+		total:  *count,
+		format: *format,
+		opts: export.Options{
+			Table:        *table,
+			Dialect:      *dialect,
+			Layout:       *layout,
+			BatchSize:    *batch,
+			Index:        *index,
+			BaseDN:       *baseDN,
+			RowGroupSize: *rowGroup,
+		},
+	}
+	var p *progressReporter
+	if *progress {
+		p = newProgressReporter(os.Stderr, *count)
+	}
+
+	opts := generator.Options{Gender: *gender, Nat: *nat, Dirty: *dirtyArg, Duplicates: *dupRate, Variants: *variants, Profile: *profile, PortraitBaseURL: *portrait}
+	opts.Age = &generator.AgeRange{Min: *minAge, Max: *maxAge}
+	opts.State, opts.City, opts.LastNameStartsWith = *state, *city, *lastName
+	if *inc != "" {
+		opts.Include = strings.Split(*inc, ",")
+	}
+	if *ipRanges != "" {
+		opts.IPRanges = strings.Split(*ipRanges, ",")
+	}
+	if *patterns != "" {
+		opts.EmailPatterns = strings.Split(*patterns, ",")
+	}
+	if *domains != "" {
+		opts.EmailDomains = strings.Split(*domains, ",")
+	}
+	if *asOf != "" {
+		t, err := time.Parse(time.DateOnly, *asOf)
+		if err != nil {
+			log.Fatalf("--as-of は YYYY-MM-DD の形式で指定してください: %q", *asOf)
+		}
+		opts.AsOf = t
+	}
+	var m *manifestWriter
+	if *manifest != "" {
+		f, err := os.Create(*manifest)
+		if err != nil {
+			log.Fatalf("%s の作成に失敗: %v", *manifest, err)
+		}
+		m = newManifestWriter(f)
+	}
+	emit := func(u model.User) error {
+		if err := w.Write(u); err != nil {
+			return err
+		}
+		if err := m.Write(u); err != nil {
+			return err
+		}
+		p.Add()
+		return nil
+	}
+	var err error
+	if population != nil {
+		err = gen.StreamPopulation(*population, *seed, opts, emit)
+	} else {
+		err = gen.Stream(*count, *seed, opts, emit)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = m.Close()
+	}
+	p.Done()
+	if err != nil {
+		log.Fatalf("ユーザーの生成に失敗: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d件を生成しました (seed=%d)\n", *count, *seed)
+}
+
+// writeMapping は Elasticsearch / OpenSearch のインデックスのマッピングを path に書き出す
+func writeMapping(path string) error {
+	b, err := json.MarshalIndent(export.ElasticsearchMapping(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// manifestWriter は dirty で加えた破損を生成順に NDJSON で書き出す。nil の場合は何もしない
+type manifestWriter struct {
+	file  *os.File
+	buf   *bufio.Writer
+	enc   *json.Encoder
+	index int
+}
+
+func newManifestWriter(f *os.File) *manifestWriter {
+	buf := bufio.NewWriter(f)
+	return &manifestWriter{file: f, buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (m *manifestWriter) Write(u model.User) error {
+	if m == nil {
+		return nil
+	}
+	for _, e := range dirty.Manifest(m.index, u) {
+		if err := m.enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	m.index++
+	return nil
+}
+
+func (m *manifestWriter) Close() error {
+	if m == nil {
+		return nil
+	}
+	if err := m.buf.Flush(); err != nil {
+		m.file.Close()
+		return err
+	}
+	return m.file.Close()
+}
+
+// shardWriter は生成順にユーザーを書き出し、件数に応じて出力ファイルを切り替える
+type shardWriter struct {
+	out    string
+	shards int
+	total  int
+	format string
+	opts   export.Options
+
+	written int
+	shard   int
+	limit   int
+	file    *os.File
+	buf     *bufio.Writer
+	enc     export.Encoder
+}
+
+func (w *shardWriter) Write(u model.User) error {
+	for w.enc == nil || w.written >= w.limit {
+		if err := w.next(); err != nil {
+			return err
+		}
+	}
+	w.written++
+	return w.enc.Encode(u)
+}
+
+// next は現在のファイルを閉じ、次のシャードのファイルを開く
+func (w *shardWriter) next() error {
+	if err := w.closeCurrent(); err != nil {
+		return err
+	}
+	if w.shard >= w.shards {
+		return fmt.Errorf("シャードの数を超えて書き出そうとしました")
+	}
+
+	var dst io.Writer = os.Stdout
+	if w.out != "-" {
+		path := shardPath(w.out, w.format, w.shard, w.shards)
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("%s の作成に失敗: %v", path, err)
+		}
+		w.file = f
+		dst = f
+	}
+	w.buf = bufio.NewWriter(dst)
+
+	enc, err := export.NewEncoder(w.buf, w.format, w.opts)
+	if err != nil {
+		return err
+	}
+	w.enc = enc
+	w.shard++
+	// 端数は先頭のシャードから順に1件ずつ割り当てる
+	w.limit = w.written + w.total/w.shards
+	if w.shard <= w.total%w.shards {
+		w.limit++
+	}
+	return nil
+}
+
+func (w *shardWriter) closeCurrent() error {
+	if w.enc == nil {
+		return nil
+	}
+	if err := w.enc.Close(); err != nil {
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	w.enc = nil
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		return err
+	}
+	return nil
+}
+
+// Close は残りのシャードを空のファイルとして書き出し、すべてのファイルを閉じる
+func (w *shardWriter) Close() error {
+	for w.shard < w.shards {
+		if err := w.next(); err != nil {
+			return err
+		}
+	}
+	return w.closeCurrent()
+}
+
+// shardPath はシャードの出力先を返す。1 ファイルの場合は out をそのまま使う
+func shardPath(out, format string, shard, shards int) string {
+	if shards == 1 {
+		return out
+	}
+	ext := filepath.Ext(out)
+	if ext == "" {
+		ext = export.Extension(format)
+	}
+	base := strings.TrimSuffix(out, filepath.Ext(out))
+	return fmt.Sprintf("%s-%05d-of-%05d%s", base, shard+1, shards, ext)
+}
+
+// progressReporter は生成件数と速度を一定間隔で表示する
+type progressReporter struct {
+	w       io.Writer
+	total   int
+	done    int
+	started time.Time
+	last    time.Time
+}
+
+func newProgressReporter(w io.Writer, total int) *progressReporter {
+	now := time.Now()
+	return &progressReporter{w: w, total: total, started: now, last: now}
+}
+
+func (p *progressReporter) Add() {
+	if p == nil {
+		return
+	}
+	p.done++
+	if now := time.Now(); now.Sub(p.last) >= 200*time.Millisecond {
+		p.last = now
+		p.print()
+	}
+}
+
+func (p *progressReporter) Done() {
+	if p == nil {
+		return
+	}
+	p.print()
+	fmt.Fprintln(p.w)
+}
+
+func (p *progressReporter) print() {
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(p.done) * 100 / float64(p.total)
+	}
+	rate := float64(p.done) / time.Since(p.started).Seconds()
+	fmt.Fprintf(p.w, "\r%d/%d (%.1f%%) %.0f件/秒", p.done, p.total, percent, rate)
+}
@@ -12,8 +12,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/directory"
 	"github.com/ryuhei/randomuser-go/internal/generator"
 	"github.com/ryuhei/randomuser-go/internal/infrastructure/controller"
+	"github.com/ryuhei/randomuser-go/internal/oidc"
+	"github.com/ryuhei/randomuser-go/internal/reload"
 )
 
 func main() {
@@ -27,18 +30,153 @@ func main() {
 	}
 
 	gen := &generator.Generator{}
-	err = gen.LoadGenerators()
+	err = gen.LoadGenerators(cfg.BucketName)
 	if err != nil {
 		log.Fatalf("ジェネレーターの読み込みに失敗: %v", err)
 	}
 
+	reloader := reload.New(gen, cfg)
+
+	var asOf time.Time
+	if cfg.DirectoryAsOf != "" {
+		if asOf, err = time.Parse(time.DateOnly, cfg.DirectoryAsOf); err != nil {
+			log.Fatalf("directoryAsOf は YYYY-MM-DD の形式で指定してください: %q", cfg.DirectoryAsOf)
+		}
+	}
+	// ディレクトリは起動中ずっと保持するため、顔写真は期限の切れない転送用の URL にする
+	dir, err := directory.New(gen, cfg.DirectorySize, cfg.DirectorySeed, generator.Options{AsOf: asOf, PortraitBaseURL: cfg.PortraitBaseURL})
+	if err != nil {
+		log.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if cfg.DirectorySnapshot != "" {
+		if err := dir.EnableSnapshot(cfg.DirectorySnapshot); err != nil {
+			log.Fatalf("ディレクトリのスナップショットの読み込みに失敗: %v", err)
+		}
+	}
+
 	router := gin.Default()
 	router.Use(corsMiddleware())
 
+	// requireAdmin は管理用トークンを検証する。トークンは再読み込みした設定のものを使う
+	requireAdmin := func(c *gin.Context) {
+		controller.RequireAdminToken(c, reloader.Config().AdminToken)
+	}
+
+	router.GET("/portraits/*key", func(c *gin.Context) {
+		controller.RedirectPortrait(c, gen)
+	})
+
 	api := router.Group("/api")
 	{
 		api.GET("", func(c *gin.Context) {
-			controller.GenerateUser(c, gen, cfg)
+			controller.GenerateUser(c, gen, reloader.Config())
+		})
+		api.POST("/population", func(c *gin.Context) {
+			controller.GeneratePopulation(c, gen, reloader.Config())
+		})
+		api.GET("/households", func(c *gin.Context) {
+			controller.GenerateHouseholds(c, gen, reloader.Config())
+		})
+		api.GET("/orgs", func(c *gin.Context) {
+			controller.GenerateOrganization(c, gen, reloader.Config())
+		})
+		api.GET("/datasets", func(c *gin.Context) {
+			controller.ListDatasets(c, gen)
+		})
+		api.GET("/mapping/elasticsearch", controller.ElasticsearchMapping)
+		api.GET("/directory", func(c *gin.Context) {
+			controller.ListDirectory(c, dir, reloader.Config())
+		})
+		api.POST("/directory", func(c *gin.Context) {
+			controller.CreateDirectoryUser(c, dir)
+		})
+		// 初期化は SCIM と OpenID Connect の状態も破棄し、大きな母集団も作れるため管理用トークンを必要とする
+		api.POST("/directory/reset", requireAdmin, func(c *gin.Context) {
+			controller.ResetDirectory(c, dir)
+		})
+		api.GET("/directory/:id", func(c *gin.Context) {
+			controller.GetDirectoryUser(c, dir)
+		})
+		api.PUT("/directory/:id", func(c *gin.Context) {
+			controller.ReplaceDirectoryUser(c, dir)
+		})
+		api.PATCH("/directory/:id", func(c *gin.Context) {
+			controller.PatchDirectoryUser(c, dir)
+		})
+		api.DELETE("/directory/:id", func(c *gin.Context) {
+			controller.DeleteDirectoryUser(c, dir)
+		})
+	}
+
+	users := router.Group("/scim/v2")
+	{
+		users.GET("/ServiceProviderConfig", controller.ScimServiceProviderConfig)
+		users.GET("/ResourceTypes", controller.ScimResourceTypes)
+		users.GET("/Users", func(c *gin.Context) {
+			controller.ScimListUsers(c, dir)
+		})
+		users.POST("/Users", func(c *gin.Context) {
+			controller.ScimCreateUser(c, dir)
+		})
+		users.GET("/Users/:id", func(c *gin.Context) {
+			controller.ScimGetUser(c, dir)
+		})
+		users.PUT("/Users/:id", func(c *gin.Context) {
+			controller.ScimReplaceUser(c, dir)
+		})
+		users.PATCH("/Users/:id", func(c *gin.Context) {
+			controller.ScimPatchUser(c, dir)
+		})
+		users.DELETE("/Users/:id", func(c *gin.Context) {
+			controller.ScimDeleteUser(c, dir)
+		})
+	}
+
+	if cfg.OIDC.Enabled {
+		var clients []oidc.Client
+		for _, c := range cfg.OIDC.Clients {
+			clients = append(clients, oidc.Client{ID: c.ClientID, Secret: c.ClientSecret, RedirectURIs: c.RedirectURIs})
+		}
+		provider, err := oidc.New(dir, clients)
+		if err != nil {
+			log.Fatalf("OpenID Connect プロバイダーの作成に失敗: %v", err)
+		}
+
+		issuer := cfg.OIDC.Issuer
+		idp := router.Group("/oidc")
+		{
+			idp.GET("/.well-known/openid-configuration", func(c *gin.Context) {
+				controller.OIDCDiscovery(c, issuer)
+			})
+			idp.GET("/jwks", func(c *gin.Context) {
+				controller.OIDCJWKS(c, provider)
+			})
+			idp.GET("/authorize", func(c *gin.Context) {
+				controller.OIDCAuthorize(c, provider)
+			})
+			idp.POST("/authorize", func(c *gin.Context) {
+				controller.OIDCLogin(c, provider)
+			})
+			idp.POST("/token", func(c *gin.Context) {
+				controller.OIDCToken(c, provider, issuer)
+			})
+			idp.GET("/userinfo", func(c *gin.Context) {
+				controller.OIDCUserInfo(c, provider, issuer)
+			})
+			idp.POST("/userinfo", func(c *gin.Context) {
+				controller.OIDCUserInfo(c, provider, issuer)
+			})
+		}
+	}
+
+	admin := router.Group("/admin")
+	admin.Use(requireAdmin)
+	{
+		admin.POST("/reload", func(c *gin.Context) {
+			controller.ReloadData(c, reloader)
+		})
+		admin.GET("/status", func(c *gin.Context) {
+			controller.ReloadStatus(c, reloader)
 		})
 	}
 
@@ -54,6 +192,15 @@ func main() {
 		}
 	}()
 
+	// SIGHUP で設定とデータセットを再読み込みする
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			_, _ = reloader.Reload(reload.TriggerSignal)
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -69,7 +216,7 @@ func main() {
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
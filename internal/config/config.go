@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,8 +15,61 @@ type Config struct {
 	MaxResults    int    `json:"maxResults"`
 	ResetInterval int    `json:"resetInterval"`
 	BucketName    string `json:"bucketName"`
+	// PortraitBaseURL は顔写真を署名付き URL に転送するエンドポイント(/portraits)の外部から見た URL。
+	// ディレクトリや書き出したファイルの顔写真はこの URL を使う。空の場合は http://localhost:{port}/portraits
+	PortraitBaseURL string `json:"portraitBaseUrl"`
+	// AdminToken は管理用エンドポイントの Bearer トークン。空の場合は環境変数 ADMIN_TOKEN を使う
+	AdminToken string `json:"adminToken"`
+	// DirectorySize は SCIM などで公開するディレクトリの母集団の人数
+	DirectorySize int `json:"directorySize"`
+	// DirectorySeed はディレクトリの母集団のシード値
+	DirectorySeed int64 `json:"directorySeed"`
+	// DirectoryAsOf はディレクトリの母集団の生年月日と登録日の基準日(YYYY-MM-DD)。空の場合は固定の既定日
+	DirectoryAsOf string `json:"directoryAsOf"`
+	// DirectorySnapshot はディレクトリの変更を書き出すファイル。空の場合は書き出さない
+	DirectorySnapshot string `json:"directorySnapshot"`
+	// OIDC はディレクトリのユーザーでログインできる OpenID Connect プロバイダーの設定
+	OIDC OIDCConfig `json:"oidc"`
+	// Internet は生成するユーザーのネットワークの設定
+	Internet InternetConfig `json:"internet"`
+	// Email は生成するユーザーのメールアドレスの設定
+	Email EmailConfig `json:"email"`
 }
 
+// EmailConfig は生成するユーザーのメールアドレスの設定。リクエストの emailPatterns と emailDomains が優先する
+type EmailConfig struct {
+	// Patterns はローカル部の書式と重み (例: "{first}.{last}:3")。空の場合は既定の書式を使う
+	Patterns []string `json:"patterns"`
+	// Domains はドメインと重み (例: "example.com:3")。空の場合は予約済みのドメインなどを使う
+	Domains []string `json:"domains"`
+}
+
+// InternetConfig は生成するユーザーのネットワークの設定
+type InternetConfig struct {
+	// IPRanges は IP アドレスを選ぶ CIDR の一覧。空の場合は文書用のアドレス (192.0.2.0/24, 2001:db8::/32 など) を使う
+	IPRanges []string `json:"ipRanges"`
+}
+
+// OIDCConfig は OpenID Connect プロバイダーの設定
+type OIDCConfig struct {
+	// Enabled が true の場合だけ /oidc 以下のエンドポイントを公開する
+	Enabled bool `json:"enabled"`
+	// Issuer は ID トークンの iss。空の場合はリクエストのホストから決める
+	Issuer string `json:"issuer"`
+	// Clients は登録済みのクライアント。空の場合はどのクライアントも受け付ける
+	Clients []OIDCClient `json:"clients"`
+}
+
+// OIDCClient は OpenID Connect のクライアントの設定
+type OIDCClient struct {
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	RedirectURIs []string `json:"redirectUris"`
+}
+
+// defaultDirectorySize はディレクトリの母集団の既定の人数
+const defaultDirectorySize = 1000
+
 // Load は設定ファイルから設定を読み込む
 func Load() (*Config, error) {
 	// 設定ファイルのパスを取得
@@ -24,9 +78,12 @@ func Load() (*Config, error) {
 	// 設定ファイルが存在しない場合はデフォルト設定を返す
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return &Config{
-			Port:          8080,
-			MaxResults:    5000,
-			BucketName:    "profile-generator",
+			Port:            8080,
+			MaxResults:      5000,
+			BucketName:      "profile-generator",
+			PortraitBaseURL: defaultPortraitBaseURL(8080),
+			AdminToken:      os.Getenv("ADMIN_TOKEN"),
+			DirectorySize:   defaultDirectorySize,
 		}, nil
 	}
 
@@ -42,10 +99,24 @@ func Load() (*Config, error) {
 	if err := json.NewDecoder(file).Decode(&config); err != nil {
 		return nil, err
 	}
+	if config.AdminToken == "" {
+		config.AdminToken = os.Getenv("ADMIN_TOKEN")
+	}
+	if config.DirectorySize <= 0 {
+		config.DirectorySize = defaultDirectorySize
+	}
+	if config.PortraitBaseURL == "" {
+		config.PortraitBaseURL = defaultPortraitBaseURL(config.Port)
+	}
 
 	return &config, nil
 }
 
+// defaultPortraitBaseURL はローカルで動かす場合の顔写真の転送用エンドポイントの URL を返す
+func defaultPortraitBaseURL(port int) string {
+	return fmt.Sprintf("http://localhost:%d/portraits", port)
+}
+
 // SetEnv は設定値を環境変数として設定します
 func SetEnv(config *Config) error {
 	if config == nil {
@@ -0,0 +1,71 @@
+package dataset
+
+import (
+	mathrand "math/rand"
+)
+
+// aliasTable は Walker のエイリアス法による重み付き抽選表。
+// 構築は O(n)、1回の抽選は O(1) で行える
+type aliasTable struct {
+	prob  []float64
+	alias []int
+}
+
+// newAliasTable は正の重みから抽選表を構築する (Vose の方法)
+func newAliasTable(weights []float64) *aliasTable {
+	n := len(weights)
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+
+	t := &aliasTable{
+		prob:  make([]float64, n),
+		alias: make([]int, n),
+	}
+	scaled := make([]float64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		t.prob[s] = scaled[s]
+		t.alias[s] = l
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	// 浮動小数点の誤差で残ったものは確率1として扱う
+	for _, i := range large {
+		t.prob[i] = 1
+	}
+	for _, i := range small {
+		t.prob[i] = 1
+	}
+
+	return t
+}
+
+// pick は重みに従ってインデックスを1つ選ぶ
+func (t *aliasTable) pick(rnd *mathrand.Rand) int {
+	i := rnd.Intn(len(t.prob))
+	if rnd.Float64() < t.prob[i] {
+		return i
+	}
+	return t.alias[i]
+}
@@ -0,0 +1,471 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// データセットに含まれるリストの名前
+const (
+	MaleFirstNames   = "first_names_male"
+	FemaleFirstNames = "first_names_female"
+	LastNames        = "last_names"
+	Cities           = "cities"
+	States           = "states"
+	Streets          = "streets"
+)
+
+// CityStates は市区町村とその市区町村がある州の対応の名前
+const CityStates = "city_states"
+
+// cityStatesFile は市区町村と州の対応を読み込むファイル
+const cityStatesFile = "city_states.txt"
+
+// builtinCityStates はファイルが無い場合に使う、組み込みの市区町村と州の対応
+var builtinCityStates = map[string][]string{
+	"New York":    {"New York"},
+	"Los Angeles": {"California"},
+	"Chicago":     {"Illinois"},
+	"Houston":     {"Texas"},
+	"Phoenix":     {"Arizona"},
+}
+
+// SourceBuiltin はファイルが無い場合に使う組み込みリストの出所
+const SourceBuiltin = "builtin"
+
+// decadesDir は出生年代別の名前リストを置くディレクトリ
+const decadesDir = "decades"
+
+// decadeFilePattern は年代別の名前リストのファイル名 (例: male_1950s.txt)
+var decadeFilePattern = regexp.MustCompile(`^(male|female)_(\d{3}0)s\.txt$`)
+
+// DecadeFirstNames は性別と出生年に対応する年代別の名前リストの名前を返す
+func DecadeFirstNames(gender string, birthYear int) string {
+	return fmt.Sprintf("first_names_%s_%ds", gender, birthYear/10*10)
+}
+
+// spec はリスト名と読み込むファイル、ファイルが無い場合の組み込みリストの対応
+type spec struct {
+	name    string
+	file    string
+	builtin []string
+}
+
+var specs = []spec{
+	{name: MaleFirstNames, file: "male_first.txt", builtin: []string{"John", "Robert", "Michael", "David", "William"}},
+	{name: FemaleFirstNames, file: "female_first.txt", builtin: []string{"Mary", "Patricia", "Jennifer", "Linda", "Elizabeth"}},
+	{name: LastNames, file: "last.txt", builtin: []string{"Smith", "Johnson", "Williams", "Brown", "Jones"}},
+	{name: Cities, file: "cities.txt", builtin: []string{"New York", "Los Angeles", "Chicago", "Houston", "Phoenix"}},
+	{name: States, file: "states.txt", builtin: []string{"California", "New York", "Texas", "Florida", "Illinois"}},
+	{name: Streets, file: "street.txt", builtin: []string{"Main Street", "Park Avenue", "Oak Street", "Maple Avenue", "Cedar Road"}},
+}
+
+// List は名前付きのデータリスト
+type List struct {
+	Name string
+	// Source は読み込んだファイルのパス。組み込みリストの場合は SourceBuiltin
+	Source  string
+	Entries []string
+	// Weights は各要素の重み。nil の場合は一様に選ばれる
+	Weights []float64
+	// Meta はファイル先頭の "# key: value" 行から読み込んだメタデータ
+	Meta map[string]string
+
+	table *aliasTable
+}
+
+// Len はリストの要素数を返す
+func (l *List) Len() int {
+	return len(l.Entries)
+}
+
+// Weighted は重み付きのリストかどうかを返す
+func (l *List) Weighted() bool {
+	return l.Weights != nil
+}
+
+// Pick はリストから1件を選ぶ。重み付きの場合は重みに比例した確率で選ばれる
+func (l *List) Pick(rnd *mathrand.Rand) string {
+	if l.table != nil {
+		return l.Entries[l.table.pick(rnd)]
+	}
+	return l.Entries[rnd.Intn(len(l.Entries))]
+}
+
+// Filter は keep が true を返す要素だけのリストを返す。重みは元の重みのまま保つ。
+// 該当する要素が無い場合は nil を返す
+func (l *List) Filter(keep func(string) bool) *List {
+	out := &List{Name: l.Name, Source: l.Source, Meta: l.Meta}
+	for i, e := range l.Entries {
+		if !keep(e) {
+			continue
+		}
+		out.Entries = append(out.Entries, e)
+		if l.Weighted() {
+			out.Weights = append(out.Weights, l.Weights[i])
+		}
+	}
+	if len(out.Entries) == 0 {
+		return nil
+	}
+	if out.Weighted() {
+		out.table = newAliasTable(out.Weights)
+	}
+	return out
+}
+
+// Find は大文字小文字を区別せずに value と一致する要素を返す。無い場合は false を返す
+func (l *List) Find(value string) (string, bool) {
+	for _, e := range l.Entries {
+		if strings.EqualFold(e, value) {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// Dataset はユーザー生成に使うリストの集合
+type Dataset struct {
+	Dir      string
+	LoadedAt time.Time
+
+	lists map[string]*List
+	// fallbackFirstNames は年代別の名前リストの名前ごとの、その年代に使う全体の名前リスト
+	fallbackFirstNames map[string]*List
+	// cityStates は市区町村ごとの、その市区町村がある州
+	cityStates       map[string][]string
+	cityStatesSource string
+}
+
+// Info はデータセット内のリストの概要
+type Info struct {
+	Name     string            `json:"name"`
+	Source   string            `json:"source"`
+	Size     int               `json:"size"`
+	Weighted bool              `json:"weighted"`
+	Meta     map[string]string `json:"meta,omitempty"`
+}
+
+// Load はディレクトリからデータセットを読み込む。
+// ファイルが無いリストは組み込みリストで補い、内容が不正な場合はエラーを返す
+func Load(dir string) (*Dataset, error) {
+	d := &Dataset{
+		Dir:      dir,
+		LoadedAt: time.Now(),
+		lists:    make(map[string]*List),
+	}
+
+	for _, s := range specs {
+		path := filepath.Join(dir, s.file)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			log.Printf("%s が見つからないため組み込みリストを使います", path)
+			d.lists[s.name] = builtinList(s)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s の読み込みに失敗: %v", path, err)
+		}
+
+		list, err := Parse(s.name, path, content)
+		if err != nil {
+			return nil, err
+		}
+		d.lists[s.name] = list
+	}
+
+	if err := d.loadDecades(filepath.Join(dir, decadesDir)); err != nil {
+		return nil, err
+	}
+	if err := d.loadCityStates(filepath.Join(dir, cityStatesFile)); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// loadDecades は出生年代別の名前リストを読み込む。ディレクトリが無い場合は何もしない
+func (d *Dataset) loadDecades(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s の読み込みに失敗: %v", dir, err)
+	}
+
+	// ReadDir はファイル名順に返すため、性別ごとの年代は古い順に並ぶ
+	decades := make(map[string][]int)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".txt" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		m := decadeFilePattern.FindStringSubmatch(e.Name())
+		if m == nil {
+			return fmt.Errorf("%s: ファイル名は <male|female>_<年代>s.txt である必要があります", path)
+		}
+		decade, _ := strconv.Atoi(m[2])
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s の読み込みに失敗: %v", path, err)
+		}
+		list, err := Parse(DecadeFirstNames(m[1], decade), path, content)
+		if err != nil {
+			return err
+		}
+		d.lists[list.Name] = list
+		decades[m[1]] = append(decades[m[1]], decade)
+	}
+	d.buildFallbackFirstNames(decades)
+	return nil
+}
+
+// buildFallbackFirstNames は年代ごとに、全体の名前リストからより後の年代のリストにだけ現れる名前を除いたリストを作る。
+// decades は性別ごとの年代別のリストの年代で、古い順に並ぶ。年代別のリストに無い名前はどの年代にも使う
+func (d *Dataset) buildFallbackFirstNames(decades map[string][]int) {
+	d.fallbackFirstNames = make(map[string]*List)
+	for gender, years := range decades {
+		flat := d.lists[MaleFirstNames]
+		if gender == "female" {
+			flat = d.lists[FemaleFirstNames]
+		}
+
+		// 名前ごとに、年代別のリストに最初に現れる年代を調べる
+		firstDecade := make(map[string]int)
+		for _, decade := range years {
+			for _, name := range d.lists[DecadeFirstNames(gender, decade)].Entries {
+				if _, ok := firstDecade[name]; !ok {
+					firstDecade[name] = decade
+				}
+			}
+		}
+
+		for _, decade := range years {
+			d.fallbackFirstNames[DecadeFirstNames(gender, decade)] = flat.Filter(func(name string) bool {
+				first, ok := firstDecade[name]
+				return !ok || first <= decade
+			})
+		}
+	}
+}
+
+// loadCityStates は市区町村と州の対応を読み込む。ファイルが無い場合は組み込みの対応を使う
+func (d *Dataset) loadCityStates(path string) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Printf("%s が見つからないため組み込みの対応を使います", path)
+		d.cityStates, d.cityStatesSource = builtinCityStates, SourceBuiltin
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s の読み込みに失敗: %v", path, err)
+	}
+
+	cityStates, err := ParseCityStates(path, content)
+	if err != nil {
+		return err
+	}
+	d.cityStates, d.cityStatesSource = cityStates, path
+	return nil
+}
+
+// Builtin は組み込みリストのみのデータセットを返す
+func Builtin() *Dataset {
+	d := &Dataset{
+		LoadedAt: time.Now(),
+		lists:    make(map[string]*List),
+	}
+	for _, s := range specs {
+		d.lists[s.name] = builtinList(s)
+	}
+	d.cityStates, d.cityStatesSource = builtinCityStates, SourceBuiltin
+	return d
+}
+
+func builtinList(s spec) *List {
+	return &List{
+		Name:    s.name,
+		Source:  SourceBuiltin,
+		Entries: append([]string(nil), s.builtin...),
+	}
+}
+
+// Parse はファイルの内容をリストとして解析し、検証する。
+// 各行は値のみ、または "値<TAB>重み" の形式で、重みはすべての行に付けるか、どの行にも付けない
+func Parse(name, source string, content []byte) (*List, error) {
+	list := &List{
+		Name:   name,
+		Source: source,
+		Meta:   make(map[string]string),
+	}
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Bytes()
+		if !utf8.Valid(raw) {
+			return nil, fmt.Errorf("%s:%d: UTF-8 として不正な行です", source, lineNo)
+		}
+
+		line := strings.TrimSpace(string(raw))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(line[1:]), ":"); ok {
+				list.Meta[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+			continue
+		}
+
+		value, weight, weighted, err := parseEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", source, lineNo, err)
+		}
+		if len(list.Entries) > 0 && weighted != list.Weighted() {
+			return nil, fmt.Errorf("%s:%d: 重みの有無が他の行と一致しません", source, lineNo)
+		}
+
+		if prev, ok := seen[value]; ok {
+			return nil, fmt.Errorf("%s:%d: %q が重複しています(%d行目)", source, lineNo, value, prev)
+		}
+		seen[value] = lineNo
+		list.Entries = append(list.Entries, value)
+		if weighted {
+			list.Weights = append(list.Weights, weight)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s の読み込みに失敗: %v", source, err)
+	}
+
+	if len(list.Entries) == 0 {
+		return nil, fmt.Errorf("%s: リストが空です", source)
+	}
+	if len(list.Meta) == 0 {
+		list.Meta = nil
+	}
+	if list.Weighted() {
+		list.table = newAliasTable(list.Weights)
+	}
+	return list, nil
+}
+
+// ParseCityStates は市区町村と州の対応を解析し、検証する。
+// 各行は "市区町村<TAB>州" の形式で、同じ名前の市区町村が複数の州にある場合は州をカンマで区切る
+func ParseCityStates(source string, content []byte) (map[string][]string, error) {
+	cityStates := make(map[string][]string)
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Bytes()
+		if !utf8.Valid(raw) {
+			return nil, fmt.Errorf("%s:%d: UTF-8 として不正な行です", source, lineNo)
+		}
+
+		line := strings.TrimSpace(string(raw))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		city, rawStates, ok := strings.Cut(line, "\t")
+		city = strings.TrimSpace(city)
+		if !ok || city == "" {
+			return nil, fmt.Errorf("%s:%d: 市区町村と州をタブで区切ってください", source, lineNo)
+		}
+		if prev, ok := seen[city]; ok {
+			return nil, fmt.Errorf("%s:%d: %q が重複しています(%d行目)", source, lineNo, city, prev)
+		}
+		seen[city] = lineNo
+
+		for _, state := range strings.Split(rawStates, ",") {
+			state = strings.TrimSpace(state)
+			if state == "" {
+				return nil, fmt.Errorf("%s:%d: %q の州が空です", source, lineNo, city)
+			}
+			cityStates[city] = append(cityStates[city], state)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s の読み込みに失敗: %v", source, err)
+	}
+
+	if len(cityStates) == 0 {
+		return nil, fmt.Errorf("%s: 対応が空です", source)
+	}
+	return cityStates, nil
+}
+
+// parseEntry は1行を値と重みに分ける
+func parseEntry(line string) (string, float64, bool, error) {
+	value, rawWeight, ok := strings.Cut(line, "\t")
+	if !ok {
+		return line, 0, false, nil
+	}
+
+	value = strings.TrimSpace(value)
+	weight, err := strconv.ParseFloat(strings.TrimSpace(rawWeight), 64)
+	if err != nil {
+		return "", 0, false, fmt.Errorf("重みが数値ではありません: %q", rawWeight)
+	}
+	if weight <= 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+		return "", 0, false, fmt.Errorf("重みは正の有限値である必要があります: %q", rawWeight)
+	}
+	return value, weight, true, nil
+}
+
+// List は名前に対応するリストを返す。存在しない場合は nil を返す
+func (d *Dataset) List(name string) *List {
+	return d.lists[name]
+}
+
+// FallbackFirstNames は年代別の名前リストを補う全体の名前リストを返す。
+// より後の年代のリストにだけ現れる名前は除く。年代別のリストが無い場合や、残る名前が無い場合は nil を返す
+func (d *Dataset) FallbackFirstNames(gender string, birthYear int) *List {
+	return d.fallbackFirstNames[DecadeFirstNames(gender, birthYear)]
+}
+
+// StatesOf は市区町村 city がある州を返す。対応が無い場合は nil を返す
+func (d *Dataset) StatesOf(city string) []string {
+	return d.cityStates[city]
+}
+
+// Info はデータセット内の全リストと市区町村と州の対応の概要を名前順で返す
+func (d *Dataset) Info() []Info {
+	infos := make([]Info, 0, len(d.lists)+1)
+	for _, l := range d.lists {
+		infos = append(infos, Info{
+			Name:     l.Name,
+			Source:   l.Source,
+			Size:     l.Len(),
+			Weighted: l.Weighted(),
+			Meta:     l.Meta,
+		})
+	}
+	if d.cityStates != nil {
+		infos = append(infos, Info{Name: CityStates, Source: d.cityStatesSource, Size: len(d.cityStates)})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
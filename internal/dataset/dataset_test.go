@@ -0,0 +1,173 @@
+package dataset
+
+import (
+	mathrand "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	list, err := Parse("cities", "cities.txt", []byte("# source: test\n\nAustin\n  Boston  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Austin", "Boston"}, list.Entries)
+	assert.Equal(t, map[string]string{"source": "test"}, list.Meta)
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "空のリスト", content: []byte("# source: test\n\n")},
+		{name: "重複", content: []byte("Austin\nBoston\nAustin\n")},
+		{name: "UTF-8として不正", content: []byte("Austin\nBo\xffston\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("cities", "cities.txt", tt.content)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	d, err := Load("../data")
+	require.NoError(t, err)
+
+	for _, info := range d.Info() {
+		assert.NotEqual(t, SourceBuiltin, info.Source, info.Name)
+		assert.Positive(t, info.Size, info.Name)
+	}
+
+	decade := d.List(DecadeFirstNames("male", 1957))
+	require.NotNil(t, decade)
+	assert.Equal(t, "first_names_male_1950s", decade.Name)
+	assert.True(t, decade.Weighted())
+	assert.Nil(t, d.List(DecadeFirstNames("female", 1890)))
+
+	// 年代別のリストを補う名前からは、より後の年代にだけ流行した名前を除く
+	fallback := d.FallbackFirstNames("female", 1935)
+	require.NotNil(t, fallback)
+	for name, want := range map[string]bool{"Mary": true, "Zoe": true, "Addison": false} {
+		_, ok := fallback.Find(name)
+		assert.Equal(t, want, ok, name)
+	}
+	_, ok := d.FallbackFirstNames("female", 2015).Find("Addison")
+	assert.True(t, ok)
+	assert.Nil(t, d.FallbackFirstNames("female", 1890))
+
+	// すべての市区町村に、データセットにある州との対応がある
+	for _, city := range d.List(Cities).Entries {
+		states := d.StatesOf(city)
+		require.NotEmpty(t, states, city)
+		for _, state := range states {
+			_, ok := d.List(States).Find(state)
+			assert.True(t, ok, "%s: %s", city, state)
+		}
+	}
+}
+
+func TestParseCityStates(t *testing.T) {
+	cityStates, err := ParseCityStates("city_states.txt", []byte("# source: test\nAustin\tTexas\nSpringfield\tIllinois, Missouri\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"Austin": {"Texas"}, "Springfield": {"Illinois", "Missouri"}}, cityStates)
+
+	for name, content := range map[string]string{
+		"州が無い": "Austin\n",
+		"州が空":  "Austin\tTexas,\n",
+		"重複":   "Austin\tTexas\nAustin\tTexas\n",
+		"空":    "# source: test\n",
+	} {
+		_, err := ParseCityStates("city_states.txt", []byte(content))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseWeighted(t *testing.T) {
+	list, err := Parse("last_names", "last.txt", []byte("Smith\t828.19\nJohnson\t655.24\nAaron\t0.5\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Smith", "Johnson", "Aaron"}, list.Entries)
+	assert.Equal(t, []float64{828.19, 655.24, 0.5}, list.Weights)
+
+	_, err = Parse("last_names", "last.txt", []byte("Smith\t828.19\nJohnson\n"))
+	assert.Error(t, err)
+	_, err = Parse("last_names", "last.txt", []byte("Smith\t-1\n"))
+	assert.Error(t, err)
+	_, err = Parse("last_names", "last.txt", []byte("Smith\tmany\n"))
+	assert.Error(t, err)
+}
+
+func TestPickWeighted(t *testing.T) {
+	list, err := Parse("names", "names.txt", []byte("A\t70\nB\t20\nC\t10\n"))
+	require.NoError(t, err)
+
+	counts := make(map[string]int)
+	rnd := mathrand.New(mathrand.NewSource(42))
+	const draws = 100000
+	for i := 0; i < draws; i++ {
+		counts[list.Pick(rnd)]++
+	}
+	assert.InDelta(t, 0.7, float64(counts["A"])/draws, 0.01)
+	assert.InDelta(t, 0.2, float64(counts["B"])/draws, 0.01)
+	assert.InDelta(t, 0.1, float64(counts["C"])/draws, 0.01)
+
+	// 同じシードなら同じ結果になる
+	a := mathrand.New(mathrand.NewSource(7))
+	b := mathrand.New(mathrand.NewSource(7))
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, list.Pick(a), list.Pick(b))
+	}
+}
+
+func TestPickNamesByFrequency(t *testing.T) {
+	d, err := Load("../data")
+	require.NoError(t, err)
+
+	// 名前と姓は頻度で重み付けし、多い名前ほど多く選ぶ
+	for _, tt := range []struct {
+		list         string
+		heavy, light string
+	}{
+		{list: LastNames, heavy: "Smith", light: "Steeves"},
+		{list: MaleFirstNames, heavy: "James", light: "Wyatt"},
+		{list: FemaleFirstNames, heavy: "Mary", light: "Nevaeh"},
+	} {
+		list := d.List(tt.list)
+		require.True(t, list.Weighted(), tt.list)
+
+		counts := make(map[string]int)
+		rnd := mathrand.New(mathrand.NewSource(42))
+		const draws = 100000
+		for i := 0; i < draws; i++ {
+			counts[list.Pick(rnd)]++
+		}
+		uniform := float64(draws) / float64(list.Len())
+		assert.Greater(t, float64(counts[tt.heavy]), 5*uniform, tt.heavy)
+		assert.Less(t, float64(counts[tt.light]), uniform, tt.light)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	list, err := Parse("last_names", "last.txt", []byte("Smith\t80\nJohnson\t15\nSanchez\t5\n"))
+	require.NoError(t, err)
+
+	s := list.Filter(func(v string) bool { return v[0] == 'S' })
+	require.NotNil(t, s)
+	assert.Equal(t, []string{"Smith", "Sanchez"}, s.Entries)
+	assert.Equal(t, []float64{80, 5}, s.Weights)
+	rnd := mathrand.New(mathrand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		assert.Contains(t, s.Entries, s.Pick(rnd))
+	}
+
+	assert.Nil(t, list.Filter(func(v string) bool { return v[0] == 'X' }))
+
+	v, ok := list.Find("johnson")
+	assert.True(t, ok)
+	assert.Equal(t, "Johnson", v)
+	_, ok = list.Find("Jones")
+	assert.False(t, ok)
+}
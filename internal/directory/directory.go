@@ -0,0 +1,357 @@
+package directory
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+var (
+	// ErrNotFound は指定した ID のユーザーが存在しないことを表す
+	ErrNotFound = errors.New("ユーザーが見つかりません")
+	// ErrConflict はユーザー名が他のユーザーと重複していることを表す
+	ErrConflict = errors.New("ユーザー名が重複しています")
+)
+
+// DefaultAsOf は基準日が指定されない場合に母集団の生年月日と登録日の基準にする日。
+// 実行した日を基準にすると、再起動や日付の変わり目で母集団が変わってしまう
+var DefaultAsOf = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Meta はユーザーの作成・更新の履歴
+type Meta struct {
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	// Version は更新のたびに 1 ずつ増える
+	Version int `json:"version"`
+}
+
+// Entry はディレクトリに登録されたユーザー
+type Entry struct {
+	// ID は生成されたユーザーでは Login.UUID と同じ
+	ID         string     `json:"id"`
+	ExternalID string     `json:"externalId,omitempty"`
+	Active     bool       `json:"active"`
+	User       model.User `json:"user"`
+	Meta       Meta       `json:"meta"`
+}
+
+// Source は母集団のユーザーを生成するインターフェース。
+// 生成するユーザーのユーザー名は、大文字小文字を区別せずに重複しないこと
+type Source interface {
+	Stream(count int, seed int64, opts generator.Options, fn func(model.User) error) error
+}
+
+// Directory はシードから決まる母集団のユーザーと、その上に重ねた変更を保持する。
+// 変更はメモリ上にのみ保持し、再起動すると母集団だけに戻る
+type Directory struct {
+	src Source
+
+	mu   sync.RWMutex
+	size int
+	seed int64
+	// opts は母集団の生成条件。AsOf は常に設定する
+	opts generator.Options
+	base []Entry
+	// overlay は変更されたユーザーと追加されたユーザー
+	overlay map[string]Entry
+	deleted map[string]bool
+	// added は追加されたユーザーの ID を追加順に並べたもの
+	added []string
+	// usernames は小文字にしたユーザー名から ID を引く
+	usernames map[string]string
+	index     map[string]int
+	now       func() time.Time
+	// snapshotPath が空でなければ、変更のたびにスナップショットを書き出す
+	snapshotPath string
+}
+
+// New は src から opts の条件で size 人の母集団を生成してディレクトリを作成する。
+// opts.AsOf は生年月日と登録日の基準日で、ゼロ値の場合は DefaultAsOf を使う。
+// 同じ size と seed と opts であれば常に同じ母集団になる
+func New(src Source, size int, seed int64, opts generator.Options) (*Directory, error) {
+	if opts.AsOf.IsZero() {
+		opts.AsOf = DefaultAsOf
+	}
+	base, index, err := generate(src, size, seed, opts)
+	if err != nil {
+		return nil, err
+	}
+	d := &Directory{
+		src:   src,
+		size:  size,
+		seed:  seed,
+		opts:  opts,
+		base:  base,
+		index: index,
+		now:   time.Now,
+	}
+	d.clear()
+	return d, nil
+}
+
+// generate は母集団を生成し、ID から母集団の位置を引く索引とともに返す
+func generate(src Source, size int, seed int64, opts generator.Options) ([]Entry, map[string]int, error) {
+	base := make([]Entry, 0, size)
+	index := make(map[string]int, size)
+	err := src.Stream(size, seed, opts, func(u model.User) error {
+		if _, ok := index[u.Login.UUID]; ok {
+			return fmt.Errorf("ユーザーの ID が重複しています: %s", u.Login.UUID)
+		}
+		// 母集団の作成日時は登録日にする
+		registered, _ := time.Parse(time.RFC3339, u.Registered.Date)
+		index[u.Login.UUID] = len(base)
+		base = append(base, Entry{
+			ID:     u.Login.UUID,
+			Active: true,
+			User:   u,
+			Meta:   Meta{Created: registered, LastModified: registered, Version: 1},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("母集団の生成に失敗: %w", err)
+	}
+	return base, index, nil
+}
+
+// Size は母集団の人数を返す
+func (d *Directory) Size() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.size
+}
+
+// Seed は母集団のシード値を返す
+func (d *Directory) Seed() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.seed
+}
+
+// AsOf は母集団の生年月日と登録日の基準日を返す
+func (d *Directory) AsOf() time.Time {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.opts.AsOf
+}
+
+// List は削除されていないユーザーを、母集団の順に続けて追加順で返す
+func (d *Directory) List() []Entry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(d.base)+len(d.added))
+	for _, e := range d.base {
+		if d.deleted[e.ID] {
+			continue
+		}
+		if o, ok := d.overlay[e.ID]; ok {
+			e = o
+		}
+		entries = append(entries, e)
+	}
+	for _, id := range d.added {
+		if !d.deleted[id] {
+			entries = append(entries, d.overlay[id])
+		}
+	}
+	return entries
+}
+
+// Get は ID のユーザーを返す
+func (d *Directory) Get(id string) (Entry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.get(id)
+}
+
+func (d *Directory) get(id string) (Entry, error) {
+	if d.deleted[id] {
+		return Entry{}, ErrNotFound
+	}
+	if e, ok := d.overlay[id]; ok {
+		return e, nil
+	}
+	if i, ok := d.index[id]; ok {
+		return d.base[i], nil
+	}
+	return Entry{}, ErrNotFound
+}
+
+// Lookup はユーザー名が一致するユーザーを大文字小文字を区別せずに探す
+func (d *Directory) Lookup(username string) (Entry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	id, ok := d.usernames[usernameKey(username)]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return d.get(id)
+}
+
+// Create はユーザーを追加する。ID と Meta はディレクトリが割り当てる
+func (d *Directory) Create(e Entry) (Entry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id, err := newID()
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := d.checkUsername(id, e.User.Login.Username); err != nil {
+		return Entry{}, err
+	}
+
+	now := d.now().UTC()
+	e.ID = id
+	e.User.Login.UUID = id
+	e.Meta = Meta{Created: now, LastModified: now, Version: 1}
+
+	d.overlay[id] = e
+	d.added = append(d.added, id)
+	d.setUsername(id, "", e.User.Login.Username)
+	d.persist()
+	return e, nil
+}
+
+// Update は ID のユーザーを fn で書き換える。fn がエラーを返した場合は何も変更しない。
+// ID・作成日時は fn で変更しても元に戻す
+func (d *Directory) Update(id string, fn func(*Entry) error) (Entry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current, err := d.get(id)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	updated := current
+	if err := fn(&updated); err != nil {
+		return Entry{}, err
+	}
+	// ユーザー名を変えない場合は id 自身が持ち主のため、常に確かめてよい
+	if err := d.checkUsername(id, updated.User.Login.Username); err != nil {
+		return Entry{}, err
+	}
+
+	updated.ID = id
+	updated.User.Login.UUID = id
+	updated.Meta = Meta{
+		Created:      current.Meta.Created,
+		LastModified: d.now().UTC(),
+		Version:      current.Meta.Version + 1,
+	}
+	d.overlay[id] = updated
+	d.setUsername(id, current.User.Login.Username, updated.User.Login.Username)
+	d.persist()
+	return updated, nil
+}
+
+// Delete は ID のユーザーを削除する
+func (d *Directory) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, err := d.get(id)
+	if err != nil {
+		return err
+	}
+	d.deleted[id] = true
+	delete(d.overlay, id)
+	d.setUsername(id, e.User.Login.Username, "")
+	d.persist()
+	return nil
+}
+
+// Reset は変更をすべて破棄して母集団だけに戻す
+func (d *Directory) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.clear()
+	d.persist()
+}
+
+// Regenerate は size と seed で母集団を生成し直し、変更をすべて破棄する。基準日は変えない
+func (d *Directory) Regenerate(size int, seed int64) error {
+	d.mu.RLock()
+	opts := d.opts
+	d.mu.RUnlock()
+
+	// 生成には時間がかかるため、ロックの外で生成してから差し替える
+	base, index, err := generate(d.src, size, seed, opts)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.size, d.seed = size, seed
+	d.base, d.index = base, index
+	d.clear()
+	d.persist()
+	return nil
+}
+
+// clear は変更を破棄する。呼び出し側でロックを取る
+func (d *Directory) clear() {
+	d.overlay = make(map[string]Entry)
+	d.deleted = make(map[string]bool)
+	d.added = nil
+	d.indexUsernames()
+}
+
+// indexUsernames は母集団のユーザー名の索引を作り直す。母集団のユーザー名は重複しない
+func (d *Directory) indexUsernames() {
+	d.usernames = make(map[string]string)
+	for _, e := range d.base {
+		if key := usernameKey(e.User.Login.Username); key != "" {
+			d.usernames[key] = e.ID
+		}
+	}
+}
+
+// checkUsername はユーザー名が id 以外のユーザーに使われていないか確かめる
+func (d *Directory) checkUsername(id, username string) error {
+	key := usernameKey(username)
+	if key == "" {
+		return nil
+	}
+	if owner, ok := d.usernames[key]; ok && owner != id {
+		return fmt.Errorf("%w: %s", ErrConflict, username)
+	}
+	return nil
+}
+
+func (d *Directory) setUsername(id, old, new string) {
+	if key := usernameKey(old); key != "" && d.usernames[key] == id {
+		delete(d.usernames, key)
+	}
+	if key := usernameKey(new); key != "" {
+		d.usernames[key] = id
+	}
+}
+
+// usernameKey はユーザー名を大文字小文字を区別せずに比較するためのキーを返す
+func usernameKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// newID は追加するユーザーのランダムな UUID を返す
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&^0xf0 | 0x40
+	b[8] = b[8]&^0xc0 | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
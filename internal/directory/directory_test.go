@@ -0,0 +1,175 @@
+package directory
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource はシードと番号から決まるユーザーを生成する
+type fakeSource struct{}
+
+func (fakeSource) Stream(count int, seed int64, _ generator.Options, fn func(model.User) error) error {
+	for i := 0; i < count; i++ {
+		u := model.User{
+			Name:       model.Name{First: fmt.Sprintf("User%d", i)},
+			Login:      model.Login{UUID: fmt.Sprintf("%08d-0000-4000-8000-%012d", seed, i), Username: fmt.Sprintf("user%d", i)},
+			Registered: model.Registered{Date: "2020-01-02T03:04:05Z"},
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	d, err := New(fakeSource{}, 5, 42, generator.Options{})
+	require.NoError(t, err)
+
+	entries := d.List()
+	require.Len(t, entries, 5)
+	assert.Equal(t, "00000042-0000-4000-8000-000000000000", entries[0].ID)
+	assert.True(t, entries[0].Active)
+	assert.Equal(t, 1, entries[0].Meta.Version)
+	assert.Equal(t, "2020-01-02T03:04:05Z", entries[0].Meta.Created.Format("2006-01-02T15:04:05Z07:00"))
+
+	again, err := New(fakeSource{}, 5, 42, generator.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, entries, again.List())
+}
+
+func TestNewOptions(t *testing.T) {
+	// 母集団の生年月日と登録日は実行した日ではなく基準日から決まる
+	asOf := time.Date(2030, time.June, 15, 0, 0, 0, 0, time.UTC)
+	opts := generator.Options{AsOf: asOf, PortraitBaseURL: "https://example.test/portraits/"}
+	d, err := New(&generator.Generator{}, 20, 3, opts)
+	require.NoError(t, err)
+	assert.Equal(t, asOf, d.AsOf())
+	for _, e := range d.List() {
+		dob, err := time.Parse(time.RFC3339, e.User.Dob.Date)
+		require.NoError(t, err)
+		assert.False(t, dob.After(asOf), e.User.Dob.Date)
+		assert.False(t, e.Meta.Created.After(asOf), e.Meta.Created)
+		// 顔写真は期限の切れる署名付き URL ではなく、転送用のエンドポイントの URL
+		assert.Regexp(t, `^https://example\.test/portraits/(male|female)/portrait%20%28\d+%29\.png$`, e.User.Picture.Thumbnail)
+	}
+
+	again, err := New(&generator.Generator{}, 20, 3, opts)
+	require.NoError(t, err)
+	assert.Equal(t, d.List(), again.List())
+
+	def, err := New(fakeSource{}, 1, 3, generator.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAsOf, def.AsOf())
+}
+
+func TestDirectoryOverlay(t *testing.T) {
+	d, err := New(fakeSource{}, 5, 1, generator.Options{})
+	require.NoError(t, err)
+	base := d.List()
+
+	created, err := d.Create(Entry{Active: true, User: model.User{Login: model.Login{Username: "new"}}})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, created.User.Login.UUID)
+	got, err := d.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	updated, err := d.Update(base[1].ID, func(e *Entry) error {
+		e.Active = false
+		e.ID = "ignored"
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, base[1].ID, updated.ID)
+	assert.False(t, updated.Active)
+	assert.Equal(t, 2, updated.Meta.Version)
+	assert.Equal(t, base[1].Meta.Created, updated.Meta.Created)
+
+	require.NoError(t, d.Delete(base[0].ID))
+	_, err = d.Get(base[0].ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, d.Delete(base[0].ID), ErrNotFound)
+
+	entries := d.List()
+	require.Len(t, entries, 5)
+	assert.Equal(t, updated, entries[0])
+	assert.Equal(t, created, entries[4])
+
+	// fn がエラーを返した場合は変更しない
+	_, err = d.Update(base[2].ID, func(e *Entry) error {
+		e.Active = false
+		return errors.New("失敗")
+	})
+	assert.Error(t, err)
+	got, err = d.Get(base[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, base[2], got)
+
+	d.Reset()
+	assert.Equal(t, base, d.List())
+}
+
+func TestDirectoryUsernameConflict(t *testing.T) {
+	d, err := New(fakeSource{}, 5, 1, generator.Options{})
+	require.NoError(t, err)
+	base := d.List()
+
+	_, err = d.Create(Entry{User: model.User{Login: model.Login{Username: "USER1"}}})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = d.Update(base[0].ID, func(e *Entry) error {
+		e.User.Login.Username = "user2"
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// ユーザー名を変えない更新や、大文字小文字だけを変える更新はできる
+	_, err = d.Update(base[3].ID, func(e *Entry) error {
+		e.Active = false
+		return nil
+	})
+	assert.NoError(t, err)
+	_, err = d.Update(base[3].ID, func(e *Entry) error {
+		e.User.Login.Username = "USER3"
+		return nil
+	})
+	assert.NoError(t, err)
+	got, err := d.Lookup("user3")
+	require.NoError(t, err)
+	assert.Equal(t, base[3].ID, got.ID)
+
+	// 削除したユーザーのユーザー名は再利用できる
+	require.NoError(t, d.Delete(base[2].ID))
+	_, err = d.Create(Entry{User: model.User{Login: model.Login{Username: "user2"}}})
+	assert.NoError(t, err)
+}
+
+func TestDirectoryLookup(t *testing.T) {
+	d, err := New(fakeSource{}, 5, 1, generator.Options{})
+	require.NoError(t, err)
+	base := d.List()
+
+	// ユーザー名は大文字小文字を区別しない
+	got, err := d.Lookup("USER1")
+	require.NoError(t, err)
+	assert.Equal(t, base[1].ID, got.ID)
+
+	_, err = d.Update(base[1].ID, func(e *Entry) error {
+		e.User.Login.Username = "renamed"
+		return nil
+	})
+	require.NoError(t, err)
+	got, err = d.Lookup("renamed")
+	require.NoError(t, err)
+	assert.Equal(t, base[1].ID, got.ID)
+	_, err = d.Lookup("user1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
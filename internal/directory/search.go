@@ -0,0 +1,106 @@
+package directory
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidQuery は検索条件が不正なことを表す
+var ErrInvalidQuery = errors.New("検索条件が不正です")
+
+// Query はユーザーの検索条件。文字列の条件は大文字小文字を区別しない部分一致になる
+type Query struct {
+	// Q は名前・メールアドレス・ユーザー名・都市のいずれかに一致する
+	Q     string
+	Name  string
+	Email string
+	City  string
+	// Sort は並べ替えに使う項目。空の場合はディレクトリの順になる
+	Sort string
+	Desc bool
+	// Offset と Limit は並べ替えた後の範囲。Limit が 0 以下の場合は Offset 以降をすべて返す
+	Offset int
+	Limit  int
+}
+
+// sortKeys は Sort に指定できる項目と比較関数
+var sortKeys = map[string]func(a, b Entry) int{
+	"name": func(a, b Entry) int {
+		return cmp.Or(
+			compareFold(a.User.Name.Last, b.User.Name.Last),
+			compareFold(a.User.Name.First, b.User.Name.First),
+		)
+	},
+	"email":    func(a, b Entry) int { return compareFold(a.User.Email, b.User.Email) },
+	"username": func(a, b Entry) int { return compareFold(a.User.Login.Username, b.User.Login.Username) },
+	"city":     func(a, b Entry) int { return compareFold(a.User.Location.City, b.User.Location.City) },
+	"created":  func(a, b Entry) int { return a.Meta.Created.Compare(b.Meta.Created) },
+	"modified": func(a, b Entry) int { return a.Meta.LastModified.Compare(b.Meta.LastModified) },
+}
+
+// SortKeys は Sort に指定できる項目の一覧を返す
+func SortKeys() []string {
+	keys := make([]string, 0, len(sortKeys))
+	for k := range sortKeys {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Search は条件に一致するユーザーを並べ替えて返す。total は範囲で絞る前の件数
+func (d *Directory) Search(q Query) ([]Entry, int, error) {
+	var less func(a, b Entry) int
+	if q.Sort != "" {
+		var ok bool
+		if less, ok = sortKeys[strings.ToLower(q.Sort)]; !ok {
+			return nil, 0, fmt.Errorf("%w: 並べ替えの項目は %s のいずれかを指定してください: %q", ErrInvalidQuery, strings.Join(SortKeys(), ", "), q.Sort)
+		}
+	}
+
+	var matched []Entry
+	for _, e := range d.List() {
+		if q.match(e) {
+			matched = append(matched, e)
+		}
+	}
+	if less != nil {
+		slices.SortStableFunc(matched, func(a, b Entry) int {
+			if q.Desc {
+				return less(b, a)
+			}
+			return less(a, b)
+		})
+	}
+
+	total := len(matched)
+	start := min(max(q.Offset, 0), total)
+	end := total
+	if q.Limit > 0 {
+		end = min(start+q.Limit, total)
+	}
+	return matched[start:end], total, nil
+}
+
+func (q Query) match(e Entry) bool {
+	u := e.User
+	name := u.Name.First + " " + u.Name.Last
+	if q.Q != "" && !containsFold(name, q.Q) && !containsFold(u.Email, q.Q) &&
+		!containsFold(u.Login.Username, q.Q) && !containsFold(u.Location.City, q.Q) {
+		return false
+	}
+	return (q.Name == "" || containsFold(name, q.Name)) &&
+		(q.Email == "" || containsFold(u.Email, q.Email)) &&
+		(q.City == "" || containsFold(u.Location.City, q.City))
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
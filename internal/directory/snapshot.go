@@ -0,0 +1,170 @@
+package directory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot はディレクトリの状態。母集団はシードから生成し直せるため、変更だけを保持する
+type Snapshot struct {
+	Size int   `json:"size"`
+	Seed int64 `json:"seed"`
+	// AsOf は母集団の生年月日と登録日の基準日
+	AsOf time.Time `json:"asOf"`
+	// Changed は変更された母集団のユーザー
+	Changed []Entry `json:"changed"`
+	// Added は追加されたユーザー。追加順に並べる
+	Added []Entry `json:"added"`
+	// Deleted は削除されたユーザーの ID
+	Deleted []string `json:"deleted"`
+}
+
+// Snapshot は現在の状態を返す
+func (d *Directory) Snapshot() Snapshot {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.snapshot()
+}
+
+func (d *Directory) snapshot() Snapshot {
+	s := Snapshot{Size: d.size, Seed: d.seed, AsOf: d.opts.AsOf, Changed: []Entry{}, Added: []Entry{}, Deleted: []string{}}
+	for _, e := range d.base {
+		if d.deleted[e.ID] {
+			s.Deleted = append(s.Deleted, e.ID)
+		} else if o, ok := d.overlay[e.ID]; ok {
+			s.Changed = append(s.Changed, o)
+		}
+	}
+	for _, id := range d.added {
+		if !d.deleted[id] {
+			s.Added = append(s.Added, d.overlay[id])
+		}
+	}
+	return s
+}
+
+// Restore はスナップショットの状態に戻す。母集団の人数・シード値・基準日が異なる場合は生成し直す。
+// 基準日を持たないスナップショットは現在の基準日で作られたものとして扱う
+func (d *Directory) Restore(s Snapshot) error {
+	d.mu.RLock()
+	if s.AsOf.IsZero() {
+		s.AsOf = d.opts.AsOf
+	}
+	same := s.Size == d.size && s.Seed == d.seed && s.AsOf.Equal(d.opts.AsOf)
+	opts := d.opts
+	d.mu.RUnlock()
+	opts.AsOf = s.AsOf
+
+	var base []Entry
+	var index map[string]int
+	if !same {
+		var err error
+		if base, index, err = generate(d.src, s.Size, s.Seed, opts); err != nil {
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if same {
+		index = d.index
+	}
+	for _, e := range s.Changed {
+		if _, ok := index[e.ID]; !ok {
+			return fmt.Errorf("スナップショットのユーザーが母集団にありません: %s", e.ID)
+		}
+	}
+
+	if !same {
+		d.size, d.seed, d.opts = s.Size, s.Seed, opts
+		d.base, d.index = base, index
+	}
+	d.clear()
+	for _, id := range s.Deleted {
+		if _, ok := d.index[id]; ok {
+			d.deleted[id] = true
+		}
+	}
+	for _, e := range s.Changed {
+		d.overlay[e.ID] = e
+	}
+	for _, e := range s.Added {
+		d.overlay[e.ID] = e
+		d.added = append(d.added, e.ID)
+	}
+
+	// 索引は母集団の後に変更を反映して作り直す
+	for id := range d.deleted {
+		if i, ok := d.index[id]; ok {
+			d.setUsername(id, d.base[i].User.Login.Username, "")
+		}
+	}
+	for _, e := range s.Changed {
+		d.setUsername(e.ID, d.base[d.index[e.ID]].User.Login.Username, e.User.Login.Username)
+	}
+	for _, e := range s.Added {
+		d.setUsername(e.ID, "", e.User.Login.Username)
+	}
+	return nil
+}
+
+// EnableSnapshot は path のスナップショットがあれば読み込み、以降は変更のたびに書き出す
+func (d *Directory) EnableSnapshot(path string) error {
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("スナップショットの読み込みに失敗: %w", err)
+	default:
+		var s Snapshot
+		if err := json.Unmarshal(b, &s); err != nil {
+			return fmt.Errorf("スナップショットの読み込みに失敗: %s: %w", path, err)
+		}
+		if err := d.Restore(s); err != nil {
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.snapshotPath = path
+	return nil
+}
+
+// persist はスナップショットを書き出す。呼び出し側でロックを取る。
+// 書き出しに失敗しても変更は取り消さず、ログに残す
+func (d *Directory) persist() {
+	if d.snapshotPath == "" {
+		return
+	}
+	if err := writeSnapshot(d.snapshotPath, d.snapshot()); err != nil {
+		log.Printf("スナップショットの書き出しに失敗: %v", err)
+	}
+}
+
+// writeSnapshot は書きかけのファイルが残らないよう、一時ファイルに書いてから置き換える
+func writeSnapshot(path string, s Snapshot) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,122 @@
+package directory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "directory.json")
+
+	d, err := New(fakeSource{}, 5, 1, generator.Options{})
+	require.NoError(t, err)
+	require.NoError(t, d.EnableSnapshot(path))
+	base := d.List()
+
+	_, err = d.Update(base[1].ID, func(e *Entry) error {
+		e.User.Login.Username = "renamed"
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, d.Delete(base[2].ID))
+	_, err = d.Create(Entry{Active: true, User: model.User{Login: model.Login{Username: "new"}}})
+	require.NoError(t, err)
+	want := d.List()
+
+	// 書き出したスナップショットから同じ状態に戻る
+	restored, err := New(fakeSource{}, 5, 1, generator.Options{})
+	require.NoError(t, err)
+	require.NoError(t, restored.EnableSnapshot(path))
+	assert.Equal(t, want, restored.List())
+
+	// 基準日もスナップショットに残し、異なる場合は生成し直す
+	dated, err := New(fakeSource{}, 5, 1, generator.Options{AsOf: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.NoError(t, dated.EnableSnapshot(path))
+	assert.Equal(t, DefaultAsOf, dated.AsOf())
+	assert.Equal(t, want, dated.List())
+
+	got, err := restored.Lookup("renamed")
+	require.NoError(t, err)
+	assert.Equal(t, base[1].ID, got.ID)
+	_, err = restored.Create(Entry{User: model.User{Login: model.Login{Username: "user2"}}})
+	assert.NoError(t, err, "削除したユーザーのユーザー名は再利用できる")
+	_, err = restored.Create(Entry{User: model.User{Login: model.Login{Username: "NEW"}}})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// 母集団の人数とシード値が異なる場合は生成し直す
+	other, err := New(fakeSource{}, 3, 2, generator.Options{})
+	require.NoError(t, err)
+	require.NoError(t, other.EnableSnapshot(path))
+	assert.Equal(t, 5, other.Size())
+	assert.Equal(t, int64(1), other.Seed())
+
+	require.NoError(t, restored.Regenerate(2, 9))
+	assert.Len(t, restored.List(), 2)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"seed": 9`)
+}
+
+func TestRestoreUnknownEntry(t *testing.T) {
+	d, err := New(fakeSource{}, 3, 1, generator.Options{})
+	require.NoError(t, err)
+	before := d.List()
+
+	err = d.Restore(Snapshot{Size: 3, Seed: 1, Changed: []Entry{{ID: "missing"}}})
+	assert.Error(t, err)
+	assert.Equal(t, before, d.List())
+}
+
+func TestSearch(t *testing.T) {
+	d, err := New(fakeSource{}, 5, 1, generator.Options{})
+	require.NoError(t, err)
+	base := d.List()
+
+	_, err = d.Update(base[3].ID, func(e *Entry) error {
+		e.User.Location.City = "Austin"
+		e.User.Email = "zed@example.com"
+		return nil
+	})
+	require.NoError(t, err)
+
+	entries, total, err := d.Search(Query{City: "aus"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, base[3].ID, entries[0].ID)
+
+	entries, total, err = d.Search(Query{Q: "USER", Sort: "name", Desc: true, Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "User3", entries[0].User.Name.First)
+	assert.Equal(t, "User2", entries[1].User.Name.First)
+
+	// 同じ値の場合はディレクトリの順を保つ
+	entries, _, err = d.Search(Query{Sort: "email"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"User0", "User1", "User2", "User4", "User3"}, firstNames(entries))
+
+	entries, total, err = d.Search(Query{Offset: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Empty(t, entries)
+
+	_, _, err = d.Search(Query{Sort: "age"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
+func firstNames(entries []Entry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.User.Name.First)
+	}
+	return names
+}
@@ -0,0 +1,273 @@
+package dirty
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// corruptor はユーザーの1つの項目を破損させ、加えた破損を返す
+type corruptor func(u *model.User, rnd *mathrand.Rand) model.Corruption
+
+// corruptors は項目のまとまりごとの破損のさせ方
+var corruptors = map[string][]corruptor{
+	FieldName: {
+		swapNames,
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			path, s := nameField(u, rnd)
+			return replace(path, s, Whitespace, addWhitespace(*s, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			path, s := nameField(u, rnd)
+			return replace(path, s, Encoding, mixEncoding(*s, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			path, s := nameField(u, rnd)
+			return remove(path, s)
+		},
+	},
+	FieldEmail: {
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			return replace("email", &u.Email, Malformed, malformEmail(u.Email, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			return replace("email", &u.Email, Whitespace, addWhitespace(u.Email, rnd))
+		},
+		func(u *model.User, _ *mathrand.Rand) model.Corruption {
+			return remove("email", &u.Email)
+		},
+	},
+	FieldPhone: {
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			path, s := phoneField(u, rnd)
+			return replace(path, s, Malformed, malformPhone(*s, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			path, s := phoneField(u, rnd)
+			return replace(path, s, Whitespace, addWhitespace(*s, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			path, s := phoneField(u, rnd)
+			return remove(path, s)
+		},
+	},
+	FieldDob: {
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			return replace("dob.date", &u.Dob.Date, OutOfRange, outOfRangeDate(u.Dob.Date, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			c := model.Corruption{Field: "dob.age", Kind: OutOfRange, Original: u.Dob.Age}
+			if rnd.Intn(2) == 0 {
+				u.Dob.Age = -1 - rnd.Intn(5)
+			} else {
+				u.Dob.Age = 150 + rnd.Intn(50)
+			}
+			c.Value = u.Dob.Age
+			return c
+		},
+		func(u *model.User, _ *mathrand.Rand) model.Corruption {
+			return model.Corruption{Field: "dob.age", Kind: WrongType, Original: u.Dob.Age, Value: strconv.Itoa(u.Dob.Age)}
+		},
+		func(u *model.User, _ *mathrand.Rand) model.Corruption {
+			return remove("dob.date", &u.Dob.Date)
+		},
+	},
+	FieldRegistered: {
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			return replace("registered.date", &u.Registered.Date, OutOfRange, outOfRangeDate(u.Registered.Date, rnd))
+		},
+		func(u *model.User, _ *mathrand.Rand) model.Corruption {
+			// 日時を UNIX 時間の数値にする
+			var unix int64
+			if t, err := time.Parse(time.RFC3339, u.Registered.Date); err == nil {
+				unix = t.Unix()
+			}
+			return model.Corruption{Field: "registered.date", Kind: WrongType, Original: u.Registered.Date, Value: unix}
+		},
+		func(u *model.User, _ *mathrand.Rand) model.Corruption {
+			return model.Corruption{Field: "registered.age", Kind: WrongType, Original: u.Registered.Age, Value: strconv.Itoa(u.Registered.Age)}
+		},
+	},
+	FieldLocation: {
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			return replace("location.city", &u.Location.City, Whitespace, addWhitespace(u.Location.City, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			if rnd.Intn(2) == 0 {
+				return replace("location.city", &u.Location.City, Encoding, mixEncoding(u.Location.City, rnd))
+			}
+			return replace("location.street.name", &u.Location.Street.Name, Encoding, mixEncoding(u.Location.Street.Name, rnd))
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			if rnd.Intn(2) == 0 {
+				return model.Corruption{Field: "location.street.number", Kind: WrongType, Original: u.Location.Street.Number, Value: strconv.Itoa(u.Location.Street.Number)}
+			}
+			// 郵便番号を数値にすると先頭の 0 も失われる
+			n, _ := strconv.Atoi(u.Location.Postcode)
+			return model.Corruption{Field: "location.postcode", Kind: WrongType, Original: u.Location.Postcode, Value: n}
+		},
+		func(u *model.User, rnd *mathrand.Rand) model.Corruption {
+			if rnd.Intn(2) == 0 {
+				return remove("location.postcode", &u.Location.Postcode)
+			}
+			return remove("location.state", &u.Location.State)
+		},
+	},
+}
+
+// replace は文字列の項目を v に差し替える
+func replace(path string, s *string, kind, v string) model.Corruption {
+	c := model.Corruption{Field: path, Kind: kind, Original: *s, Value: v}
+	*s = v
+	return c
+}
+
+// remove は文字列の項目を欠落させる
+func remove(path string, s *string) model.Corruption {
+	c := model.Corruption{Field: path, Kind: Missing, Original: *s}
+	*s = ""
+	return c
+}
+
+func swapNames(u *model.User, _ *mathrand.Rand) model.Corruption {
+	c := model.Corruption{Field: "name", Kind: Swapped, Original: u.Name}
+	u.Name.First, u.Name.Last = u.Name.Last, u.Name.First
+	c.Value = u.Name
+	return c
+}
+
+func nameField(u *model.User, rnd *mathrand.Rand) (string, *string) {
+	if rnd.Intn(2) == 0 {
+		return "name.first", &u.Name.First
+	}
+	return "name.last", &u.Name.Last
+}
+
+func phoneField(u *model.User, rnd *mathrand.Rand) (string, *string) {
+	if rnd.Intn(2) == 0 || u.Cell == "" {
+		return "phone", &u.Phone
+	}
+	return "cell", &u.Cell
+}
+
+// addWhitespace は前後や途中に余分な空白を加える。改行やノーブレークスペースも使う
+func addWhitespace(s string, rnd *mathrand.Rand) string {
+	switch rnd.Intn(6) {
+	case 0:
+		return " " + s
+	case 1:
+		return s + "\u00a0"
+	case 2:
+		return "\t" + s
+	case 3:
+		return s + "\n"
+	case 4:
+		return " " + s + " "
+	default:
+		if len(s) < 2 {
+			return s + " "
+		}
+		i := 1 + rnd.Intn(len(s)-1)
+		return s[:i] + "  " + s[i:]
+	}
+}
+
+// accents は ASCII の母音と、アクセントを付けた文字
+var accents = map[byte]string{'a': "á", 'e': "é", 'i': "í", 'o': "ó", 'u': "ü", 'A': "Á", 'E': "É", 'O': "Ö"}
+
+// mixEncoding は母音の1つにアクセントを付け、UTF-8 を Latin-1 として読んだ文字化け (é → Ã©) か、
+// 結合文字で分解した形 (NFD) にする
+func mixEncoding(s string, rnd *mathrand.Rand) string {
+	var vowels []int
+	for i := 0; i < len(s); i++ {
+		if _, ok := accents[s[i]]; ok {
+			vowels = append(vowels, i)
+		}
+	}
+	if len(vowels) == 0 {
+		return s + latin1("é")
+	}
+	i := vowels[rnd.Intn(len(vowels))]
+	if rnd.Intn(2) == 0 {
+		return s[:i] + latin1(accents[s[i]]) + s[i+1:]
+	}
+	// 結合用アキュート・アクセント (U+0301) を後ろに付ける
+	return s[:i+1] + "́" + s[i+1:]
+}
+
+// latin1 は UTF-8 のバイト列を Latin-1 の文字として読み直す
+func latin1(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(rune(s[i]))
+	}
+	return b.String()
+}
+
+func malformEmail(email string, rnd *mathrand.Rand) string {
+	local, domain, _ := strings.Cut(email, "@")
+	switch rnd.Intn(6) {
+	case 0:
+		return local + "@@" + domain
+	case 1:
+		return local + "." + domain
+	case 2:
+		return local + "@" + strings.Replace(domain, ".", "..", 1)
+	case 3:
+		if i := strings.LastIndex(domain, "."); i > 0 {
+			return local + "@" + domain[:i]
+		}
+		return local + "@"
+	case 4:
+		return local + "@" + domain + "."
+	default:
+		return local + "@"
+	}
+}
+
+func malformPhone(phone string, rnd *mathrand.Rand) string {
+	if len(phone) < 4 {
+		return phone + "x"
+	}
+	switch rnd.Intn(4) {
+	case 0:
+		// 桁が足りない
+		return phone[:len(phone)-3]
+	case 1:
+		// 桁が多すぎる
+		return phone + fmt.Sprint(10+rnd.Intn(90))
+	case 2:
+		// 数字の 0 と 1 を英字の O と l にする
+		return strings.NewReplacer("0", "O", "1", "l").Replace(phone)
+	default:
+		return strings.NewReplacer(" ", ".", "-", ".", "(", "", ")", "").Replace(phone) + " ext."
+	}
+}
+
+// outOfRangeDate は RFC 3339 の日時を、遠い過去・未来、存在しない月日、ゼロの日付のいずれかにする
+func outOfRangeDate(date string, rnd *mathrand.Rand) string {
+	rest := "T00:00:00Z"
+	if len(date) >= 10 {
+		rest = date[10:]
+	}
+	year, md := "1990", "-01-01"
+	if len(date) >= 10 {
+		year, md = date[:4], date[4:10]
+	}
+	switch rnd.Intn(5) {
+	case 0:
+		return fmt.Sprintf("%04d%s%s", 1800+rnd.Intn(50), md, rest)
+	case 1:
+		return fmt.Sprintf("%04d%s%s", 2150+rnd.Intn(50), md, rest)
+	case 2:
+		return year + "-13" + md[3:] + rest
+	case 3:
+		return year + "-02-30" + rest
+	default:
+		return "0000-00-00" + rest
+	}
+}
@@ -0,0 +1,129 @@
+// Package dirty は ETL や入力検証のテスト用に、生成したユーザーの一部を決定論的に破損させる。
+// 加えた破損は model.User の Corruptions に記録し、マニフェストとして別に書き出せる
+package dirty
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// 破損の種類
+const (
+	// Missing は項目の欠落。JSON では項目を書き出さず、それ以外の形式では空の値になる
+	Missing = "missing"
+	// WrongType は JSON の型の誤り (数値の文字列など)。JSON 以外の形式では元の値のまま
+	WrongType = "wrong_type"
+	// Malformed は形式の崩れたメールアドレスや電話番号
+	Malformed = "malformed"
+	// Swapped は名と姓の入れ替え
+	Swapped = "swapped"
+	// Whitespace は前後や途中の余分な空白
+	Whitespace = "whitespace"
+	// Encoding は文字コードの混在 (UTF-8 を Latin-1 として読んだ文字化け、正規化されていない文字)
+	Encoding = "encoding"
+	// OutOfRange は範囲外や存在しない日付、負の年齢など
+	OutOfRange = "out_of_range"
+)
+
+// 破損させる項目のまとまり。率はこの単位で指定する
+const (
+	FieldName       = "name"
+	FieldEmail      = "email"
+	FieldPhone      = "phone"
+	FieldDob        = "dob"
+	FieldRegistered = "registered"
+	FieldLocation   = "location"
+)
+
+// fields は破損させる項目の順序。乱数の消費順を固定するため、この順に判定する
+var fields = []string{FieldName, FieldEmail, FieldPhone, FieldDob, FieldRegistered, FieldLocation}
+
+// Fields は率を指定できる項目の一覧を返す
+func Fields() []string {
+	return slices.Clone(fields)
+}
+
+// Rates は破損させる割合
+type Rates struct {
+	// All はいずれか1つの項目を破損させるユーザーの割合
+	All float64
+	// Fields は項目ごとの割合。All とは別に判定する
+	Fields map[string]float64
+}
+
+// Enabled は破損させる設定があるかを返す
+func (r Rates) Enabled() bool {
+	return r.All > 0 || len(r.Fields) > 0
+}
+
+// ParseRates は "0.05" や "0.05,email:0.2,phone:0.1" の形の指定を読み取る。
+// 項目の無い数値は All、"項目:割合" は項目ごとの割合になる
+func ParseRates(s string) (Rates, error) {
+	var r Rates
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field, rate, ok := strings.Cut(part, ":")
+		if !ok {
+			field, rate = "", part
+		}
+		p, err := strconv.ParseFloat(rate, 64)
+		if err != nil || p < 0 || p > 1 {
+			return Rates{}, fmt.Errorf("割合は 0 から 1 の数値で指定してください: %q", part)
+		}
+		switch {
+		case field == "":
+			r.All = p
+		case slices.Contains(fields, field):
+			if r.Fields == nil {
+				r.Fields = make(map[string]float64)
+			}
+			r.Fields[field] = p
+		default:
+			return Rates{}, fmt.Errorf("項目は %s のいずれかを指定してください: %q", strings.Join(fields, ", "), field)
+		}
+	}
+	return r, nil
+}
+
+// Apply は割合に従ってユーザーを破損させ、加えた破損を u.Corruptions に記録する。
+// 同じユーザーと乱数であれば同じ破損になる
+func Apply(u *model.User, r Rates, rnd *mathrand.Rand) {
+	var picked []string
+	if rnd.Float64() < r.All {
+		picked = append(picked, fields[rnd.Intn(len(fields))])
+	}
+	for _, f := range fields {
+		if rate, ok := r.Fields[f]; ok && rnd.Float64() < rate && !slices.Contains(picked, f) {
+			picked = append(picked, f)
+		}
+	}
+	for _, f := range picked {
+		cs := corruptors[f]
+		u.Corruptions = append(u.Corruptions, cs[rnd.Intn(len(cs))](u, rnd))
+	}
+}
+
+// Entry はマニフェストの1行。何番目のどのユーザーにどの破損を加えたかを表す
+type Entry struct {
+	// Index は生成した順の 0 から始まる番号
+	Index int    `json:"index"`
+	UUID  string `json:"uuid"`
+	model.Corruption
+}
+
+// Manifest はユーザーに加えた破損をマニフェストの行にする
+func Manifest(index int, u model.User) []Entry {
+	entries := make([]Entry, 0, len(u.Corruptions))
+	for _, c := range u.Corruptions {
+		entries = append(entries, Entry{Index: index, UUID: u.Login.UUID, Corruption: c})
+	}
+	return entries
+}
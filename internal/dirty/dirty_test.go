@@ -0,0 +1,93 @@
+package dirty
+
+import (
+	"encoding/json"
+	mathrand "math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+func TestParseRates(t *testing.T) {
+	r, err := ParseRates("0.05, email:0.2,phone:1")
+	require.NoError(t, err)
+	assert.Equal(t, Rates{All: 0.05, Fields: map[string]float64{FieldEmail: 0.2, FieldPhone: 1}}, r)
+	assert.True(t, r.Enabled())
+
+	r, err = ParseRates("")
+	require.NoError(t, err)
+	assert.False(t, r.Enabled())
+
+	for _, s := range []string{"1.5", "-0.1", "x", "email:", "zip:0.1"} {
+		_, err := ParseRates(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func testUser() model.User {
+	return model.User{
+		Name:       model.Name{Title: "Ms", First: "Jane", Last: "Doe"},
+		Location:   model.Location{Street: model.Street{Number: 12, Name: "Oak St"}, City: "Austin", State: "Texas", Postcode: "01234"},
+		Email:      "jane.doe@example.com",
+		Login:      model.Login{UUID: "00000000-0000-4000-8000-000000000000"},
+		Dob:        model.Dob{Date: "1990-05-06T00:00:00Z", Age: 35},
+		Registered: model.Registered{Date: "2015-01-02T03:04:05Z", Age: 10},
+		Phone:      "(512) 555-0100",
+		Cell:       "(512) 555-0199",
+	}
+}
+
+func TestApply(t *testing.T) {
+	rates := Rates{All: 0.5, Fields: map[string]float64{FieldEmail: 1}}
+	kinds := map[string]bool{}
+	for seed := int64(0); seed < 300; seed++ {
+		u := testUser()
+		Apply(&u, rates, mathrand.New(mathrand.NewSource(seed)))
+		require.NotEmpty(t, u.Corruptions)
+
+		// 同じ乱数であれば同じ破損になる
+		again := testUser()
+		Apply(&again, rates, mathrand.New(mathrand.NewSource(seed)))
+		assert.Equal(t, u, again)
+
+		var email bool
+		for _, c := range u.Corruptions {
+			kinds[c.Kind] = true
+			email = email || c.Field == "email"
+			assert.NotEqual(t, c.Original, c.Value, "%+v", c)
+		}
+		assert.True(t, email, "率 1 の項目は必ず破損させる")
+	}
+	for _, k := range []string{Missing, WrongType, Malformed, Swapped, Whitespace, Encoding, OutOfRange} {
+		assert.True(t, kinds[k], k)
+	}
+}
+
+func TestMarshalCorruptedUser(t *testing.T) {
+	u := testUser()
+	u.Corruptions = []model.Corruption{
+		{Field: "dob.age", Kind: WrongType, Original: 35, Value: "35"},
+		{Field: "email", Kind: Missing, Original: u.Email},
+		{Field: "location.street.number", Kind: WrongType, Original: 12, Value: "12"},
+	}
+	b, err := json.Marshal(u)
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(b, &doc))
+	assert.Equal(t, "35", doc["dob"].(map[string]any)["age"])
+	assert.NotContains(t, doc, "email")
+	assert.Equal(t, "12", doc["location"].(map[string]any)["street"].(map[string]any)["number"])
+	// キーの順序は保つ
+	assert.Less(t, strings.Index(string(b), `"gender"`), strings.Index(string(b), `"name"`))
+	assert.Less(t, strings.Index(string(b), `"login"`), strings.Index(string(b), `"dob"`))
+
+	entries := Manifest(3, u)
+	require.Len(t, entries, 3)
+	assert.Equal(t, 3, entries[0].Index)
+	assert.Equal(t, u.Login.UUID, entries[0].UUID)
+}
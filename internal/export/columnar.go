@@ -0,0 +1,177 @@
+package export
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// defaultRowGroupSize は Parquet の行グループ・Arrow のレコードバッチにまとめる既定の行数
+const defaultRowGroupSize = 10000
+
+// arrowSchema は model.User の列を平坦化した Arrow のスキーマを返す。
+// 列名は SQL と同じく "_" で連結する (例: location_street_number)。
+// 日時は UTC のタイムスタンプ、座標は浮動小数点数になる
+func arrowSchema() *arrow.Schema {
+	cols := Columns()
+	fields := make([]arrow.Field, len(cols))
+	for i, c := range cols {
+		fields[i] = arrow.Field{Name: c.Name("_"), Type: arrowType(c), Nullable: c.Nullable}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+func arrowType(c Column) arrow.DataType {
+	name := c.Name(".")
+	if dateFields[name] {
+		return &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}
+	}
+	if strings.HasPrefix(name, geoField+".") {
+		return arrow.PrimitiveTypes.Float64
+	}
+	switch c.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return arrow.PrimitiveTypes.Int64
+	case reflect.Float32, reflect.Float64:
+		return arrow.PrimitiveTypes.Float64
+	case reflect.Bool:
+		return arrow.FixedWidthTypes.Boolean
+	}
+	return arrow.BinaryTypes.String
+}
+
+// appendValue は列の値をビルダーに追加する。変換できない値は null になる
+func appendValue(b array.Builder, v any) {
+	if v == nil {
+		b.AppendNull()
+		return
+	}
+	switch b := b.(type) {
+	case *array.TimestampBuilder:
+		t, err := time.Parse(time.RFC3339, formatValue(v))
+		if err != nil {
+			b.AppendNull()
+			return
+		}
+		b.Append(arrow.Timestamp(t.UnixMilli()))
+	case *array.Float64Builder:
+		f, err := strconv.ParseFloat(formatValue(v), 64)
+		if err != nil {
+			b.AppendNull()
+			return
+		}
+		b.Append(f)
+	case *array.Int64Builder:
+		rv := reflect.ValueOf(v)
+		if rv.CanInt() {
+			b.Append(rv.Int())
+		} else {
+			b.Append(int64(rv.Uint()))
+		}
+	case *array.BooleanBuilder:
+		b.Append(v.(bool))
+	case *array.StringBuilder:
+		b.Append(formatValue(v))
+	default:
+		b.AppendNull()
+	}
+}
+
+// recordWriter は Parquet と Arrow IPC の書き出し先
+type recordWriter interface {
+	Write(rec arrow.Record) error
+	Close() error
+}
+
+// columnarEncoder はユーザーを列ごとに溜め、rowGroupSize 行ごとに書き出す。
+// 書き出し先は最初の書き込みまで作らないため、エンコーダーの作成時には何も出力しない
+type columnarEncoder struct {
+	w            io.Writer
+	open         func(w io.Writer, schema *arrow.Schema) (recordWriter, error)
+	rowGroupSize int
+
+	schema  *arrow.Schema
+	builder *array.RecordBuilder
+	out     recordWriter
+	rows    int
+}
+
+func newColumnarEncoder(w io.Writer, opts Options, open func(io.Writer, *arrow.Schema) (recordWriter, error)) *columnarEncoder {
+	size := opts.RowGroupSize
+	if size <= 0 {
+		size = defaultRowGroupSize
+	}
+	schema := arrowSchema()
+	return &columnarEncoder{
+		// 書き出し先が io.Closer でも閉じないように io.Writer だけを渡す
+		w:            struct{ io.Writer }{w},
+		open:         open,
+		rowGroupSize: size,
+		schema:       schema,
+		builder:      array.NewRecordBuilder(memory.DefaultAllocator, schema),
+	}
+}
+
+func newParquetEncoder(w io.Writer, opts Options) (Encoder, error) {
+	return newColumnarEncoder(w, opts, func(w io.Writer, schema *arrow.Schema) (recordWriter, error) {
+		props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+		return pqarrow.NewFileWriter(schema, w, props, pqarrow.DefaultWriterProps())
+	}), nil
+}
+
+func newArrowEncoder(w io.Writer, opts Options) (Encoder, error) {
+	return newColumnarEncoder(w, opts, func(w io.Writer, schema *arrow.Schema) (recordWriter, error) {
+		return ipc.NewWriter(w, ipc.WithSchema(schema)), nil
+	}), nil
+}
+
+func (e *columnarEncoder) Encode(u model.User) error {
+	for i, c := range Columns() {
+		appendValue(e.builder.Field(i), c.Value(&u))
+	}
+	e.rows++
+	if e.rows >= e.rowGroupSize {
+		return e.flush()
+	}
+	return nil
+}
+
+// flush は溜めた行を1つの行グループ (レコードバッチ) として書き出す
+func (e *columnarEncoder) flush() error {
+	if e.out == nil {
+		out, err := e.open(e.w, e.schema)
+		if err != nil {
+			return err
+		}
+		e.out = out
+	}
+	if e.rows == 0 {
+		return nil
+	}
+
+	rec := e.builder.NewRecord()
+	defer rec.Release()
+	e.rows = 0
+	return e.out.Write(rec)
+}
+
+func (e *columnarEncoder) Close() error {
+	defer e.builder.Release()
+	if err := e.flush(); err != nil {
+		return err
+	}
+	return e.out.Close()
+}
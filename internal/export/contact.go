@@ -0,0 +1,290 @@
+package export
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// defaultBaseDN は LDIF のエントリを置く既定のベース DN
+const defaultBaseDN = "ou=people,dc=example,dc=com"
+
+const (
+	// vcardLineLimit は vCard の1行の最大オクテット数 (RFC 6350 3.2)
+	vcardLineLimit = 75
+	// ldifLineLimit は LDIF の1行の最大文字数 (RFC 2849)
+	ldifLineLimit = 76
+)
+
+// vcardEncoder は vCard 4.0 (RFC 6350) を書き出す
+type vcardEncoder struct {
+	w *bufio.Writer
+}
+
+func newVCardEncoder(w io.Writer, _ Options) (Encoder, error) {
+	return &vcardEncoder{w: bufio.NewWriter(w)}, nil
+}
+
+func (e *vcardEncoder) Encode(u model.User) error {
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:4.0",
+	}
+	add := func(name, value string) {
+		lines = append(lines, name+":"+value)
+	}
+
+	if u.Login.UUID != "" {
+		add("UID", "urn:uuid:"+u.Login.UUID)
+	}
+	add("FN", vcardText(strings.TrimSpace(u.Name.First+" "+u.Name.Last)))
+	add("N", vcardStructured(u.Name.Last, u.Name.First, "", u.Name.Title, ""))
+	switch u.Gender {
+	case "male":
+		add("GENDER", "M")
+	case "female":
+		add("GENDER", "F")
+	}
+	if u.Dob.Date != "" {
+		date, _, _ := strings.Cut(u.Dob.Date, "T")
+		add("BDAY", strings.ReplaceAll(date, "-", ""))
+	}
+
+	loc := u.Location
+	add("ADR;TYPE=home", vcardStructured("", "", streetLine(loc.Street), loc.City, loc.State, loc.Postcode, loc.Country))
+	if lat, lon, ok := coordinates(loc.Coordinates); ok {
+		add("GEO", "geo:"+strconv.FormatFloat(lat, 'f', -1, 64)+","+strconv.FormatFloat(lon, 'f', -1, 64))
+	}
+
+	// E.164 の番号がある場合は tel: の URI、無い場合は国内表記のテキストとして書き出す
+	addTel := func(typ, number, e164 string) {
+		switch {
+		case e164 != "":
+			add("TEL;TYPE="+typ+";VALUE=uri", "tel:"+e164)
+		case number != "":
+			add("TEL;TYPE="+typ+";VALUE=text", vcardText(number))
+		}
+	}
+	addTel("home,voice", u.Phone, u.PhoneE164)
+	addTel("cell", u.Cell, u.CellE164)
+	if u.Email != "" {
+		add("EMAIL;TYPE=home", vcardText(u.Email))
+	}
+	if photo := u.Picture.Portrait(); photo != "" {
+		add("PHOTO", photo)
+	}
+	// 近似重複の正解のまとまりは拡張プロパティで書き出す
+	if c := u.Cluster; c != nil {
+		add("X-CLUSTER-ID", vcardText(c.ID))
+		add("X-CLUSTER-VARIANT", strconv.Itoa(c.Variant))
+	}
+	if len(u.EdgeCases) > 0 {
+		// 境界値の種類は英小文字と _ だけのため、カンマ区切りの値の一覧としてそのまま書く
+		add("X-EDGE-CASES", strings.Join(u.EdgeCases, ","))
+	}
+	lines = append(lines, "END:VCARD")
+
+	for _, line := range lines {
+		if _, err := e.w.WriteString(foldVCard(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *vcardEncoder) Close() error {
+	return e.w.Flush()
+}
+
+// streetLine は番地と通りの名前を1行にする
+func streetLine(s model.Street) string {
+	if s.Number == 0 {
+		return s.Name
+	}
+	return strings.TrimSpace(strconv.Itoa(s.Number) + " " + s.Name)
+}
+
+// vcardText は vCard のテキスト値のバックスラッシュ・カンマ・セミコロン・改行をエスケープする
+func vcardText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// vcardStructured は N や ADR のようにセミコロンで区切る構造化された値を返す
+func vcardStructured(components ...string) string {
+	escaped := make([]string, len(components))
+	for i, c := range components {
+		escaped[i] = vcardText(c)
+	}
+	return strings.Join(escaped, ";")
+}
+
+// foldVCard は1行が 75 オクテットを超えないように折り返し、CRLF を付けて返す。
+// マルチバイト文字の途中では折り返さない
+func foldVCard(line string) string {
+	var b strings.Builder
+	limit := vcardLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// 継続行は先頭の空白の分だけ短くする
+		limit = vcardLineLimit - 1
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
+
+// ldifEncoder は inetOrgPerson のエントリを LDIF (RFC 2849) で書き出す
+type ldifEncoder struct {
+	w      *bufio.Writer
+	baseDN string
+	wrote  bool
+}
+
+func newLDIFEncoder(w io.Writer, opts Options) (Encoder, error) {
+	baseDN := opts.BaseDN
+	if baseDN == "" {
+		baseDN = defaultBaseDN
+	}
+	return &ldifEncoder{w: bufio.NewWriter(w), baseDN: baseDN}, nil
+}
+
+func (e *ldifEncoder) Encode(u model.User) error {
+	if u.Login.Username == "" {
+		return fmt.Errorf("ユーザー名が空のため DN を作れません")
+	}
+	if !e.wrote {
+		e.wrote = true
+		if _, err := e.w.WriteString("version: 1\n"); err != nil {
+			return err
+		}
+	}
+
+	cn := strings.TrimSpace(u.Name.First + " " + u.Name.Last)
+	attrs := [][2]string{
+		{"dn", "uid=" + escapeRDN(u.Login.Username) + "," + e.baseDN},
+		{"objectClass", "top"},
+		{"objectClass", "person"},
+		{"objectClass", "organizationalPerson"},
+		{"objectClass", "inetOrgPerson"},
+		{"uid", u.Login.Username},
+		{"cn", cn},
+		{"sn", u.Name.Last},
+		{"givenName", u.Name.First},
+		{"displayName", cn},
+		{"mail", u.Email},
+		{"telephoneNumber", cmp.Or(u.PhoneE164, u.Phone)},
+		{"mobile", cmp.Or(u.CellE164, u.Cell)},
+		{"street", streetLine(u.Location.Street)},
+		{"l", u.Location.City},
+		{"st", u.Location.State},
+		{"postalCode", u.Location.Postcode},
+		{"employeeNumber", u.Login.UUID},
+		{"labeledURI", u.Picture.Portrait()},
+		{"userPassword", sshaPassword(u.Login.Password, u.Login.Salt)},
+	}
+	// 近似重複の正解のまとまりは inetOrgPerson に対応する属性が無いため description に書く
+	if c := u.Cluster; c != nil {
+		attrs = append(attrs, [2]string{"description", "cluster=" + c.ID + " variant=" + strconv.Itoa(c.Variant)})
+	}
+	if len(u.EdgeCases) > 0 {
+		attrs = append(attrs, [2]string{"description", "edgeCases=" + strings.Join(u.EdgeCases, ",")})
+	}
+
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, a := range attrs {
+		// sn と cn は inetOrgPerson の必須属性のため空でも省略しない
+		if a[1] == "" && a[0] != "sn" && a[0] != "cn" {
+			continue
+		}
+		b.WriteString(ldifLine(a[0], a[1]))
+	}
+	_, err := e.w.WriteString(b.String())
+	return err
+}
+
+func (e *ldifEncoder) Close() error {
+	if !e.wrote {
+		if _, err := e.w.WriteString("version: 1\n"); err != nil {
+			return err
+		}
+	}
+	return e.w.Flush()
+}
+
+// sshaPassword は OpenLDAP などが検証できる {SSHA} 形式のパスワードを返す。
+// model.Login の SHA1 はダミーの値のため、平文のパスワードとソルトから計算し直す
+func sshaPassword(password, salt string) string {
+	if password == "" {
+		return ""
+	}
+	h := sha1.Sum([]byte(password + salt))
+	return "{SSHA}" + base64.StdEncoding.EncodeToString(append(h[:], salt...))
+}
+
+// escapeRDN は DN の属性値の特殊文字をエスケープする (RFC 4514)
+func escapeRDN(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, r),
+			i == 0 && (r == ' ' || r == '#'),
+			i == len(s)-1 && r == ' ':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ldifLine は属性を1行で返す。安全な文字列でない値は base64 にする。
+// 76 文字を超える行は先頭に空白を置いた継続行に折り返す
+func ldifLine(name, value string) string {
+	line := name + ": " + value
+	if !ldifSafe(value) {
+		line = name + ":: " + base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	var b strings.Builder
+	limit := ldifLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\n ")
+		line = line[cut:]
+		limit = ldifLineLimit - 1
+	}
+	b.WriteString(line + "\n")
+	return b.String()
+}
+
+// ldifSafe は値が RFC 2849 の SAFE-STRING かどうかを返す
+func ldifSafe(s string) bool {
+	if s == "" {
+		return true
+	}
+	if s[0] == ' ' || s[0] == ':' || s[0] == '<' || s[len(s)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == 0 || c == '\n' || c == '\r' || c > 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,240 @@
+package export
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// defaultIndex は Elasticsearch / OpenSearch の既定のインデックス名
+const defaultIndex = "users"
+
+// dateFields は日時として扱う列 (json 名を "." で連結したもの)
+var dateFields = map[string]bool{
+	"dob.date":        true,
+	"registered.date": true,
+}
+
+// ipFields は IP アドレスとして扱う列
+var ipFields = map[string]bool{
+	"internet.ipv4": true,
+	"internet.ipv6": true,
+}
+
+// geoField は緯度経度を持つ列
+const geoField = "location.coordinates"
+
+// textFields は全文検索の対象にする列。keyword のサブフィールドも持たせる
+var textFields = map[string]bool{
+	"name.first":           true,
+	"name.last":            true,
+	"location.street.name": true,
+	"location.city":        true,
+	"location.state":       true,
+}
+
+// userDocument はユーザーを json タグ通りの入れ子のマップに変換する
+func userDocument(u model.User) (map[string]any, error) {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// docField は "." で区切ったパスの値を持つマップとキーを返す
+func docField(doc map[string]any, path string) (map[string]any, string, bool) {
+	keys := strings.Split(path, ".")
+	m := doc
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := m[k].(map[string]any)
+		if !ok {
+			return nil, "", false
+		}
+		m = next
+	}
+	last := keys[len(keys)-1]
+	_, ok := m[last]
+	return m, last, ok
+}
+
+// coordinates は緯度経度を数値で返す
+func coordinates(c model.Coordinates) (lat, lon float64, ok bool) {
+	lat, err := strconv.ParseFloat(c.Latitude, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lon, err = strconv.ParseFloat(c.Longitude, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// mongoEncoder は mongoimport で読み込める MongoDB Extended JSON (relaxed) を1行ずつ書き出す
+type mongoEncoder struct {
+	enc *json.Encoder
+}
+
+func newMongoEncoder(w io.Writer, _ Options) (Encoder, error) {
+	return &mongoEncoder{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *mongoEncoder) Encode(u model.User) error {
+	doc, err := userDocument(u)
+	if err != nil {
+		return err
+	}
+
+	// _id は Login.UUID を UUID (サブタイプ 04) のバイナリにしたもの
+	raw, err := hex.DecodeString(strings.ReplaceAll(u.Login.UUID, "-", ""))
+	if err != nil || len(raw) != 16 {
+		return fmt.Errorf("UUID が不正です: %q", u.Login.UUID)
+	}
+	doc["_id"] = map[string]any{
+		"$binary": map[string]any{
+			"base64":  base64.StdEncoding.EncodeToString(raw),
+			"subType": "04",
+		},
+	}
+
+	for path := range dateFields {
+		if m, key, ok := docField(doc, path); ok {
+			if s, _ := m[key].(string); s != "" {
+				m[key] = map[string]any{"$date": s}
+			}
+		}
+	}
+
+	// 座標は 2dsphere インデックスを張れる GeoJSON の Point にする
+	if m, key, ok := docField(doc, geoField); ok {
+		if lat, lon, ok := coordinates(u.Location.Coordinates); ok {
+			m[key] = map[string]any{
+				"type":        "Point",
+				"coordinates": []float64{lon, lat},
+			}
+		} else {
+			delete(m, key)
+		}
+	}
+
+	return e.enc.Encode(doc)
+}
+
+func (e *mongoEncoder) Close() error {
+	return nil
+}
+
+// bulkEncoder は Elasticsearch / OpenSearch の _bulk API の NDJSON を書き出す
+type bulkEncoder struct {
+	enc   *json.Encoder
+	index string
+}
+
+func newBulkEncoder(w io.Writer, opts Options) (Encoder, error) {
+	index := opts.Index
+	if index == "" {
+		index = defaultIndex
+	}
+	return &bulkEncoder{enc: json.NewEncoder(w), index: index}, nil
+}
+
+func (e *bulkEncoder) Encode(u model.User) error {
+	doc, err := userDocument(u)
+	if err != nil {
+		return err
+	}
+
+	// 座標は geo_point として解釈される {"lat", "lon"} にする
+	if m, key, ok := docField(doc, geoField); ok {
+		if lat, lon, ok := coordinates(u.Location.Coordinates); ok {
+			m[key] = map[string]any{"lat": lat, "lon": lon}
+		} else {
+			delete(m, key)
+		}
+	}
+
+	action := map[string]any{
+		"index": map[string]any{
+			"_index": e.index,
+			"_id":    u.Login.UUID,
+		},
+	}
+	if err := e.enc.Encode(action); err != nil {
+		return err
+	}
+	return e.enc.Encode(doc)
+}
+
+func (e *bulkEncoder) Close() error {
+	return nil
+}
+
+// ElasticsearchMapping は _bulk 形式の出力に対応するインデックスのマッピングを返す。
+// PUT /<index> のリクエストボディとしてそのまま使える
+func ElasticsearchMapping() map[string]any {
+	properties := make(map[string]any)
+	for _, c := range Columns() {
+		name := c.Name(".")
+		if strings.HasPrefix(name, geoField+".") {
+			addProperty(properties, strings.Split(geoField, "."), map[string]any{"type": "geo_point"})
+			continue
+		}
+		addProperty(properties, c.Path, fieldMapping(name, c))
+	}
+	return map[string]any{
+		"mappings": map[string]any{
+			"dynamic":    false,
+			"properties": properties,
+		},
+	}
+}
+
+func fieldMapping(name string, c Column) map[string]any {
+	if dateFields[name] {
+		return map[string]any{"type": "date"}
+	}
+	if ipFields[name] {
+		return map[string]any{"type": "ip"}
+	}
+	if textFields[name] {
+		return map[string]any{
+			"type":   "text",
+			"fields": map[string]any{"keyword": map[string]any{"type": "keyword"}},
+		}
+	}
+	switch c.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "long"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "double"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	}
+	return map[string]any{"type": "keyword"}
+}
+
+// addProperty は入れ子の properties にフィールドのマッピングを追加する
+func addProperty(properties map[string]any, path []string, mapping map[string]any) {
+	for _, key := range path[:len(path)-1] {
+		obj, ok := properties[key].(map[string]any)
+		if !ok {
+			obj = map[string]any{"properties": map[string]any{}}
+			properties[key] = obj
+		}
+		properties = obj["properties"].(map[string]any)
+	}
+	properties[path[len(path)-1]] = mapping
+}
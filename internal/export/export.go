@@ -0,0 +1,202 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// 出力形式
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatSQL    = "sql"
+	// FormatMongo は mongoimport で読み込める MongoDB Extended JSON
+	FormatMongo = "mongo"
+	// FormatElasticsearch は Elasticsearch / OpenSearch の _bulk API の NDJSON
+	FormatElasticsearch = "elasticsearch"
+	// FormatVCard は vCard 4.0 の連絡先
+	FormatVCard = "vcf"
+	// FormatLDIF は inetOrgPerson のエントリの LDIF
+	FormatLDIF = "ldif"
+	// FormatParquet は列を平坦化した Parquet
+	FormatParquet = "parquet"
+	// FormatArrow は列を平坦化した Arrow IPC ストリーム
+	FormatArrow = "arrow"
+)
+
+// Encoder はユーザーを1人ずつ書き出すエンコーダー
+type Encoder interface {
+	Encode(u model.User) error
+	// Close は末尾を書き出す。下位の io.Writer は閉じない
+	Close() error
+}
+
+// Options は出力形式ごとの設定
+type Options struct {
+	// Table は SQL の出力先テーブル名
+	Table string
+	// Dialect は SQL の方言 (postgres, mysql, sqlite)
+	Dialect string
+	// Layout は SQL のテーブル構成 (normalized, flat)
+	Layout string
+	// BatchSize は SQL の1つの INSERT 文にまとめる行数
+	BatchSize int
+	// Index は Elasticsearch / OpenSearch の出力先インデックス名
+	Index string
+	// BaseDN は LDIF のエントリを置くベース DN
+	BaseDN string
+	// RowGroupSize は Parquet の1つの行グループ、Arrow の1つのレコードバッチにまとめる行数
+	RowGroupSize int
+}
+
+type format struct {
+	ext         string
+	contentType string
+	newEncoder  func(w io.Writer, opts Options) (Encoder, error)
+}
+
+var formats = map[string]format{
+	FormatJSON:          {ext: ".json", contentType: "application/json; charset=utf-8", newEncoder: newJSONEncoder},
+	FormatNDJSON:        {ext: ".ndjson", contentType: "application/x-ndjson; charset=utf-8", newEncoder: newNDJSONEncoder},
+	FormatCSV:           {ext: ".csv", contentType: "text/csv; charset=utf-8", newEncoder: newCSVEncoder},
+	FormatSQL:           {ext: ".sql", contentType: "application/sql; charset=utf-8", newEncoder: newSQLEncoder},
+	FormatMongo:         {ext: ".json", contentType: "application/json; charset=utf-8", newEncoder: newMongoEncoder},
+	FormatElasticsearch: {ext: ".ndjson", contentType: "application/x-ndjson; charset=utf-8", newEncoder: newBulkEncoder},
+	FormatVCard:         {ext: ".vcf", contentType: "text/vcard; charset=utf-8", newEncoder: newVCardEncoder},
+	FormatLDIF:          {ext: ".ldif", contentType: "text/x-ldif; charset=utf-8", newEncoder: newLDIFEncoder},
+	FormatParquet:       {ext: ".parquet", contentType: "application/vnd.apache.parquet", newEncoder: newParquetEncoder},
+	FormatArrow:         {ext: ".arrows", contentType: "application/vnd.apache.arrow.stream", newEncoder: newArrowEncoder},
+}
+
+// Formats は対応している出力形式の一覧を返す
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEncoder は出力形式に対応するエンコーダーを作成する
+func NewEncoder(w io.Writer, name string, opts Options) (Encoder, error) {
+	f, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("未対応の出力形式です: %q (対応: %v)", name, Formats())
+	}
+	return f.newEncoder(w, opts)
+}
+
+// Extension は出力形式のファイル拡張子を返す
+func Extension(name string) string {
+	return formats[name].ext
+}
+
+// ContentType は出力形式の Content-Type を返す
+func ContentType(name string) string {
+	return formats[name].contentType
+}
+
+// jsonEncoder はユーザーの配列を書き出す
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONEncoder(w io.Writer, _ Options) (Encoder, error) {
+	return &jsonEncoder{w: w}, nil
+}
+
+func (e *jsonEncoder) Encode(u model.User) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "[\n"
+	}
+	e.count++
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// ndjsonEncoder は1行に1人ずつ書き出す
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer, _ Options) (Encoder, error) {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonEncoder) Encode(u model.User) error {
+	return e.enc.Encode(u)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// csvEncoder は平坦化した列で書き出す。列名は "location.street.number" の形式
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVEncoder(w io.Writer, _ Options) (Encoder, error) {
+	return &csvEncoder{w: csv.NewWriter(w)}, nil
+}
+
+func (e *csvEncoder) Encode(u model.User) error {
+	cols := Columns()
+	if !e.wroteHeader {
+		if err := e.w.Write(e.header()); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	record := make([]string, len(cols))
+	for i, c := range cols {
+		record[i] = formatValue(c.Value(&u))
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvEncoder) header() []string {
+	cols := Columns()
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name(".")
+	}
+	return header
+}
+
+func (e *csvEncoder) Close() error {
+	if !e.wroteHeader {
+		if err := e.w.Write(e.header()); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,309 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUsers() []model.User {
+	return []model.User{
+		{
+			Gender:   "male",
+			Name:     model.Name{Title: "Mr", First: "Test", Last: "O'Brien"},
+			Location: model.Location{Coordinates: model.Coordinates{Latitude: "35.6812", Longitude: "139.7671"}},
+			Login:    model.Login{UUID: "1a8b9525-e20f-4a68-927f-2b2ff836f735"},
+			Dob:      model.Dob{Date: "1990-01-02T03:04:05Z", Age: 30},
+			Picture:  model.Picture{Large: "https://example.com/placeholder/male/large.png", Thumbnail: "https://example.com/portrait.png"},
+			NAT:      "US",
+		},
+		{
+			Gender: "female",
+			Name:   model.Name{Title: "Ms", First: "Test2", Last: "User, Jr."},
+			Login:  model.Login{UUID: "0f6a3c1e-9b2d-4e7f-8a10-5c3d2b1a0e9f"},
+			NAT:    "US",
+		},
+	}
+}
+
+func encodeAll(t *testing.T, format string, opts Options) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, format, opts)
+	require.NoError(t, err)
+	for _, u := range testUsers() {
+		require.NoError(t, enc.Encode(u))
+	}
+	require.NoError(t, enc.Close())
+	return buf.String()
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var users []model.User
+	require.NoError(t, json.Unmarshal([]byte(encodeAll(t, FormatJSON, Options{})), &users))
+	assert.Equal(t, testUsers(), users)
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(encodeAll(t, FormatNDJSON, Options{})), "\n")
+	require.Len(t, lines, 2)
+	var u model.User
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &u))
+	assert.Equal(t, testUsers()[1], u)
+}
+
+func TestCSVEncoder(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(encodeAll(t, FormatCSV, Options{})), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "gender,name.title,name.first,name.last,location.street.number,"))
+	assert.True(t, strings.HasPrefix(lines[2], `female,Ms,Test2,"User, Jr.",0,`))
+}
+
+func TestSQLEncoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		contains []string
+		inserts  int
+	}{
+		{
+			name: "PostgreSQL 正規化",
+			opts: Options{Table: "people"},
+			contains: []string{
+				`CREATE TABLE IF NOT EXISTS "people" (` + "\n" + `  "uuid" UUID NOT NULL,`,
+				`CREATE TABLE IF NOT EXISTS "people_location" (`,
+				`FOREIGN KEY ("user_uuid") REFERENCES "people" ("uuid") ON DELETE CASCADE`,
+				`"dob_age" BIGINT NOT NULL`,
+				`'O''Brien'`,
+				"BEGIN;\n",
+				"COMMIT;\n",
+			},
+			inserts: 3,
+		},
+		{
+			name: "MySQL 平坦化",
+			opts: Options{Dialect: DialectMySQL, Layout: LayoutFlat},
+			contains: []string{
+				"CREATE TABLE IF NOT EXISTS `users` (",
+				"`login_uuid` CHAR(36) NOT NULL",
+				"PRIMARY KEY (`login_uuid`)",
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+				`'O''Brien'`,
+				"START TRANSACTION;",
+			},
+			inserts: 1,
+		},
+		{
+			name:     "SQLite 1行ずつ",
+			opts:     Options{Dialect: DialectSQLite, BatchSize: 1},
+			contains: []string{`"dob_age" INTEGER NOT NULL`, "BEGIN TRANSACTION;"},
+			inserts:  6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := encodeAll(t, FormatSQL, tt.opts)
+			for _, s := range tt.contains {
+				assert.Contains(t, out, s)
+			}
+			assert.Equal(t, tt.inserts, strings.Count(out, "INSERT INTO"))
+		})
+	}
+}
+
+func TestMongoEncoder(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(encodeAll(t, FormatMongo, Options{})), "\n")
+	require.Len(t, lines, 2)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &doc))
+	assert.Equal(t, map[string]any{"base64": "GouVJeIPSmiSfysv+Db3NQ==", "subType": "04"}, doc["_id"].(map[string]any)["$binary"])
+	assert.Equal(t, map[string]any{"$date": "1990-01-02T03:04:05Z"}, doc["dob"].(map[string]any)["date"])
+	assert.Equal(t, map[string]any{"type": "Point", "coordinates": []any{139.7671, 35.6812}},
+		doc["location"].(map[string]any)["coordinates"])
+
+	// 座標や日付が空の場合は GeoJSON や $date にしない
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	assert.NotContains(t, doc["location"], "coordinates")
+	assert.Equal(t, "", doc["dob"].(map[string]any)["date"])
+}
+
+func TestMongoEncoderInvalidUUID(t *testing.T) {
+	enc, err := NewEncoder(&bytes.Buffer{}, FormatMongo, Options{})
+	require.NoError(t, err)
+	assert.Error(t, enc.Encode(model.User{}))
+}
+
+func TestElasticsearchEncoder(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(encodeAll(t, FormatElasticsearch, Options{Index: "people"})), "\n")
+	require.Len(t, lines, 4)
+	assert.JSONEq(t, `{"index":{"_index":"people","_id":"1a8b9525-e20f-4a68-927f-2b2ff836f735"}}`, lines[0])
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	assert.Equal(t, map[string]any{"lat": 35.6812, "lon": 139.7671}, doc["location"].(map[string]any)["coordinates"])
+
+	lines = strings.Split(strings.TrimSpace(encodeAll(t, FormatElasticsearch, Options{})), "\n")
+	assert.Contains(t, lines[2], `"_index":"users"`)
+}
+
+func TestElasticsearchMapping(t *testing.T) {
+	b, err := json.Marshal(ElasticsearchMapping())
+	require.NoError(t, err)
+	s := string(b)
+	assert.Contains(t, s, `"coordinates":{"type":"geo_point"}`)
+	assert.Contains(t, s, `"dob":{"properties":{"age":{"type":"long"},"date":{"type":"date"}}}`)
+	assert.Contains(t, s, `"first":{"fields":{"keyword":{"type":"keyword"}},"type":"text"}`)
+	assert.Contains(t, s, `"ipv6":{"type":"ip"}`)
+	assert.NotContains(t, s, "latitude")
+}
+
+func TestVCardEncoder(t *testing.T) {
+	out := encodeAll(t, FormatVCard, Options{})
+	assert.Equal(t, 2, strings.Count(out, "BEGIN:VCARD\r\nVERSION:4.0\r\n"))
+	assert.Contains(t, out, "UID:urn:uuid:1a8b9525-e20f-4a68-927f-2b2ff836f735\r\n")
+	assert.Contains(t, out, "N:O'Brien;Test;;Mr;\r\n")
+	assert.Contains(t, out, "BDAY:19900102\r\n")
+	assert.Contains(t, out, "GEO:geo:35.6812,139.7671\r\n")
+	assert.Contains(t, out, "FN:Test2 User\\, Jr.\r\n")
+	assert.Contains(t, out, "N:User\\, Jr.;Test2;;Ms;\r\n")
+	// 仮の画像ではなく顔写真の URL を書く
+	assert.Contains(t, out, "PHOTO:https://example.com/portrait.png\r\n")
+	assert.Equal(t, 1, strings.Count(out, "PHOTO:"))
+}
+
+func TestFoldVCard(t *testing.T) {
+	line := "NOTE:" + strings.Repeat("あ", 40)
+	folded := foldVCard(line)
+	for _, l := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(l), vcardLineLimit)
+		assert.True(t, utf8.ValidString(l))
+	}
+	assert.Equal(t, line, strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+}
+
+func TestLDIFEncoder(t *testing.T) {
+	users := []model.User{{
+		Name:    model.Name{First: "Zoë", Last: "Smith"},
+		Email:   "zoe@example.com",
+		Login:   model.Login{Username: "zoe,smith", Password: "secret", Salt: "salt"},
+		Picture: model.Picture{Large: "https://example.com/placeholder/female/large.png", Thumbnail: "https://example.com/portrait.png"},
+	}}
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, FormatLDIF, Options{BaseDN: "ou=users,dc=test"})
+	require.NoError(t, err)
+	for _, u := range users {
+		require.NoError(t, enc.Encode(u))
+	}
+	require.NoError(t, enc.Close())
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "version: 1\n\ndn: uid=zoe\\,smith,ou=users,dc=test\n"))
+	assert.Contains(t, out, "objectClass: inetOrgPerson\n")
+	assert.Contains(t, out, "givenName:: Wm/Dqw==\n")
+	assert.Contains(t, out, "sn: Smith\n")
+	assert.NotContains(t, out, "telephoneNumber")
+	assert.Contains(t, out, "labeledURI: https://example.com/portrait.png\n")
+
+	// {SSHA} は SHA-1(パスワード + ソルト) の後ろにソルトを付けたもの
+	_, encoded, ok := strings.Cut(out, "userPassword: {SSHA}")
+	require.True(t, ok)
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	require.NoError(t, err)
+	sum := sha1.Sum([]byte("secretsalt"))
+	assert.Equal(t, append(sum[:], "salt"...), raw)
+}
+
+func TestLDIFEncoderEmptyUsername(t *testing.T) {
+	enc, err := NewEncoder(&bytes.Buffer{}, FormatLDIF, Options{})
+	require.NoError(t, err)
+	assert.Error(t, enc.Encode(model.User{}))
+}
+
+func TestParquetEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, FormatParquet, Options{RowGroupSize: 1})
+	require.NoError(t, err)
+	// 最初の行グループを書き出すまで何も出力しない
+	assert.Zero(t, buf.Len())
+	for _, u := range testUsers() {
+		require.NoError(t, enc.Encode(u))
+	}
+	require.NoError(t, enc.Close())
+
+	r, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.NumRowGroups())
+
+	table, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(buf.Bytes()), nil, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	defer table.Release()
+	assert.Equal(t, int64(2), table.NumRows())
+	want := arrowSchema()
+	require.Equal(t, want.NumFields(), table.Schema().NumFields())
+	for i, f := range table.Schema().Fields() {
+		assert.Equal(t, want.Field(i).Name, f.Name)
+		assert.True(t, arrow.TypeEqual(want.Field(i).Type, f.Type), f.Name)
+	}
+}
+
+func TestArrowEncoder(t *testing.T) {
+	out := encodeAll(t, FormatArrow, Options{})
+	r, err := ipc.NewReader(strings.NewReader(out))
+	require.NoError(t, err)
+	defer r.Release()
+
+	require.True(t, r.Next())
+	rec := r.Record()
+	assert.Equal(t, int64(2), rec.NumRows())
+
+	col := func(name string) arrow.Array {
+		idx := rec.Schema().FieldIndices(name)
+		require.Len(t, idx, 1, name)
+		return rec.Column(idx[0])
+	}
+	assert.Equal(t, "O'Brien", col("name_last").(*array.String).Value(0))
+	assert.Equal(t, int64(30), col("dob_age").(*array.Int64).Value(0))
+	assert.Equal(t, 35.6812, col("location_coordinates_latitude").(*array.Float64).Value(0))
+	assert.True(t, col("location_coordinates_latitude").IsNull(1))
+
+	dob := col("dob_date").(*array.Timestamp)
+	assert.Equal(t, time.Date(1990, 1, 2, 3, 4, 5, 0, time.UTC), dob.Value(0).ToTime(arrow.Millisecond))
+	assert.True(t, dob.IsNull(1))
+
+	assert.False(t, r.Next())
+}
+
+func TestSQLQuoteString(t *testing.T) {
+	assert.Equal(t, `'a''b\c'`, standardQuoteString(`a'b\c`))
+	assert.Equal(t, `'a''b\\c\n\0'`, mysqlQuoteString("a'b\\c\n\x00"))
+}
+
+func TestSQLEncoderInvalidOptions(t *testing.T) {
+	_, err := NewEncoder(&bytes.Buffer{}, FormatSQL, Options{Dialect: "oracle"})
+	assert.Error(t, err)
+	_, err = NewEncoder(&bytes.Buffer{}, FormatSQL, Options{Layout: "star"})
+	assert.Error(t, err)
+}
+
+func TestNewEncoderUnknownFormat(t *testing.T) {
+	_, err := NewEncoder(&bytes.Buffer{}, "xml", Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// Column は model.User を平坦化した列。
+// 列は json タグの名前から導出するため、model.User にフィールドを追加すると自動的に列も増える
+type Column struct {
+	// Path は json タグの名前を外側から並べたもの (例: location, street, number)
+	Path []string
+	// Kind は値の種類。スライスやマップは JSON 文字列として扱い reflect.String になる
+	Kind reflect.Kind
+	// Nullable はポインタを経由する列かどうか
+	Nullable bool
+
+	index [][]int
+}
+
+// Name は Path を sep で連結した列名を返す
+func (c Column) Name(sep string) string {
+	return strings.Join(c.Path, sep)
+}
+
+var (
+	columnsOnce sync.Once
+	columns     []Column
+)
+
+// Columns は model.User の全列を定義順で返す
+func Columns() []Column {
+	columnsOnce.Do(func() {
+		columns = walkColumns(reflect.TypeOf(model.User{}), nil, nil, false)
+	})
+	return columns
+}
+
+func walkColumns(t reflect.Type, path []string, index [][]int, nullable bool) []Column {
+	var cols []Column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+
+		p := append(append([]string(nil), path...), name)
+		idx := append(append([][]int(nil), index...), f.Index)
+
+		ft := f.Type
+		fieldNullable := nullable
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+			fieldNullable = true
+		}
+
+		switch ft.Kind() {
+		case reflect.Struct:
+			cols = append(cols, walkColumns(ft, p, idx, fieldNullable)...)
+		case reflect.Slice, reflect.Map, reflect.Array, reflect.Interface:
+			cols = append(cols, Column{Path: p, Kind: reflect.String, Nullable: true, index: idx})
+		default:
+			cols = append(cols, Column{Path: p, Kind: ft.Kind(), Nullable: fieldNullable, index: idx})
+		}
+	}
+	return cols
+}
+
+func jsonName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// Value はユーザーから列の値を取り出す。途中のポインタが nil の場合は nil を返す。
+// スライスやマップは JSON 文字列に変換し、空の場合は nil を返す
+func (c Column) Value(u *model.User) any {
+	v := reflect.ValueOf(u).Elem()
+	for _, idx := range c.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.FieldByIndex(idx)
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Interface:
+		if v.IsZero() {
+			return nil
+		}
+		if v.Kind() != reflect.Interface && v.Len() == 0 {
+			return nil
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil
+		}
+		return string(b)
+	}
+	return v.Interface()
+}
+
+// formatValue は列の値を文字列に変換する。nil は空文字列になる
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
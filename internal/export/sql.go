@@ -0,0 +1,391 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// SQL の方言
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// SQL のテーブル構成
+const (
+	// LayoutFlat はすべての列を1つのテーブルに平坦化する
+	LayoutFlat = "flat"
+	// LayoutNormalized は normalizedGroups の列を別テーブルに分ける
+	LayoutNormalized = "normalized"
+)
+
+const (
+	// defaultTable は SQL の既定のテーブル名
+	defaultTable = "users"
+	// defaultBatchSize は1つの INSERT 文にまとめる既定の行数
+	defaultBatchSize = 100
+	// userKeyColumn は正規化した子テーブルから親を参照する列名
+	userKeyColumn = "user_uuid"
+)
+
+// normalizedGroups は正規化する場合に別テーブルに分ける model.User のフィールド (json 名)
+var normalizedGroups = []string{"location", "login"}
+
+// keyPath は主キーに使う列のパス
+var keyPath = []string{"login", "uuid"}
+
+// sqlDialect は方言ごとの識別子・リテラル・型の違い
+type sqlDialect struct {
+	quoteIdent  func(string) string
+	quoteString func(string) string
+	trueLiteral string
+	falseLit    string
+	intType     string
+	floatType   string
+	boolType    string
+	textType    string
+	keyType     string
+	preamble    string
+	begin       string
+	tableSuffix string
+}
+
+var dialects = map[string]sqlDialect{
+	DialectPostgres: {
+		quoteIdent:  doubleQuoteIdent,
+		quoteString: standardQuoteString,
+		trueLiteral: "TRUE",
+		falseLit:    "FALSE",
+		intType:     "BIGINT",
+		floatType:   "DOUBLE PRECISION",
+		boolType:    "BOOLEAN",
+		textType:    "TEXT",
+		keyType:     "UUID",
+		begin:       "BEGIN;",
+	},
+	DialectMySQL: {
+		quoteIdent:  backquoteIdent,
+		quoteString: mysqlQuoteString,
+		trueLiteral: "TRUE",
+		falseLit:    "FALSE",
+		intType:     "BIGINT",
+		floatType:   "DOUBLE",
+		boolType:    "BOOLEAN",
+		textType:    "TEXT",
+		keyType:     "CHAR(36)",
+		preamble:    "SET NAMES utf8mb4;",
+		begin:       "START TRANSACTION;",
+		tableSuffix: " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	},
+	DialectSQLite: {
+		quoteIdent:  doubleQuoteIdent,
+		quoteString: standardQuoteString,
+		trueLiteral: "1",
+		falseLit:    "0",
+		intType:     "INTEGER",
+		floatType:   "REAL",
+		boolType:    "INTEGER",
+		textType:    "TEXT",
+		keyType:     "TEXT",
+		begin:       "BEGIN TRANSACTION;",
+	},
+}
+
+// SQLDialects は対応している SQL の方言の一覧を返す
+func SQLDialects() []string {
+	return []string{DialectPostgres, DialectMySQL, DialectSQLite}
+}
+
+// sqlColumn はテーブルの1列
+type sqlColumn struct {
+	name string
+	col  Column
+	key  bool
+}
+
+// sqlTable は出力するテーブル。parent が空でない場合は親テーブルの子
+type sqlTable struct {
+	name    string
+	parent  string
+	columns []sqlColumn
+}
+
+// buildSQLTables は model.User の列からテーブル構成を導出する
+func buildSQLTables(table, layout string) []sqlTable {
+	cols := Columns()
+
+	if layout == LayoutFlat {
+		t := sqlTable{name: table}
+		for _, c := range cols {
+			t.columns = append(t.columns, sqlColumn{name: c.Name("_"), col: c, key: samePath(c.Path, keyPath)})
+		}
+		return []sqlTable{t}
+	}
+
+	main := sqlTable{name: table}
+	children := make([]sqlTable, len(normalizedGroups))
+	for i, g := range normalizedGroups {
+		children[i] = sqlTable{name: table + "_" + g, parent: table}
+	}
+
+	for _, c := range cols {
+		if samePath(c.Path, keyPath) {
+			main.columns = append([]sqlColumn{{name: "uuid", col: c, key: true}}, main.columns...)
+			for i := range children {
+				children[i].columns = append([]sqlColumn{{name: userKeyColumn, col: c, key: true}}, children[i].columns...)
+			}
+			continue
+		}
+
+		group := -1
+		for i, g := range normalizedGroups {
+			if c.Path[0] == g {
+				group = i
+			}
+		}
+		if group < 0 {
+			main.columns = append(main.columns, sqlColumn{name: c.Name("_"), col: c})
+			continue
+		}
+		name := strings.Join(c.Path[1:], "_")
+		children[group].columns = append(children[group].columns, sqlColumn{name: name, col: c})
+	}
+
+	return append([]sqlTable{main}, children...)
+}
+
+func samePath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sqlEncoder は CREATE TABLE と、複数行をまとめた INSERT 文を書き出す
+type sqlEncoder struct {
+	w         io.Writer
+	dialect   sqlDialect
+	tables    []sqlTable
+	batchSize int
+
+	wroteSchema bool
+	rows        [][]string
+}
+
+func newSQLEncoder(w io.Writer, opts Options) (Encoder, error) {
+	dialectName := opts.Dialect
+	if dialectName == "" {
+		dialectName = DialectPostgres
+	}
+	dialect, ok := dialects[dialectName]
+	if !ok {
+		return nil, fmt.Errorf("未対応の SQL の方言です: %q (対応: %v)", opts.Dialect, SQLDialects())
+	}
+
+	layout := opts.Layout
+	if layout == "" {
+		layout = LayoutNormalized
+	}
+	if layout != LayoutFlat && layout != LayoutNormalized {
+		return nil, fmt.Errorf("未対応のテーブル構成です: %q (対応: %s, %s)", opts.Layout, LayoutFlat, LayoutNormalized)
+	}
+
+	table := opts.Table
+	if table == "" {
+		table = defaultTable
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	tables := buildSQLTables(table, layout)
+	return &sqlEncoder{
+		w:         w,
+		dialect:   dialect,
+		tables:    tables,
+		batchSize: batchSize,
+		rows:      make([][]string, len(tables)),
+	}, nil
+}
+
+func (e *sqlEncoder) writeSchema() error {
+	e.wroteSchema = true
+	d := e.dialect
+
+	var b strings.Builder
+	if d.preamble != "" {
+		b.WriteString(d.preamble + "\n\n")
+	}
+	for _, t := range e.tables {
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", d.quoteIdent(t.name))
+		var keys []string
+		for _, c := range t.columns {
+			fmt.Fprintf(&b, "  %s %s", d.quoteIdent(c.name), e.columnType(c))
+			if c.key || !c.col.Nullable {
+				b.WriteString(" NOT NULL")
+			}
+			b.WriteString(",\n")
+			if c.key {
+				keys = append(keys, d.quoteIdent(c.name))
+			}
+		}
+		fmt.Fprintf(&b, "  PRIMARY KEY (%s)", strings.Join(keys, ", "))
+		if t.parent != "" {
+			fmt.Fprintf(&b, ",\n  FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE CASCADE",
+				d.quoteIdent(userKeyColumn), d.quoteIdent(t.parent), d.quoteIdent("uuid"))
+		}
+		fmt.Fprintf(&b, "\n)%s;\n\n", d.tableSuffix)
+	}
+	b.WriteString(d.begin + "\n")
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+// columnType は列の値の種類に対応する方言の型を返す
+func (e *sqlEncoder) columnType(c sqlColumn) string {
+	if c.key {
+		return e.dialect.keyType
+	}
+	switch c.col.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return e.dialect.intType
+	case reflect.Float32, reflect.Float64:
+		return e.dialect.floatType
+	case reflect.Bool:
+		return e.dialect.boolType
+	}
+	return e.dialect.textType
+}
+
+func (e *sqlEncoder) Encode(u model.User) error {
+	if !e.wroteSchema {
+		if err := e.writeSchema(); err != nil {
+			return err
+		}
+	}
+
+	for i, t := range e.tables {
+		values := make([]string, len(t.columns))
+		present := t.parent == ""
+		for j, c := range t.columns {
+			v := c.col.Value(&u)
+			if v != nil && !c.key {
+				present = true
+			}
+			values[j] = e.literal(v)
+		}
+		// 子テーブルの列がすべて空の場合は行を作らない
+		if present {
+			e.rows[i] = append(e.rows[i], "("+strings.Join(values, ", ")+")")
+		}
+	}
+
+	if len(e.rows[0]) >= e.batchSize {
+		return e.flush()
+	}
+	return nil
+}
+
+// flush は溜めた行を INSERT 文として書き出す。外部キーのため親テーブルから順に書き出す
+func (e *sqlEncoder) flush() error {
+	d := e.dialect
+	for i, t := range e.tables {
+		if len(e.rows[i]) == 0 {
+			continue
+		}
+		names := make([]string, len(t.columns))
+		for j, c := range t.columns {
+			names[j] = d.quoteIdent(c.name)
+		}
+		_, err := fmt.Fprintf(e.w, "INSERT INTO %s (%s) VALUES\n%s;\n",
+			d.quoteIdent(t.name), strings.Join(names, ", "), strings.Join(e.rows[i], ",\n"))
+		if err != nil {
+			return err
+		}
+		e.rows[i] = e.rows[i][:0]
+	}
+	return nil
+}
+
+func (e *sqlEncoder) Close() error {
+	if !e.wroteSchema {
+		if err := e.writeSchema(); err != nil {
+			return err
+		}
+	}
+	if err := e.flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "COMMIT;\n")
+	return err
+}
+
+// literal は値を方言の SQL リテラルに変換する
+func (e *sqlEncoder) literal(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return e.dialect.quoteString(x)
+	case bool:
+		if x {
+			return e.dialect.trueLiteral
+		}
+		return e.dialect.falseLit
+	}
+	return formatValue(v)
+}
+
+func doubleQuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func backquoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// standardQuoteString は標準 SQL の文字列リテラルを返す。シングルクォートのみを二重にする
+func standardQuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// mysqlQuoteString は MySQL の文字列リテラルを返す。
+// NO_BACKSLASH_ESCAPES が無効な既定の設定ではバックスラッシュも解釈されるためエスケープする
+func mysqlQuoteString(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case 0:
+			b.WriteString(`\0`)
+		case '\'':
+			b.WriteString(`''`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case 0x1a:
+			b.WriteString(`\Z`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
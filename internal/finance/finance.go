@@ -0,0 +1,166 @@
+// Package finance はテスト用の決済カード番号と IBAN を生成・検証する。
+// カード番号は各ブランドのテスト用 BIN から作り、実在の口座には紐付かない
+package finance
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// カードのブランド
+const (
+	Visa       = "visa"
+	Mastercard = "mastercard"
+	Amex       = "amex"
+	Discover   = "discover"
+	Diners     = "diners"
+	JCB        = "jcb"
+	UnionPay   = "unionpay"
+)
+
+// scheme はブランドごとのテスト用 BIN と番号の長さ
+type scheme struct {
+	name   string
+	weight int
+	bins   []string
+	length int
+	cvv    int
+}
+
+// schemes は決済サービスがテスト用に公開している番号の BIN
+var schemes = []scheme{
+	{Visa, 45, []string{"424242", "400005", "401288"}, 16, 3},
+	{Mastercard, 30, []string{"555555", "222300", "520082", "510510"}, 16, 3},
+	{Amex, 10, []string{"378282", "371449", "378734"}, 15, 4},
+	{Discover, 5, []string{"601111", "601100"}, 16, 3},
+	{Diners, 3, []string{"305693", "362272"}, 14, 3},
+	{JCB, 4, []string{"356600", "353011"}, 16, 3},
+	{UnionPay, 3, []string{"620000"}, 16, 3},
+}
+
+// maxExpiryMonths は有効期限を asOf から何か月先までにするか
+const maxExpiryMonths = 60
+
+// Generate は国籍 nat のユーザーの決済カードと IBAN を生成する。
+// カードの有効期限は asOf より後の月になる。IBAN を使わない国籍では IBAN を空にする
+func Generate(nat string, asOf time.Time, rnd *mathrand.Rand) model.Finance {
+	f := model.Finance{Card: generateCard(asOf, rnd)}
+	if c, ok := countries[strings.ToUpper(nat)]; ok {
+		f.IBAN = generateIBAN(strings.ToUpper(nat), c, rnd)
+	}
+	return f
+}
+
+func generateCard(asOf time.Time, rnd *mathrand.Rand) model.Card {
+	s := pickScheme(rnd)
+	number := s.bins[rnd.Intn(len(s.bins))] + randomNumber(rnd, s.length-7)
+	number += string(rune('0' + luhnCheckDigit(number)))
+
+	// 月の初日から数えて、月末の日付のずれを避ける
+	expiry := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1+rnd.Intn(maxExpiryMonths), 0)
+	return model.Card{
+		Scheme: s.name,
+		Number: number,
+		Expiry: expiry.Format("01/06"),
+		CVV:    randomNumber(rnd, s.cvv),
+	}
+}
+
+func pickScheme(rnd *mathrand.Rand) scheme {
+	total := 0
+	for _, s := range schemes {
+		total += s.weight
+	}
+	n := rnd.Intn(total)
+	for _, s := range schemes {
+		if n < s.weight {
+			return s
+		}
+		n -= s.weight
+	}
+	return schemes[0]
+}
+
+// luhnCheckDigit は number に続く Luhn の検査用数字を計算する
+func luhnCheckDigit(number string) int {
+	sum := 0
+	double := true
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// ValidLuhn は数字の列が Luhn のチェックを満たすかを返す。空白とハイフンは無視する
+func ValidLuhn(number string) bool {
+	number = strings.NewReplacer(" ", "", "-", "").Replace(number)
+	if len(number) < 2 {
+		return false
+	}
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return luhnCheckDigit(number[:len(number)-1]) == int(number[len(number)-1]-'0')
+}
+
+// CheckCard はカードがブランドのテスト用 BIN・桁数・Luhn を満たし、asOf の時点で期限が切れていないかを確かめる。
+// 満たさない場合は理由をエラーで返す
+func CheckCard(c model.Card, asOf time.Time) error {
+	var s *scheme
+	for i := range schemes {
+		if schemes[i].name == c.Scheme {
+			s = &schemes[i]
+		}
+	}
+	switch {
+	case s == nil:
+		return fmt.Errorf("未知のブランドです: %q", c.Scheme)
+	case len(c.Number) != s.length:
+		return fmt.Errorf("%s のカード番号は %d 桁です: %q", c.Scheme, s.length, c.Number)
+	case !hasAnyPrefix(c.Number, s.bins):
+		return fmt.Errorf("%s のテスト用 BIN ではありません: %q", c.Scheme, c.Number)
+	case !ValidLuhn(c.Number):
+		return fmt.Errorf("Luhn のチェックを満たしません: %q", c.Number)
+	case len(c.CVV) != s.cvv:
+		return fmt.Errorf("%s のセキュリティコードは %d 桁です: %q", c.Scheme, s.cvv, c.CVV)
+	}
+	expiry, err := time.Parse("01/06", c.Expiry)
+	if err != nil {
+		return fmt.Errorf("有効期限は MM/YY の形式です: %q", c.Expiry)
+	}
+	// 有効期限は表示された月の末日まで
+	if !asOf.Before(expiry.AddDate(0, 1, 0)) {
+		return fmt.Errorf("有効期限が切れています: %s", c.Expiry)
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func randomNumber(rnd *mathrand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + rnd.Intn(10))
+	}
+	return string(b)
+}
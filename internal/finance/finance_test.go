@@ -0,0 +1,70 @@
+package finance
+
+import (
+	mathrand "math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidLuhn(t *testing.T) {
+	for _, s := range []string{"4242424242424242", "5555 5555 5555 4444", "378282246310005", "6011-1111-1111-1117"} {
+		assert.True(t, ValidLuhn(s), s)
+	}
+	for _, s := range []string{"4242424242424241", "", "4", "4242x42424242424"} {
+		assert.False(t, ValidLuhn(s), s)
+	}
+}
+
+func TestValidIBAN(t *testing.T) {
+	for _, s := range []string{"GB82 WEST 1234 5698 7654 32", "NL91ABNA0417164300", "FR1420041010050500013M02606", "DE89370400440532013000", "BR1800360305000010009795493C1"} {
+		assert.True(t, ValidIBAN(s), s)
+	}
+	for _, s := range []string{"GB83WEST12345698765432", "GB82WEST1234569876543", "NL91ABNA041716430", "1234", "GB82-WEST-1234-5698-7654-32"} {
+		assert.False(t, ValidIBAN(s), s)
+	}
+}
+
+func TestCheckCard(t *testing.T) {
+	asOf := time.Date(2030, 3, 15, 0, 0, 0, 0, time.UTC)
+	valid := model.Card{Scheme: Visa, Number: "4242424242424242", Expiry: "03/30", CVV: "123"}
+	assert.NoError(t, CheckCard(valid, asOf))
+
+	for _, c := range []model.Card{
+		{Scheme: Visa, Number: "4242424242424242", Expiry: "02/30", CVV: "123"},
+		{Scheme: Visa, Number: "4242424242424241", Expiry: "03/30", CVV: "123"},
+		{Scheme: Visa, Number: "4111111111111111", Expiry: "03/30", CVV: "123"},
+		{Scheme: Amex, Number: "378282246310005", Expiry: "03/30", CVV: "123"},
+		{Scheme: "other", Number: "4242424242424242", Expiry: "03/30", CVV: "123"},
+	} {
+		assert.Error(t, CheckCard(c, asOf), c)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	rnd := mathrand.New(mathrand.NewSource(1))
+	asOf := time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+	used := map[string]bool{}
+	for _, nat := range []string{"US", "GB", "FR", "NL", "JP", "BR"} {
+		for i := 0; i < 300; i++ {
+			f := Generate(nat, asOf, rnd)
+			require.NoError(t, CheckCard(f.Card, asOf))
+			expiry, _ := time.Parse("01/06", f.Card.Expiry)
+			assert.True(t, expiry.After(asOf), "有効期限は基準日より後")
+			used[f.Card.Scheme] = true
+
+			switch nat {
+			case "US", "JP":
+				assert.Empty(t, f.IBAN)
+			default:
+				assert.True(t, strings.HasPrefix(f.IBAN, nat), f.IBAN)
+				require.True(t, ValidIBAN(f.IBAN), f.IBAN)
+			}
+		}
+	}
+	assert.Len(t, used, len(schemes))
+}
@@ -0,0 +1,87 @@
+package finance
+
+import (
+	"fmt"
+	"math/big"
+	mathrand "math/rand"
+	"strings"
+)
+
+// country は国ごとの IBAN の銀行コードと BBAN (国内の口座番号) の組み立て方
+type country struct {
+	banks []string
+	bban  func(bank string, rnd *mathrand.Rand) string
+}
+
+// countries は IBAN を生成する国籍。米国と日本は IBAN を使わない
+var countries = map[string]country{
+	// 銀行 4 文字 + ソートコード 6 桁 + 口座番号 8 桁
+	"GB": {[]string{"NWBK", "BARC", "LOYD", "HBUK", "MIDL"}, func(bank string, rnd *mathrand.Rand) string {
+		return bank + randomNumber(rnd, 6) + randomNumber(rnd, 8)
+	}},
+	// 銀行 5 桁 + 支店 5 桁 + 口座番号 11 桁 + RIB キー 2 桁
+	"FR": {[]string{"30004", "30003", "20041", "30002", "10278"}, func(bank string, rnd *mathrand.Rand) string {
+		branch, account := randomNumber(rnd, 5), randomNumber(rnd, 11)
+		return bank + branch + account + ribKey(bank+branch+account)
+	}},
+	// 銀行 4 文字 + 口座番号 10 桁
+	"NL": {[]string{"ABNA", "INGB", "RABO", "SNSB", "TRIO"}, func(bank string, rnd *mathrand.Rand) string {
+		return bank + randomNumber(rnd, 10)
+	}},
+	// 銀行 (ISPB) 8 桁 + 支店 5 桁 + 口座番号 10 桁 + 口座の種類 1 文字 + 名義人 1 文字
+	"BR": {[]string{"00000000", "00360305", "60701190", "60746948", "90400888"}, func(bank string, rnd *mathrand.Rand) string {
+		return bank + randomNumber(rnd, 5) + randomNumber(rnd, 10) + string("CP"[rnd.Intn(2)]) + "1"
+	}},
+}
+
+// ibanLengths は国ごとの IBAN の長さ
+var ibanLengths = map[string]int{"GB": 22, "FR": 27, "NL": 18, "BR": 29, "DE": 22, "ES": 24, "IT": 27, "BE": 16}
+
+func generateIBAN(code string, c country, rnd *mathrand.Rand) string {
+	bban := c.bban(c.banks[rnd.Intn(len(c.banks))], rnd)
+	return code + ibanCheckDigits(code, bban) + bban
+}
+
+// ibanCheckDigits は ISO 13616 の mod 97 の検査用数字を計算する
+func ibanCheckDigits(code, bban string) string {
+	return fmt.Sprintf("%02d", 98-mod97(bban+code+"00"))
+}
+
+// mod97 は英字を 10 から 35 の数に置き換えた数値を 97 で割った余りを返す
+func mod97(s string) int64 {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= 'A' && c <= 'Z' {
+			fmt.Fprintf(&b, "%d", c-'A'+10)
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(b.String(), 10)
+	return n.Mod(n, big.NewInt(97)).Int64()
+}
+
+// ValidIBAN は IBAN の国ごとの長さと mod 97 の検査用数字が正しいかを返す。空白は無視する
+func ValidIBAN(iban string) bool {
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if len(iban) < 5 || len(iban) > 34 {
+		return false
+	}
+	if n, ok := ibanLengths[iban[:2]]; ok && len(iban) != n {
+		return false
+	}
+	for i, c := range iban {
+		letter := c >= 'A' && c <= 'Z'
+		digit := c >= '0' && c <= '9'
+		if (i < 2 && !letter) || (i >= 2 && i < 4 && !digit) || (!letter && !digit) {
+			return false
+		}
+	}
+	return mod97(iban[4:]+iban[:4]) == 1
+}
+
+// ribKey はフランスの銀行・支店・口座番号を連結した数字から RIB キーを計算する。
+// 各部分の重み 89, 15, 3 は、連結した数値に 100 を掛けて 97 で割った余りと等しい
+func ribKey(number string) string {
+	return fmt.Sprintf("%02d", 97-mod97(number+"00"))
+}
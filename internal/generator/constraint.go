@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+)
+
+// constrain は年齢以外の属性の条件をデータセットに照らして確かめ、条件に合う値だけから選べるようにする。
+// 州と市区町村はデータセットの表記に揃え、対応の無い組み合わせは認めない。満たせない条件はエラーにする
+func (s *snapshot) constrain(o Options) (Options, error) {
+	if o.State != "" {
+		state, ok := s.data.List(dataset.States).Find(o.State)
+		if !ok {
+			return o, fmt.Errorf("%w: 州 %q はデータセットにありません", ErrInvalidOptions, o.State)
+		}
+		o.State = state
+	}
+	if o.City != "" {
+		city, ok := s.data.List(dataset.Cities).Find(o.City)
+		if !ok {
+			return o, fmt.Errorf("%w: 市区町村 %q はデータセットにありません", ErrInvalidOptions, o.City)
+		}
+		o.City = city
+	}
+
+	// 市区町村と州は、データセットの対応で同じ住所になる組み合わせだけにする
+	switch {
+	case o.City != "" && o.State != "":
+		if states := s.data.StatesOf(o.City); !slices.Contains(states, o.State) {
+			return o, fmt.Errorf("%w: 市区町村 %q は州 %q にありません", ErrInvalidOptions, o.City, o.State)
+		}
+	case o.City != "":
+		states := s.data.StatesOf(o.City)
+		o.states = s.data.List(dataset.States).Filter(func(state string) bool {
+			return slices.Contains(states, state)
+		})
+		if o.states == nil {
+			return o, fmt.Errorf("%w: 市区町村 %q のある州はデータセットにありません", ErrInvalidOptions, o.City)
+		}
+	case o.State != "":
+		o.cities = s.data.List(dataset.Cities).Filter(func(city string) bool {
+			return slices.Contains(s.data.StatesOf(city), o.State)
+		})
+		if o.cities == nil {
+			return o, fmt.Errorf("%w: 州 %q の市区町村はデータセットにありません", ErrInvalidOptions, o.State)
+		}
+	}
+	if prefix := strings.ToLower(o.LastNameStartsWith); prefix != "" {
+		o.lastNames = s.data.List(dataset.LastNames).Filter(func(name string) bool {
+			return strings.HasPrefix(strings.ToLower(name), prefix)
+		})
+		if o.lastNames == nil {
+			return o, fmt.Errorf("%w: %q で始まる姓はデータセットにありません", ErrInvalidOptions, o.LastNameStartsWith)
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConstraints(t *testing.T) {
+	g := &Generator{}
+	opts := Options{Age: &AgeRange{Min: 30, Max: 45}, State: "texas", City: "Houston", LastNameStartsWith: "j"}
+	users, err := g.Generate(200, 1, 1, opts)
+	require.NoError(t, err)
+	require.Len(t, users, 200)
+
+	lastNames := map[string]bool{}
+	for _, u := range users {
+		assert.GreaterOrEqual(t, u.Dob.Age, 30)
+		assert.LessOrEqual(t, u.Dob.Age, 45)
+		// 州と市区町村はデータセットの表記に揃える
+		assert.Equal(t, "Texas", u.Location.State)
+		assert.Equal(t, "Houston", u.Location.City)
+		assert.True(t, strings.HasPrefix(u.Name.Last, "J"), u.Name.Last)
+		lastNames[u.Name.Last] = true
+	}
+	// 条件に合う姓 (Johnson, Jones) はどちらも選ばれる
+	assert.Len(t, lastNames, 2)
+
+	// 条件の無い場合と同じ乱数の消費で、州の条件だけでは他の項目が変わらない
+	plain, err := g.Generate(5, 1, 1, Options{})
+	require.NoError(t, err)
+	state, err := g.Generate(5, 1, 1, Options{State: "Texas"})
+	require.NoError(t, err)
+	for i := range plain {
+		assert.Equal(t, plain[i].Login.UUID, state[i].Login.UUID)
+		assert.Equal(t, plain[i].Name, state[i].Name)
+	}
+}
+
+func TestGenerateCityState(t *testing.T) {
+	g := &Generator{}
+
+	// 市区町村だけの指定では、その市区町村のある州になる
+	users, err := g.Generate(20, 1, 1, Options{City: "houston"})
+	require.NoError(t, err)
+	for _, u := range users {
+		assert.Equal(t, "Houston", u.Location.City)
+		assert.Equal(t, "Texas", u.Location.State)
+	}
+
+	// 州だけの指定では、その州の市区町村から選ぶ
+	users, err = g.Generate(20, 1, 1, Options{State: "Illinois"})
+	require.NoError(t, err)
+	for _, u := range users {
+		assert.Equal(t, "Chicago", u.Location.City)
+		assert.Equal(t, "Illinois", u.Location.State)
+	}
+}
+
+func TestGenerateZeroAge(t *testing.T) {
+	// 0 歳から 0 歳の指定は既定の範囲と区別する
+	users, err := (&Generator{}).Generate(20, 1, 1, Options{Age: &AgeRange{}})
+	require.NoError(t, err)
+	for _, u := range users {
+		assert.Equal(t, 0, u.Dob.Age)
+	}
+}
+
+func TestGenerateUnsatisfiableConstraints(t *testing.T) {
+	g := &Generator{}
+	for name, opts := range map[string]Options{
+		"年齢の下限が上限より大きい": {Age: &AgeRange{Min: 50, Max: 40}},
+		"年齢の上限を超える":     {Age: &AgeRange{Min: 100, Max: 130}},
+		"データセットに無い州":    {State: "Atlantis"},
+		"データセットに無い市区町村": {City: "Atlantis"},
+		"市区町村が州に無い":     {State: "Florida", City: "Houston"},
+		"市区町村のある州が無い":   {City: "Phoenix"},
+		"州に市区町村が無い":     {State: "Florida"},
+		"該当する姓が無い":      {LastNameStartsWith: "Q"},
+	} {
+		_, err := g.Generate(1, 1, 1, opts)
+		assert.ErrorIs(t, err, ErrInvalidOptions, name)
+	}
+}
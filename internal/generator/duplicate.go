@@ -0,0 +1,323 @@
+package generator
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"slices"
+	"strconv"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// 近似重複のレコードに加える変更
+const (
+	PerturbTypo        = "typo"
+	PerturbNickname    = "nickname"
+	PerturbTransposed  = "transposed_digits"
+	PerturbMoved       = "moved"
+	PerturbMarriedName = "married_name"
+	PerturbEmail       = "email_change"
+)
+
+const (
+	// MaxVariants は1人のユーザーから作る近似重複のレコードの上限
+	MaxVariants = 10
+	// maxVariantDelay は元のユーザーから何件後までに近似重複のレコードを出すか
+	maxVariantDelay = 100
+	// minMarriedAge は姓が変わる変更を加える最低年齢
+	minMarriedAge = 20
+)
+
+// nicknames は名と、その愛称
+var nicknames = map[string][]string{
+	"Robert":      {"Bob", "Rob", "Bobby"},
+	"William":     {"Bill", "Will", "Billy"},
+	"Richard":     {"Rick", "Dick", "Rich"},
+	"James":       {"Jim", "Jimmy", "Jamie"},
+	"John":        {"Jack", "Johnny"},
+	"Michael":     {"Mike", "Mikey"},
+	"Thomas":      {"Tom", "Tommy"},
+	"Charles":     {"Charlie", "Chuck"},
+	"Joseph":      {"Joe", "Joey"},
+	"Christopher": {"Chris"},
+	"Daniel":      {"Dan", "Danny"},
+	"Matthew":     {"Matt"},
+	"Anthony":     {"Tony"},
+	"Edward":      {"Ed", "Eddie", "Ted"},
+	"Steven":      {"Steve"},
+	"Andrew":      {"Andy", "Drew"},
+	"Joshua":      {"Josh"},
+	"Nicholas":    {"Nick"},
+	"Benjamin":    {"Ben"},
+	"Samuel":      {"Sam"},
+	"Alexander":   {"Alex"},
+	"Jonathan":    {"Jon"},
+	"Timothy":     {"Tim"},
+	"Gregory":     {"Greg"},
+	"Patrick":     {"Pat"},
+	"Elizabeth":   {"Liz", "Beth", "Betty", "Eliza"},
+	"Margaret":    {"Maggie", "Peggy", "Meg"},
+	"Katherine":   {"Kate", "Katie", "Kathy"},
+	"Catherine":   {"Cathy", "Cat"},
+	"Jennifer":    {"Jen", "Jenny"},
+	"Patricia":    {"Pat", "Patty", "Trish"},
+	"Susan":       {"Sue", "Suzy"},
+	"Deborah":     {"Deb", "Debbie"},
+	"Rebecca":     {"Becky", "Becca"},
+	"Jessica":     {"Jess"},
+	"Victoria":    {"Vicky", "Tori"},
+	"Samantha":    {"Sam"},
+	"Christine":   {"Chris", "Chrissy"},
+	"Barbara":     {"Barb"},
+	"Dorothy":     {"Dot", "Dottie"},
+	"Abigail":     {"Abby"},
+	"Alexandra":   {"Alex", "Lexi"},
+	"Kimberly":    {"Kim"},
+	"Pamela":      {"Pam"},
+	"Stephanie":   {"Steph"},
+}
+
+// perturbation は近似重複のレコードに変更を加える。加えられない場合は false を返す
+type perturbation struct {
+	name  string
+	apply func(s *snapshot, u *model.User, opts Options, rnd *mathrand.Rand) bool
+}
+
+var perturbations = []perturbation{
+	{PerturbTypo, perturbTypo},
+	{PerturbNickname, perturbNickname},
+	{PerturbTransposed, perturbTransposed},
+	{PerturbMoved, perturbMoved},
+	{PerturbMarriedName, perturbMarriedName},
+	{PerturbEmail, perturbEmail},
+}
+
+// duplicator は近似重複のレコードを作り、元のユーザーから少し後に出すまで保持する
+type duplicator struct {
+	s    *snapshot
+	opts Options
+	// count は生成する件数。近似重複のレコードはすべてこの件数の中に出す
+	count    int
+	pending  []scheduledVariant
+	disabled bool
+}
+
+type scheduledVariant struct {
+	at   int
+	user model.User
+}
+
+func newDuplicator(s *snapshot, opts Options, count int) *duplicator {
+	return &duplicator{s: s, opts: opts, count: count, disabled: opts.Duplicates == 0}
+}
+
+// next は i 件目に出す近似重複のレコードを返す。出すものが無い場合は false を返す。
+// 残りの件数が予約の数まで減った場合は、予約した件目より前でも出して count 件の中に収める
+func (d *duplicator) next(i int) (model.User, bool) {
+	if len(d.pending) == 0 || d.pending[0].at > i && len(d.pending) < d.count-i {
+		return model.User{}, false
+	}
+	u := d.pending[0].user
+	d.pending = d.pending[1:]
+	return u, true
+}
+
+// add は i 件目に出す元のユーザーに Cluster を設定し、選ばれた場合は近似重複のレコードを予約する。
+// 残りの件数に近似重複のレコードがすべて収まらない場合は選ばない
+func (d *duplicator) add(i int, u *model.User) {
+	if d.disabled {
+		return
+	}
+	u.Cluster = &model.Cluster{ID: u.Login.UUID}
+	rnd := userRand(*u, "duplicate")
+	if rnd.Float64() >= d.opts.Duplicates || len(d.pending)+d.opts.Variants > d.count-1-i {
+		return
+	}
+	for n := 1; n <= d.opts.Variants; n++ {
+		v := d.s.variant(*u, n, d.opts)
+		at := i + 1 + rnd.Intn(maxVariantDelay)
+		// 同じ件目に予約されたものは予約した順に出す
+		pos, _ := slices.BinarySearchFunc(d.pending, at+1, func(p scheduledVariant, t int) int { return p.at - t })
+		d.pending = slices.Insert(d.pending, pos, scheduledVariant{at: at, user: v})
+	}
+}
+
+// variant は元のユーザーに 1 から 3 個の変更を加えた n 番目のレコードを作る
+func (s *snapshot) variant(base model.User, n int, opts Options) model.User {
+	rnd := userRand(base, "variant/"+strconv.Itoa(n))
+	// 別のアカウントとして登録されたレコードのため、UUID とユーザー名は新しくする
+	v := base
+	v.Login.UUID = generateUUIDWithRand(rnd)
+	setUsername(&v, localName(v.Name.First+v.Name.Last)+strconv.Itoa(rnd.Intn(99)))
+
+	want := 1 + rnd.Intn(3)
+	var applied []string
+	for _, i := range rnd.Perm(len(perturbations)) {
+		if len(applied) == want {
+			break
+		}
+		p := perturbations[i]
+		if p.apply(s, &v, opts, rnd) {
+			applied = append(applied, p.name)
+		}
+	}
+	v.Cluster = &model.Cluster{ID: base.Cluster.ID, Variant: n, Perturbations: applied}
+	return v
+}
+
+// recordEmailChange は重複を避けるために近似重複のレコードのメールアドレスが before から変わった場合、
+// その変更も Perturbations に記録する
+func recordEmailChange(u *model.User, before string) {
+	if u.Cluster == nil || u.Cluster.Variant == 0 || u.Email == before {
+		return
+	}
+	if !slices.Contains(u.Cluster.Perturbations, PerturbEmail) {
+		u.Cluster.Perturbations = append(u.Cluster.Perturbations, PerturbEmail)
+	}
+}
+
+// perturbTypo は名か姓に、隣り合う文字の入れ替え・脱字・重複・置換のいずれかの誤記を加える
+func perturbTypo(_ *snapshot, u *model.User, _ Options, rnd *mathrand.Rand) bool {
+	name := &u.Name.First
+	if rnd.Intn(2) == 0 {
+		name = &u.Name.Last
+	}
+	r := []rune(*name)
+	if len(r) < 3 {
+		return false
+	}
+	i := 1 + rnd.Intn(len(r)-2)
+	switch rnd.Intn(4) {
+	case 0:
+		r[i], r[i+1] = r[i+1], r[i]
+	case 1:
+		r = slices.Delete(r, i, i+1)
+	case 2:
+		r = slices.Insert(r, i, r[i])
+	default:
+		r[i] = rune('a' + rnd.Intn(26))
+	}
+	typo := string(r)
+	if typo == *name {
+		return false
+	}
+	*name = typo
+	return true
+}
+
+// perturbNickname は名を愛称にする
+func perturbNickname(_ *snapshot, u *model.User, _ Options, rnd *mathrand.Rand) bool {
+	nicks, ok := nicknames[u.Name.First]
+	if !ok {
+		return false
+	}
+	u.Name.First = nicks[rnd.Intn(len(nicks))]
+	return true
+}
+
+// perturbTransposed は番地か郵便番号の隣り合う数字を入れ替える
+func perturbTransposed(_ *snapshot, u *model.User, _ Options, rnd *mathrand.Rand) bool {
+	if rnd.Intn(2) == 0 {
+		if t, ok := transposeDigits(strconv.Itoa(u.Location.Street.Number), rnd); ok {
+			u.Location.Street.Number, _ = strconv.Atoi(t)
+			return true
+		}
+	}
+	t, ok := transposeDigits(u.Location.Postcode, rnd)
+	if ok {
+		u.Location.Postcode = t
+	}
+	return ok
+}
+
+// transposeDigits は異なる隣り合う数字の組を1つ入れ替える。先頭が 0 になる入れ替えはしない
+func transposeDigits(s string, rnd *mathrand.Rand) (string, bool) {
+	var pairs []int
+	for i := 0; i+1 < len(s); i++ {
+		if s[i] != s[i+1] && !(i == 0 && s[i+1] == '0') {
+			pairs = append(pairs, i)
+		}
+	}
+	if len(pairs) == 0 {
+		return s, false
+	}
+	i := pairs[rnd.Intn(len(pairs))]
+	b := []byte(s)
+	b[i], b[i+1] = b[i+1], b[i]
+	return string(b), true
+}
+
+// perturbMoved は住所を新しい住所にする。州と市区町村は条件の中から選び、州は市区町村のある州にする
+func perturbMoved(s *snapshot, u *model.User, opts Options, rnd *mathrand.Rand) bool {
+	data := s.data
+	u.Location.Street = model.Street{
+		Number: rnd.Intn(9999) + 1,
+		Name:   data.List(dataset.Streets).Pick(rnd),
+	}
+	u.Location.City, u.Location.State = s.pickCityState(opts, rnd)
+	u.Location.Postcode = fmt.Sprintf("%05d", rnd.Intn(99999))
+	u.Location.Coordinates = model.Coordinates{
+		Latitude:  fmt.Sprintf("%.4f", -90.0+rnd.Float64()*180.0),
+		Longitude: fmt.Sprintf("%.4f", -180.0+rnd.Float64()*360.0),
+	}
+	return true
+}
+
+// pickCityState は条件に合う市区町村と、その市区町村のある州を選ぶ。
+// データセットの対応に条件に合う州が無い場合は、条件に合う州から選ぶ
+func (s *snapshot) pickCityState(opts Options, rnd *mathrand.Rand) (string, string) {
+	city := opts.City
+	if city == "" {
+		cities := s.data.List(dataset.Cities)
+		if opts.cities != nil {
+			cities = opts.cities
+		}
+		city = cities.Pick(rnd)
+	}
+	if opts.State != "" {
+		return city, opts.State
+	}
+
+	states := s.data.List(dataset.States)
+	if opts.states != nil {
+		states = opts.states
+	}
+	related := s.data.StatesOf(city)
+	if paired := states.Filter(func(state string) bool { return slices.Contains(related, state) }); paired != nil {
+		states = paired
+	}
+	return city, states.Pick(rnd)
+}
+
+// perturbMarriedName は結婚などで姓が変わったレコードにする。姓から作るユーザー名とメールアドレスも変わる
+func perturbMarriedName(s *snapshot, u *model.User, opts Options, rnd *mathrand.Rand) bool {
+	if u.Dob.Age < minMarriedAge {
+		return false
+	}
+	lastNames := s.data.List(dataset.LastNames)
+	if opts.lastNames != nil {
+		lastNames = opts.lastNames
+	}
+	last := lastNames.Pick(rnd)
+	if last == u.Name.Last {
+		return false
+	}
+	u.Name.Last = last
+	if u.Gender == "female" {
+		u.Name.Title = "Mrs"
+	}
+	setUsername(u, localName(u.Name.First+last)+strconv.Itoa(rnd.Intn(99)))
+	u.Email = generateEmail(*u, opts, rnd)
+	return true
+}
+
+// perturbEmail は別のメールアドレスにする
+func perturbEmail(_ *snapshot, u *model.User, opts Options, rnd *mathrand.Rand) bool {
+	email := generateEmail(*u, opts, rnd)
+	if email == u.Email {
+		return false
+	}
+	u.Email = email
+	return true
+}
@@ -0,0 +1,135 @@
+package generator
+
+import (
+	mathrand "math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+func TestGenerateDuplicates(t *testing.T) {
+	g := &Generator{}
+	opts := Options{Duplicates: 0.3, Variants: 3}
+	users, err := g.Generate(500, 8, 1, opts)
+	require.NoError(t, err)
+	require.Len(t, users, 500)
+
+	byUUID := map[string]model.User{}
+	clusters := map[string][]model.User{}
+	for i, u := range users {
+		require.NotNil(t, u.Cluster, i)
+		byUUID[u.Login.UUID] = u
+		clusters[u.Cluster.ID] = append(clusters[u.Cluster.ID], u)
+	}
+
+	variants := 0
+	for id, members := range clusters {
+		base, ok := byUUID[id]
+		require.True(t, ok, "まとまりの ID は元のユーザーの UUID")
+		assert.Equal(t, 0, base.Cluster.Variant)
+		// 近似重複のレコードは途中で切れずにすべて出る
+		if len(members) > 1 {
+			assert.Len(t, members, 1+opts.Variants)
+		}
+		for _, v := range members {
+			if v.Cluster.Variant == 0 {
+				continue
+			}
+			variants++
+			assert.NotEmpty(t, v.Cluster.Perturbations)
+			assert.NotEqual(t, base.Login.UUID, v.Login.UUID)
+			// メールアドレスが変わった場合は、姓の変更に伴うもの以外は必ず email_change を記録する
+			if v.Email != base.Email && !slices.Contains(v.Cluster.Perturbations, PerturbMarriedName) {
+				assert.Contains(t, v.Cluster.Perturbations, PerturbEmail, v.Email)
+			}
+			// 同じ人物のため生年月日と性別は変えない
+			assert.Equal(t, base.Dob, v.Dob)
+			assert.Equal(t, base.Gender, v.Gender)
+		}
+	}
+	assert.Greater(t, variants, 50)
+
+	// 末尾近くで選ばれたユーザーの近似重複のレコードも件数の中に出る
+	for _, count := range []int{1, 4, 20} {
+		short, err := g.Generate(count, 8, 1, Options{Duplicates: 1, Variants: 3})
+		require.NoError(t, err)
+		require.Len(t, short, count)
+		sizes := map[string]int{}
+		for _, u := range short {
+			sizes[u.Cluster.ID]++
+		}
+		for id, n := range sizes {
+			assert.Contains(t, []int{1, 4}, n, "%d 件: %s", count, id)
+		}
+	}
+
+	// 同じシードであれば同じまとまりになる
+	again, err := g.Generate(500, 8, 1, opts)
+	require.NoError(t, err)
+	for i := range users {
+		assert.Equal(t, *users[i].Cluster, *again[i].Cluster)
+	}
+
+	// 指定しない場合は Cluster を設定しない
+	plain, err := g.Generate(10, 8, 1, Options{})
+	require.NoError(t, err)
+	for _, u := range plain {
+		assert.Nil(t, u.Cluster)
+	}
+
+	_, err = g.Generate(1, 1, 1, Options{Duplicates: 0.1, Variants: MaxVariants + 1})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+	_, err = g.Generate(1, 1, 1, Options{Duplicates: 1.5})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestTransposeDigits(t *testing.T) {
+	rnd := mathrand.New(mathrand.NewSource(1))
+	for range 100 {
+		s, ok := transposeDigits("10234", rnd)
+		require.True(t, ok)
+		assert.NotEqual(t, "10234", s)
+		assert.NotEqual(t, byte('0'), s[0], "先頭を 0 にしない")
+	}
+	_, ok := transposeDigits("1111", rnd)
+	assert.False(t, ok)
+}
+
+func TestGenerateDuplicatesConstrained(t *testing.T) {
+	data, err := dataset.Load("../data")
+	require.NoError(t, err)
+	s := builtinSnapshot()
+	s.data = data
+	g := &Generator{}
+	g.snap.Store(s)
+
+	// 転居や姓の変更を加えたレコードも、州と姓の条件と市区町村と州の対応を満たす
+	opts := Options{Duplicates: 1, Variants: 5, State: "Texas", LastNameStartsWith: "s"}
+	users, err := g.Generate(300, 3, 1, opts)
+	require.NoError(t, err)
+	perturbed := map[string]bool{}
+	for _, u := range users {
+		assert.Equal(t, "Texas", u.Location.State)
+		assert.Contains(t, data.StatesOf(u.Location.City), "Texas", u.Location.City)
+		assert.True(t, strings.HasPrefix(u.Name.Last, "S"), u.Name.Last)
+		for _, p := range u.Cluster.Perturbations {
+			perturbed[p] = true
+		}
+	}
+	assert.True(t, perturbed[PerturbMoved])
+	assert.True(t, perturbed[PerturbMarriedName])
+
+	// 市区町村だけの指定では、転居先もその市区町村のある州になる
+	users, err = g.Generate(100, 3, 1, Options{Duplicates: 1, Variants: 5, City: "Springfield"})
+	require.NoError(t, err)
+	for _, u := range users {
+		assert.Equal(t, "Springfield", u.Location.City)
+		assert.Contains(t, data.StatesOf("Springfield"), u.Location.State)
+	}
+}
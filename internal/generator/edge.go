@@ -0,0 +1,246 @@
+package generator
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/ryuhei/randomuser-go/internal/nationalid"
+)
+
+// 生成するユーザーの傾向
+const (
+	// ProfileEdge は境界値や入力検証・表示の不具合を起こしやすい値を持つユーザー
+	ProfileEdge = "edge"
+)
+
+var profiles = []string{ProfileEdge}
+
+// 境界値の種類。User.EdgeCases に記録する
+const (
+	EdgeLongName      = "long_name"
+	EdgeShortName     = "single_char_name"
+	EdgeApostrophe    = "apostrophe"
+	EdgeHyphen        = "hyphen"
+	EdgeDiacritics    = "diacritics"
+	EdgeRTL           = "rtl_script"
+	EdgeCJK           = "cjk_script"
+	EdgeEmoji         = "emoji"
+	EdgeZeroWidth     = "zero_width"
+	EdgeSQLInjection  = "sql_injection"
+	EdgeHTMLInjection = "html_injection"
+	EdgeLeapDay       = "leap_day"
+	EdgeAgeZero       = "age_0"
+	EdgeAge120        = "age_120"
+	EdgeNorthPole     = "north_pole"
+	EdgeSouthPole     = "south_pole"
+	EdgeAntimeridian  = "antimeridian"
+)
+
+// edgeCase はユーザーに境界値を設定する
+type edgeCase struct {
+	name  string
+	apply func(u *model.User, opts Options, rnd *mathrand.Rand)
+}
+
+// edgeAllowed は条件によって選べない境界値と、選べるかどうかを返す関数
+var edgeAllowed = map[string]func(opts Options) bool{
+	EdgeLeapDay: func(opts Options) bool {
+		_, ok := leapDayDob(opts.AsOf, opts.Age.Min, *opts.Age)
+		return ok
+	},
+	EdgeAgeZero: func(opts Options) bool { return opts.Age.Min == 0 },
+	EdgeAge120:  func(opts Options) bool { return opts.Age.Max >= 120 },
+}
+
+// edgeGroups は同じ項目を変える境界値のまとまり。1人には各まとまりから1つまで設定する
+var edgeGroups = [][]edgeCase{
+	{
+		{EdgeLongName, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			if rnd.Intn(2) == 0 && opts.lastNames == nil {
+				u.Name.Last = "Wolfeschlegelsteinhausenbergerdorff"
+				return
+			}
+			// 255 文字の名
+			u.Name.First = string([]rune(strings.Repeat(u.Name.First+" ", 255))[:255])
+		}},
+		{EdgeShortName, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			setName(u, opts, rnd, "A", "O")
+		}},
+		{EdgeApostrophe, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			setName(u, opts, rnd, pick(rnd, []string{"D'Andre", "Ma'ayan", "N'Golo"}), pick(rnd, []string{"O'Brien", "D'Angelo", "O'Connor", "Dell'Acqua"}))
+		}},
+		{EdgeHyphen, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			setName(u, opts, rnd, pick(rnd, []string{"Mary-Jane", "Jean-Luc", "Anne-Marie"}), pick(rnd, []string{"Smith-Jones", "Lloyd-Webber", "Garcia-Lopez"}))
+		}},
+		{EdgeDiacritics, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			setName(u, opts, rnd, pick(rnd, []string{"José", "Zoë", "Łukasz", "Søren", "Ångström", "François"}), pick(rnd, []string{"Müller", "Nguyễn", "Dvořák", "Ñúñez", "Çelik"}))
+		}},
+		{EdgeRTL, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			if rnd.Intn(2) == 0 {
+				setFullName(u, opts, "محمد", "العلي")
+			} else {
+				setFullName(u, opts, "דוד", "כהן")
+			}
+		}},
+		{EdgeCJK, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			switch rnd.Intn(3) {
+			case 0:
+				setFullName(u, opts, "太郎", "山田")
+			case 1:
+				setFullName(u, opts, "秀英", "王")
+			default:
+				setFullName(u, opts, "민준", "김")
+			}
+		}},
+		{EdgeEmoji, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			// 肌の色の修飾子や ZWJ で結合した絵文字は複数のコードポイントになる
+			u.Name.First += pick(rnd, []string{"😀", "👩🏽\u200d💻", "🏳\ufe0f\u200d🌈", "🎉"})
+		}},
+		{EdgeZeroWidth, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			// ゼロ幅スペース、ゼロ幅接合子、BOM のいずれかを名の途中に入れる
+			zw := pick(rnd, []string{"\u200b", "\u200d", "\ufeff"})
+			if r := []rune(u.Name.First); len(r) > 1 {
+				i := 1 + rnd.Intn(len(r)-1)
+				u.Name.First = string(r[:i]) + zw + string(r[i:])
+			} else {
+				u.Name.First += zw
+			}
+		}},
+		{EdgeSQLInjection, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			injection := pick(rnd, []string{"Robert'); DROP TABLE users;--", "' OR '1'='1", `"; SELECT * FROM users WHERE "a"="a`, "1; DELETE FROM users"})
+			// 姓の条件がある場合は名に入れる
+			if opts.lastNames != nil {
+				u.Name.First = injection
+			} else {
+				u.Name.Last = injection
+			}
+		}},
+		{EdgeHTMLInjection, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			u.Name.First = pick(rnd, []string{"<script>alert(1)</script>", `"><img src=x onerror=alert(1)>`, "<b>Bold</b>", "&lt;Escaped&gt; &amp;"})
+		}},
+	},
+	{
+		{EdgeLeapDay, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			if dob, ok := leapDayDob(opts.AsOf, u.Dob.Age, *opts.Age); ok {
+				setDob(u, dob, opts.AsOf, rnd)
+			}
+		}},
+		{EdgeAgeZero, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			setDob(u, opts.AsOf.AddDate(0, 0, -rnd.Intn(300)), opts.AsOf, rnd)
+		}},
+		{EdgeAge120, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			setDob(u, opts.AsOf.AddDate(-120, 0, -rnd.Intn(300)), opts.AsOf, rnd)
+		}},
+	},
+	{
+		{EdgeNorthPole, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			u.Location.Coordinates = model.Coordinates{Latitude: "90.0000", Longitude: fmt.Sprintf("%.4f", -180.0+rnd.Float64()*360.0)}
+		}},
+		{EdgeSouthPole, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			u.Location.Coordinates = model.Coordinates{Latitude: "-90.0000", Longitude: fmt.Sprintf("%.4f", -180.0+rnd.Float64()*360.0)}
+		}},
+		{EdgeAntimeridian, func(u *model.User, opts Options, rnd *mathrand.Rand) {
+			lon := "180.0000"
+			if rnd.Intn(2) == 0 {
+				lon = "-180.0000"
+			}
+			u.Location.Coordinates = model.Coordinates{Latitude: fmt.Sprintf("%.4f", -60.0+rnd.Float64()*120.0), Longitude: lon}
+		}},
+	},
+}
+
+// applyEdgeCases は 1 から 3 個のまとまりを選び、各まとまりから1つの境界値を設定して EdgeCases に記録する。
+// 生年月日は opts.AsOf を基準にし、年齢と姓の条件は満たしたままにする。メールアドレスとユーザー名は元の値のまま
+func applyEdgeCases(u *model.User, opts Options, rnd *mathrand.Rand) {
+	groups := allowedEdgeGroups(opts)
+	for _, g := range rnd.Perm(len(groups))[:1+rnd.Intn(len(groups))] {
+		c := groups[g][rnd.Intn(len(groups[g]))]
+		c.apply(u, opts, rnd)
+		u.EdgeCases = append(u.EdgeCases, c.name)
+	}
+}
+
+// allowedEdgeGroups は条件で選べる境界値だけのまとまりを返す。選べる境界値の無いまとまりは除く
+func allowedEdgeGroups(opts Options) [][]edgeCase {
+	var groups [][]edgeCase
+	for _, group := range edgeGroups {
+		var cases []edgeCase
+		for _, c := range group {
+			if allowed, ok := edgeAllowed[c.name]; !ok || allowed(opts) {
+				cases = append(cases, c)
+			}
+		}
+		if len(cases) > 0 {
+			groups = append(groups, cases)
+		}
+	}
+	return groups
+}
+
+// setName は名か姓、または両方を設定する。姓の条件がある場合は名だけを設定する
+func setName(u *model.User, opts Options, rnd *mathrand.Rand, first, last string) {
+	switch rnd.Intn(3) {
+	case 0:
+		u.Name.First = first
+	case 1:
+		if opts.lastNames == nil {
+			u.Name.Last = last
+		} else {
+			u.Name.First = first
+		}
+	default:
+		setFullName(u, opts, first, last)
+	}
+}
+
+// setFullName は名と姓を設定する。姓の条件がある場合は名だけを設定する
+func setFullName(u *model.User, opts Options, first, last string) {
+	u.Name.First = first
+	if opts.lastNames == nil {
+		u.Name.Last = last
+	}
+}
+
+// leapDayDob は年齢 age に最も近い 2 月 29 日の生年月日のうち、asOf 時点の年齢が r に収まるものを返す。
+// 年齢が上がる方を先に探し、asOf より後の日付は返さない
+func leapDayDob(asOf time.Time, age int, r AgeRange) (time.Time, bool) {
+	for _, step := range []int{-1, 1} {
+		for year := asOf.Year() - age; ; year += step {
+			// asOf の年の 2 月 29 日より前であれば、その年の誕生日はまだ来ていない
+			a := asOf.Year() - year
+			if asOf.Month() < time.February || asOf.Month() == time.February && asOf.Day() < 29 {
+				a--
+			}
+			if step < 0 && a > r.Max || step > 0 && a < r.Min {
+				break
+			}
+			if isLeap(year) && a >= r.Min && a <= r.Max {
+				return time.Date(year, time.February, 29, 0, 0, 0, 0, time.UTC), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// setDob は生年月日を変え、asOf 時点の年齢・敬称・生年月日を含む識別番号も揃える
+func setDob(u *model.User, dob, asOf time.Time, rnd *mathrand.Rand) {
+	age := asOf.Year() - dob.Year()
+	if asOf.Month() < dob.Month() || asOf.Month() == dob.Month() && asOf.Day() < dob.Day() {
+		age--
+	}
+	u.Dob = model.Dob{Date: dob.Format(time.RFC3339), Age: age}
+	if age < 18 {
+		u.Name.Title = "Master"
+		if u.Gender == "female" {
+			u.Name.Title = "Miss"
+		}
+	}
+	u.ID = nationalid.Generate(u.NAT, u.Gender, dob, rnd)
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateEdgeProfile(t *testing.T) {
+	g := &Generator{}
+	asOf := time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)
+	opts := Options{Profile: ProfileEdge, AsOf: asOf, Age: &AgeRange{Min: 0, Max: MaxAge}}
+	users, err := g.Generate(300, 5, 1, opts)
+	require.NoError(t, err)
+
+	seen := map[string]bool{}
+	for i, u := range users {
+		require.NotEmpty(t, u.EdgeCases, i)
+		for _, c := range u.EdgeCases {
+			seen[c] = true
+		}
+		dob, err := time.Parse(time.RFC3339, u.Dob.Date)
+		require.NoError(t, err)
+		switch {
+		case slices.Contains(u.EdgeCases, EdgeLeapDay):
+			assert.Equal(t, time.February, dob.Month())
+			assert.Equal(t, 29, dob.Day())
+		case slices.Contains(u.EdgeCases, EdgeAgeZero):
+			assert.Equal(t, 0, u.Dob.Age)
+		case slices.Contains(u.EdgeCases, EdgeAge120):
+			assert.Equal(t, 120, u.Dob.Age)
+		}
+		// 生年月日は基準日から決まる
+		assert.False(t, dob.After(asOf), u.Dob.Date)
+		if slices.Contains(u.EdgeCases, EdgeNorthPole) {
+			assert.Equal(t, "90.0000", u.Location.Coordinates.Latitude)
+		}
+	}
+	// 300 人いればすべての境界値が現れる
+	for _, group := range edgeGroups {
+		for _, c := range group {
+			assert.True(t, seen[c.name], c.name)
+		}
+	}
+
+	// 同じシードと基準日であれば、生年月日と識別番号も含めて同じユーザーになる
+	again, err := g.Generate(300, 5, 1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, users, again)
+
+	// 指定しない場合は境界値を設定しない
+	plain, err := g.Generate(10, 5, 1, Options{})
+	require.NoError(t, err)
+	for _, u := range plain {
+		assert.Empty(t, u.EdgeCases)
+	}
+
+	_, err = g.Generate(1, 5, 1, Options{Profile: "unknown"})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestGenerateEdgeProfileConstrained(t *testing.T) {
+	// 境界値を設定しても年齢と姓の条件は満たしたままにする
+	opts := Options{Profile: ProfileEdge, Age: &AgeRange{Min: 30, Max: 40}, LastNameStartsWith: "j"}
+	users, err := (&Generator{}).Generate(300, 5, 1, opts)
+	require.NoError(t, err)
+	seen := map[string]bool{}
+	for _, u := range users {
+		require.NotEmpty(t, u.EdgeCases)
+		for _, c := range u.EdgeCases {
+			seen[c] = true
+		}
+		assert.GreaterOrEqual(t, u.Dob.Age, 30, u.EdgeCases)
+		assert.LessOrEqual(t, u.Dob.Age, 40, u.EdgeCases)
+		assert.True(t, strings.HasPrefix(u.Name.Last, "J"), "%s %v", u.Name.Last, u.EdgeCases)
+	}
+	assert.False(t, seen[EdgeAgeZero])
+	assert.False(t, seen[EdgeAge120])
+	assert.True(t, seen[EdgeLeapDay])
+	assert.True(t, seen[EdgeSQLInjection])
+	assert.True(t, seen[EdgeCJK])
+}
+
+func TestGenerateEdgeLeapDay(t *testing.T) {
+	// 閏年の 2 月 29 日より前の基準日でも、未来の生年月日や負の年齢にしない
+	asOf := time.Date(2028, 1, 15, 0, 0, 0, 0, time.UTC)
+	for _, age := range []AgeRange{{Min: 0, Max: 0}, {Min: 0, Max: 5}, {Min: 3, Max: 3}} {
+		users, err := (&Generator{}).Generate(200, 7, 1, Options{Profile: ProfileEdge, AsOf: asOf, Age: &age})
+		require.NoError(t, err)
+		leapDays := 0
+		for _, u := range users {
+			dob, err := time.Parse(time.RFC3339, u.Dob.Date)
+			require.NoError(t, err)
+			assert.False(t, dob.After(asOf), u.Dob.Date)
+			assert.GreaterOrEqual(t, u.Dob.Age, age.Min, u.EdgeCases)
+			assert.LessOrEqual(t, u.Dob.Age, age.Max, u.EdgeCases)
+			if slices.Contains(u.EdgeCases, EdgeLeapDay) {
+				leapDays++
+				assert.Equal(t, "2024-02-29T00:00:00Z", u.Dob.Date)
+				assert.Equal(t, 3, u.Dob.Age)
+			}
+		}
+		// 0 歳では 2 月 29 日の誕生日がまだ無いため設定しない
+		if age.Max == 0 {
+			assert.Zero(t, leapDays)
+		} else {
+			assert.NotZero(t, leapDays, "%d-%d", age.Min, age.Max)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package generator
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// weighted は重み付きで選ぶ値。設定では "値:重み" と書き、重みを省略した場合は 1 になる
+type weighted struct {
+	value  string
+	weight int
+}
+
+// defaultEmailPatterns はメールアドレスのローカル部の既定の書式と重み
+var defaultEmailPatterns = []weighted{
+	{"{first}.{last}", 40},
+	{"{f}{last}", 20},
+	{"{first}{last}{nn}", 15},
+	{"{first}_{last}", 10},
+	{"{username}+{tag}", 10},
+	{"{first}{yyyy}", 5},
+}
+
+// defaultEmailDomains はメールアドレスの既定のドメインと重み。
+// 予約済みの example.* と .test (RFC 2606)、国際化ドメイン名の試験用の .テスト、架空の会社の .example のドメインを使う
+var defaultEmailDomains = []weighted{
+	{"example.com", 35},
+	{"example.net", 15},
+	{"example.org", 15},
+	{"mail.test", 10},
+	{"acme.example", 8},
+	{"globex.example", 7},
+	{"initech.example", 5},
+	{"例え.テスト", 5},
+}
+
+// emailTags は {tag} に入るサブアドレスの語
+var emailTags = []string{"news", "shop", "work", "social", "travel", "bills", "spam", "test"}
+
+// emailTokens は書式に使えるプレースホルダー
+var emailTokens = []string{"{first}", "{last}", "{f}", "{l}", "{username}", "{tag}", "{nn}", "{yyyy}"}
+
+// parseWeighted は "値:重み" の一覧を読み取る。空の場合は defaults を返す
+func parseWeighted(items []string, defaults []weighted, what string) ([]weighted, error) {
+	var ws []weighted
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		w := weighted{value: item, weight: 1}
+		if i := strings.LastIndex(item, ":"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: %sの重みは 1 以上の整数で指定してください: %q", ErrInvalidOptions, what, item)
+			}
+			w = weighted{value: item[:i], weight: n}
+		}
+		ws = append(ws, w)
+	}
+	if len(ws) == 0 {
+		return defaults, nil
+	}
+	return ws, nil
+}
+
+func pickWeighted(rnd *mathrand.Rand, ws []weighted) string {
+	total := 0
+	for _, w := range ws {
+		total += w.weight
+	}
+	n := rnd.Intn(total)
+	for _, w := range ws {
+		if n < w.weight {
+			return w.value
+		}
+		n -= w.weight
+	}
+	return ws[0].value
+}
+
+// parseEmailPatterns はメールアドレスの書式を読み取り、未知のプレースホルダーや名前を含まない書式を弾く
+func parseEmailPatterns(items []string) ([]weighted, error) {
+	ws, err := parseWeighted(items, defaultEmailPatterns, "メールアドレスの書式")
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range ws {
+		rest := w.value
+		for _, t := range emailTokens {
+			rest = strings.ReplaceAll(rest, t, "")
+		}
+		if strings.ContainsAny(rest, "{}@") || !strings.Contains(w.value, "{") {
+			return nil, fmt.Errorf("%w: メールアドレスの書式は %s を組み合わせて指定してください: %q", ErrInvalidOptions, strings.Join(emailTokens, " "), w.value)
+		}
+	}
+	return ws, nil
+}
+
+// parseEmailDomains はメールアドレスのドメインを読み取る。国際化ドメイン名は Unicode の表記に揃える
+func parseEmailDomains(items []string) ([]weighted, error) {
+	ws, err := parseWeighted(items, defaultEmailDomains, "メールアドレスのドメイン")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]weighted, len(ws))
+	for i, w := range ws {
+		domain, err := idna.Lookup.ToUnicode(strings.ToLower(w.value))
+		if err != nil || !strings.Contains(domain, ".") {
+			return nil, fmt.Errorf("%w: メールアドレスのドメインが不正です: %q", ErrInvalidOptions, w.value)
+		}
+		out[i] = weighted{value: domain, weight: w.weight}
+	}
+	return out, nil
+}
+
+// generateEmail は書式とドメインを選んでメールアドレスを作る。
+// ローカル部の名前は小文字の英数字だけにする
+func generateEmail(u model.User, opts Options, rnd *mathrand.Rand) string {
+	pattern := pickWeighted(rnd, opts.emailPatterns)
+	domain := pickWeighted(rnd, opts.emailDomains)
+
+	first, last := localName(u.Name.First), localName(u.Name.Last)
+	year := ""
+	if len(u.Dob.Date) >= 4 {
+		year = u.Dob.Date[:4]
+	}
+	local := strings.NewReplacer(
+		"{first}", first,
+		"{last}", last,
+		"{f}", initial(first),
+		"{l}", initial(last),
+		"{username}", localName(u.Login.Username),
+		"{tag}", emailTags[rnd.Intn(len(emailTags))],
+		"{nn}", fmt.Sprintf("%02d", rnd.Intn(100)),
+		"{yyyy}", year,
+	).Replace(pattern)
+	return local + "@" + domain
+}
+
+// localName は名前をメールアドレスのローカル部に使える小文字の英数字にする
+func localName(s string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(s) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func initial(s string) string {
+	if s == "" {
+		return ""
+	}
+	return s[:1]
+}
+
+// uniqueLogins は1回の生成で使ったユーザー名とメールアドレス。
+// 大文字小文字を区別せずに重複を避ける
+type uniqueLogins struct {
+	usernames map[string]bool
+	emails    map[string]bool
+}
+
+func newUniqueLogins() *uniqueLogins {
+	return &uniqueLogins{usernames: make(map[string]bool), emails: make(map[string]bool)}
+}
+
+// claim はユーザー名とメールアドレスを予約する。使用済みの場合は末尾に番号を付けて重複しない値に変える。
+// ユーザー名を変えた場合は、ユーザー名から作る項目も揃える
+func (l *uniqueLogins) claim(u *model.User, opts Options) {
+	username := u.Login.Username
+	if l.usernames[strings.ToLower(username)] {
+		base := strings.TrimRight(username, "0123456789")
+		for n := 100; l.usernames[strings.ToLower(username)]; n++ {
+			username = base + strconv.Itoa(n)
+		}
+		// ユーザー名から作ったメールアドレスだけを作り直す
+		fromUsername := strings.Contains(strings.ToLower(u.Email), strings.ToLower(u.Login.Username))
+		setUsername(u, username)
+		if fromUsername {
+			u.Email = generateEmail(*u, opts, userRand(*u, "email"))
+		}
+	}
+	l.usernames[strings.ToLower(username)] = true
+
+	email := u.Email
+	if l.emails[strings.ToLower(email)] {
+		local, domain, _ := strings.Cut(u.Email, "@")
+		// サブアドレスがある場合は + の前に番号を付ける
+		name, tag, hasTag := strings.Cut(local, "+")
+		if hasTag {
+			tag = "+" + tag
+		}
+		for n := 2; l.emails[strings.ToLower(email)]; n++ {
+			email = name + strconv.Itoa(n) + tag + "@" + domain
+		}
+		u.Email = email
+	}
+	l.emails[strings.ToLower(email)] = true
+}
+
+// setUsername はユーザー名を変え、ユーザー名から作る Web サイトと SNS のアカウントも揃える
+func setUsername(u *model.User, username string) {
+	u.Login.Username = username
+	u.Internet.Website = "https://" + username + ".example"
+	u.Internet.Social = socialHandles(username)
+}
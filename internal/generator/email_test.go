@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateUniqueLogins(t *testing.T) {
+	g := &Generator{}
+	// 書式とドメインを1つにして重複が起きやすくする
+	users, err := g.Generate(3000, 11, 1, Options{EmailPatterns: []string{"{f}{last}"}, EmailDomains: []string{"example.com"}})
+	require.NoError(t, err)
+
+	emails, usernames := map[string]bool{}, map[string]bool{}
+	renamed := 0
+	for _, u := range users {
+		email, username := strings.ToLower(u.Email), strings.ToLower(u.Login.Username)
+		assert.False(t, emails[email], "メールアドレスが重複しています: %s", email)
+		assert.False(t, usernames[username], "ユーザー名が重複しています: %s", username)
+		emails[email], usernames[username] = true, true
+
+		assert.True(t, strings.HasSuffix(u.Email, "@example.com"), u.Email)
+		assert.Equal(t, "@"+u.Login.Username, u.Internet.Social.X)
+		if local, _, _ := strings.Cut(u.Email, "@"); local != localName(u.Name.First)[:1]+localName(u.Name.Last) {
+			renamed++
+		}
+	}
+	assert.Positive(t, renamed, "重複したメールアドレスには番号が付く")
+}
+
+func TestGenerateEmailPatterns(t *testing.T) {
+	g := &Generator{}
+	users, err := g.Generate(200, 5, 1, Options{
+		EmailPatterns: []string{"{username}+{tag}:1", "{first}.{last}{nn}:1"},
+		EmailDomains:  []string{"例え.テスト:3", "XN--R8JZ45G.xn--zckzah"},
+	})
+	require.NoError(t, err)
+	for _, u := range users {
+		local, domain, _ := strings.Cut(u.Email, "@")
+		assert.Contains(t, []string{"例え.テスト", "例.テスト"}, domain, "国際化ドメイン名は Unicode の表記に揃える")
+		if name, _, ok := strings.Cut(local, "+"); ok {
+			assert.True(t, strings.HasPrefix(name, localName(u.Login.Username)), u.Email)
+		} else {
+			assert.Regexp(t, `^[a-z0-9]+\.[a-z0-9]+\d{2,}$`, local)
+		}
+	}
+
+	// 同じシードと条件であれば同じメールアドレスになる
+	again, err := g.Generate(200, 5, 1, Options{
+		EmailPatterns: []string{"{username}+{tag}:1", "{first}.{last}{nn}:1"},
+		EmailDomains:  []string{"例え.テスト:3", "XN--R8JZ45G.xn--zckzah"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, users[len(users)-1].Email, again[len(again)-1].Email)
+}
+
+func TestGenerateDefaultEmailDomains(t *testing.T) {
+	users, err := (&Generator{}).Generate(500, 3, 1, Options{})
+	require.NoError(t, err)
+	domains := map[string]int{}
+	for _, u := range users {
+		_, domain, _ := strings.Cut(u.Email, "@")
+		domains[domain]++
+	}
+	// 既定のドメインには国際化ドメイン名も含む
+	assert.Positive(t, domains["例え.テスト"])
+	assert.Len(t, domains, len(defaultEmailDomains))
+}
+
+func TestEmailOptionsInvalid(t *testing.T) {
+	g := &Generator{}
+	for _, opts := range []Options{
+		{EmailPatterns: []string{"{first}.{middle}"}},
+		{EmailPatterns: []string{"fixed"}},
+		{EmailPatterns: []string{"{first}@corp"}},
+		{EmailPatterns: []string{"{first}:0"}},
+		{EmailDomains: []string{"localhost"}},
+		{EmailDomains: []string{"exa mple.com"}},
+		{EmailDomains: []string{"example.com:x"}},
+	} {
+		_, err := g.Generate(1, 1, 1, opts)
+		assert.ErrorIs(t, err, ErrInvalidOptions, "%+v", opts)
+	}
+}
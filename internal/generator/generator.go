@@ -3,19 +3,27 @@ package generator
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"log"
 	mathrand "math/rand"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/ryuhei/randomuser-go/internal/dirty"
+	"github.com/ryuhei/randomuser-go/internal/finance"
 	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/ryuhei/randomuser-go/internal/nationalid"
 )
 
 // S3クライアントのシングルトン実装
@@ -31,7 +39,7 @@ func initS3Client() {
 		// AWS SDKの設定をロード
 		cfg, err := config.LoadDefaultConfig(context.TODO())
 		if err != nil {
-			fmt.Printf("AWS設定のロードに失敗: %v\n", err)
+			log.Printf("AWS設定のロードに失敗: %v", err)
 			return
 		}
 
@@ -42,76 +50,159 @@ func initS3Client() {
 	})
 }
 
-// Generator はユーザー生成器
+// Generator はユーザー生成器。
+// データセットはスナップショットとして保持し、再読み込み時は丸ごと差し替える
 type Generator struct {
-	Data map[string]interface{}
+	snap atomic.Pointer[snapshot]
 }
 
-// LoadGenerators はジェネレーターをロードする
-func (g *Generator) LoadGenerators() error {
+// snapshot は生成に使うデータの一式。作成後は変更しない
+type snapshot struct {
+	data      *dataset.Dataset
+	portraits *portraitIndex
+	// bucket は顔写真を格納するバケット名
+	bucket   string
+	loadedAt time.Time
+}
+
+// builtinSnapshot は組み込みデータのみのスナップショットを返す
+func builtinSnapshot() *snapshot {
+	portraits, _ := newPortraitIndex(legacyPortraitManifest(), dataset.SourceBuiltin)
+	return &snapshot{
+		data:      dataset.Builtin(),
+		portraits: portraits,
+		bucket:    bucketName(),
+	}
+}
+
+// current は現在のスナップショットを返す。未ロードの場合は組み込みデータを使う
+func (g *Generator) current() *snapshot {
+	if s := g.snap.Load(); s != nil {
+		return s
+	}
+	return builtinSnapshot()
+}
+
+// LoadGenerators はワーキングディレクトリの internal/data からジェネレーターをロードする
+func (g *Generator) LoadGenerators(bucket string) error {
 	// APIディレクトリの確認
 	workDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("ワーキングディレクトリの取得に失敗: %v", err)
 	}
-	dataDir := filepath.Join(workDir, "internal", "data")
+	return g.LoadGeneratorsFrom(filepath.Join(workDir, "internal", "data"), bucket)
+}
+
+// LoadGeneratorsFrom は指定したディレクトリからジェネレーターをロードする。
+// 新しいスナップショットは読み込みに成功した場合のみ差し替えるため、
+// 処理中のリクエストは古いスナップショットのまま完了する。
+// bucket は顔写真のバケット名で、空の場合は環境変数 BUCKET_NAME か既定のバケットを使う
+func (g *Generator) LoadGeneratorsFrom(dataDir, bucket string) error {
 	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
 		return fmt.Errorf("APIディレクトリが見つかりません: %v", err)
 	}
 
-	g.Data = make(map[string]interface{})
-	// 名前リスト
-	firstNamesMale, err := readLines(filepath.Join(dataDir, "male_first.txt"))
-	if err == nil {
-		g.Data["first_names_male"] = firstNamesMale
-	}
-
-	firstNamesFemale, err := readLines(filepath.Join(dataDir, "female_first.txt"))
-	if err == nil {
-		g.Data["first_names_female"] = firstNamesFemale
+	data, err := dataset.Load(dataDir)
+	if err != nil {
+		return fmt.Errorf("データセットの読み込みに失敗: %v", err)
 	}
 
-	lastNames, err := readLines(filepath.Join(dataDir, "last.txt"))
-	if err == nil {
-		g.Data["last_names"] = lastNames
+	if bucket == "" {
+		bucket = bucketName()
 	}
+	g.snap.Store(&snapshot{
+		data:      data,
+		portraits: loadPortraitIndex(dataDir, bucket),
+		bucket:    bucket,
+		loadedAt:  time.Now(),
+	})
 
 	return nil
 }
 
-// readLines はファイルから行を読み込みリストで返す
-func readLines(filename string) ([]string, error) {
-	content, err := os.ReadFile(filename)
+// LoadedAt は現在のスナップショットを読み込んだ時刻を返す。未ロードの場合はゼロ値
+func (g *Generator) LoadedAt() time.Time {
+	return g.current().loadedAt
+}
+
+// Datasets は使用中のデータセットの概要を返す
+func (g *Generator) Datasets() []dataset.Info {
+	s := g.current()
+	infos := s.data.Info()
+
+	size := 0
+	for _, p := range s.portraits.pools {
+		size += len(p.keys)
+	}
+	return append(infos, dataset.Info{
+		Name:   "portraits",
+		Source: s.portraits.source,
+		Size:   size,
+	})
+}
+
+// Generate は指定された数のユーザーを生成
+func (g *Generator) Generate(count int, seed int64, page int, opts Options) ([]model.User, error) {
+	users := make([]model.User, 0, count)
+	err := g.Stream(count, seed, opts, func(u model.User) error {
+		users = append(users, u)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	lines := strings.Split(string(content), "\n")
-	var result []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			result = append(result, line)
-		}
-	}
-	return result, nil
+	return users, nil
 }
 
-// Generate は指定された数のユーザーを生成
-func (g *Generator) Generate(count int, seed int64, page int, gender string) ([]model.User, error) {
+// Stream は指定された数のユーザーを1人ずつ生成して fn に渡す。
+// 同じシードと条件であれば Generate と同じ順序で同じユーザーが得られる。
+// ユーザー名とメールアドレスは生成する範囲の中で重複しない。
+// opts.Duplicates を指定した場合は、選ばれたユーザーの近似重複のレコードを少し後に混ぜ、count 件に含める。
+// opts.Dirty を指定した場合は、重複を避けた後に一部のユーザーを破損させる。
+// 年齢・州・市区町村・姓の条件は条件に合う値だけから選んで満たし、データセットで満たせない場合はエラーを返す
+func (g *Generator) Stream(count int, seed int64, opts Options, fn func(model.User) error) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		return err
+	}
+
 	// 乱数ジェネレーターの初期化 - これにより決定論的な結果が得られる
 	rnd := mathrand.New(mathrand.NewSource(seed))
+	// 生成中に再読み込みされても同じデータを使い続ける
+	s := g.current()
+	if opts, err = s.constrain(opts); err != nil {
+		return err
+	}
+	logins := newUniqueLogins()
+	dups := newDuplicator(s, opts, count)
 
 	// ユーザー生成
-	users := make([]model.User, count)
 	for i := 0; i < count; i++ {
-		users[i] = g.generateUser(gender, rnd)
+		u, ok := dups.next(i)
+		if !ok {
+			u = s.generateUser(opts, rnd)
+		}
+		email := u.Email
+		logins.claim(&u, opts)
+		recordEmailChange(&u, email)
+		if !ok {
+			// 近似重複のレコードは、重複を避けた後の元のユーザーから作る
+			dups.add(i, &u)
+		}
+		if opts.dirty.Enabled() {
+			dirty.Apply(&u, opts.dirty, userRand(u, "dirty"))
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
 	}
 
-	return users, nil
+	return nil
 }
 
 // generateUser は1人のユーザーを生成
-func (g *Generator) generateUser(gender string, rnd *mathrand.Rand) model.User {
+func (s *snapshot) generateUser(opts Options, rnd *mathrand.Rand) model.User {
+	gender := opts.Gender
 	if gender == "" {
 		if rnd.Intn(2) == 1 {
 			gender = "male"
@@ -120,64 +211,86 @@ func (g *Generator) generateUser(gender string, rnd *mathrand.Rand) model.User {
 		}
 	}
 
-	var firstName, lastName string
-
-	if gender == "male" && g.Data["first_names_male"] != nil {
-		firstNames := g.Data["first_names_male"].([]string)
-		firstName = firstNames[rnd.Intn(len(firstNames))]
-	} else if gender == "female" && g.Data["first_names_female"] != nil {
-		firstNames := g.Data["first_names_female"].([]string)
-		firstName = firstNames[rnd.Intn(len(firstNames))]
-	} else {
-		maleNames := []string{"John", "Robert", "Michael", "David", "William"}
-		femaleNames := []string{"Mary", "Patricia", "Jennifer", "Linda", "Elizabeth"}
+	age := opts.Age.Min + rnd.Intn(opts.Age.Max-opts.Age.Min+1)
+	u := s.generatePerson(gender, age, opts, rnd)
+	applyExtras(&u, opts)
+	if opts.Profile == ProfileEdge {
+		applyEdgeCases(&u, opts, userRand(u, "edge"))
+	}
+	return u
+}
 
-		if gender == "male" {
-			firstName = maleNames[rnd.Intn(len(maleNames))]
-		} else {
-			firstName = femaleNames[rnd.Intn(len(femaleNames))]
-		}
+// applyExtras は顔写真の種類と追加の項目を、generatePerson で作ったユーザーに反映する
+func applyExtras(u *model.User, opts Options) {
+	switch opts.Picture {
+	case PicturePlaceholder:
+		u.Picture = placeholderPicture(u.Gender)
+	case PictureNone:
+		u.Picture = model.Picture{}
+	}
+	if opts.includes(IncludeFinance) {
+		f := finance.Generate(opts.Nat, opts.AsOf, userRand(*u, IncludeFinance))
+		u.Finance = &f
 	}
+}
+
+// userRand はユーザーの UUID と用途から決まる乱数を返す。
+// 追加の項目はこの乱数で生成し、指定の有無によって他の項目が変わらないようにする
+func userRand(u model.User, purpose string) *mathrand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(u.Login.UUID + "/" + purpose))
+	return mathrand.New(newSplitMix(int64(h.Sum64())))
+}
+
+// generatePerson は性別と年齢を決めた1人のユーザーを生成する。opts は normalize したもの
+func (s *snapshot) generatePerson(gender string, age int, opts Options, rnd *mathrand.Rand) model.User {
+	data := s.data
+	nat := opts.Nat
 
-	if g.Data != nil && g.Data["last_names"] != nil {
-		lastNames := g.Data["last_names"].([]string)
-		lastName = lastNames[rnd.Intn(len(lastNames))]
-	} else {
-		defaultLastNames := []string{"Smith", "Johnson", "Williams", "Brown", "Jones"}
-		lastName = defaultLastNames[rnd.Intn(len(defaultLastNames))]
+	dob := opts.AsOf.AddDate(-age, 0, -rnd.Intn(365))
+
+	firstName := pickFirstName(data, gender, dob.Year(), rnd)
+	lastNames := data.List(dataset.LastNames)
+	if opts.lastNames != nil {
+		lastNames = opts.lastNames
+	}
+	lastName := lastNames.Pick(rnd)
+	cities, states := data.List(dataset.Cities), data.List(dataset.States)
+	if opts.cities != nil {
+		cities = opts.cities
+	}
+	if opts.states != nil {
+		states = opts.states
 	}
 
 	title := "Mr"
 	if gender == "female" {
 		title = "Ms"
 	}
-
-	var photoNumber int
-	if gender == "male" {
-		photoNumber = rnd.Intn(46) + 1
-	} else {
-		photoNumber = rnd.Intn(24) + 1
+	if age < 18 {
+		title = "Master"
+		if gender == "female" {
+			title = "Miss"
+		}
 	}
 
-	email := strings.ToLower(firstName) + "." + strings.ToLower(lastName) + "@example.com"
+	// 識別番号は1回の乱数から作る別の系列で生成し、国籍によって rnd の消費が変わらないようにする
+	idRnd := mathrand.New(newSplitMix(rnd.Int63n(100000000)))
 
-	userID := rnd.Int63n(100000000)
-
-	bucket := os.Getenv("BUCKET_NAME")
-	if bucket == "" {
-		bucket = "profile-generator"
+	// 顔写真を使わない場合も選ぶための乱数は消費し、他の項目が変わらないようにする。署名は顔写真を使う場合だけ行う
+	thumbnailKey := s.portraits.pick(gender, age, nat, rnd)
+	var thumbnailURL string
+	if opts.Picture != PicturePlaceholder && opts.Picture != PictureNone {
+		thumbnailURL = s.portraitURL(thumbnailKey, opts.PortraitBaseURL)
 	}
-	thumbnailKey := fmt.Sprintf("%s/portrait (%d).png", gender, photoNumber)
-
-	thumbnailURL, _ := generateSignedURL(bucket, thumbnailKey, 10*time.Minute)
 
-	largeURL := fmt.Sprintf("https://example.com/placeholder/%s/large.png", gender)
-	mediumURL := fmt.Sprintf("https://example.com/placeholder/%s/medium.png", gender)
+	placeholder := placeholderPicture(gender)
+	largeURL, mediumURL := placeholder.Large, placeholder.Medium
 	if thumbnailURL == "" {
-		thumbnailURL = fmt.Sprintf("https://example.com/placeholder/%s/thumbnail.png", gender)
+		thumbnailURL = placeholder.Thumbnail
 	}
 
-	return model.User{
+	u := model.User{
 		Gender: gender,
 		Name: model.Name{
 			Title: title,
@@ -187,18 +300,17 @@ func (g *Generator) generateUser(gender string, rnd *mathrand.Rand) model.User {
 		Location: model.Location{
 			Street: model.Street{
 				Number: rnd.Intn(9999) + 1,
-				Name:   generateRandomStreetWithRand(rnd),
+				Name:   data.List(dataset.Streets).Pick(rnd),
 			},
-			City:     generateRandomCityWithRand(rnd),
-			State:    generateRandomStateWithRand(rnd),
-			Country:  "US",
+			City:     cities.Pick(rnd),
+			State:    states.Pick(rnd),
+			Country:  nat,
 			Postcode: fmt.Sprintf("%05d", rnd.Intn(99999)),
 			Coordinates: model.Coordinates{
 				Latitude:  fmt.Sprintf("%.4f", -90.0+rnd.Float64()*180.0),
 				Longitude: fmt.Sprintf("%.4f", -180.0+rnd.Float64()*360.0),
 			},
 		},
-		Email: email,
 		Login: model.Login{
 			UUID:     generateUUIDWithRand(rnd),
 			Username: strings.ToLower(firstName + lastName + strconv.Itoa(rnd.Intn(99))),
@@ -209,42 +321,72 @@ func (g *Generator) generateUser(gender string, rnd *mathrand.Rand) model.User {
 			SHA256:   generateRandomStringWithRand(rnd, 64),
 		},
 		Dob: model.Dob{
-			Date: time.Now().AddDate(-rnd.Intn(80)-18, -rnd.Intn(12), -rnd.Intn(28)).Format(time.RFC3339),
-			Age:  rnd.Intn(80) + 18,
+			Date: dob.Format(time.RFC3339),
+			Age:  age,
 		},
 		Registered: model.Registered{
-			Date: time.Now().AddDate(-rnd.Intn(20), -rnd.Intn(12), -rnd.Intn(28)).Format(time.RFC3339),
+			Date: opts.AsOf.AddDate(-rnd.Intn(20), -rnd.Intn(12), -rnd.Intn(28)).Format(time.RFC3339),
 			Age:  rnd.Intn(20),
 		},
-		Phone: fmt.Sprintf("(%03d)-%03d-%04d", rnd.Intn(1000), rnd.Intn(1000), rnd.Intn(10000)),
-		Cell:  fmt.Sprintf("(%03d)-%03d-%04d", rnd.Intn(1000), rnd.Intn(1000), rnd.Intn(10000)),
-		ID: model.ID{
-			Name:  "ID",
-			Value: fmt.Sprintf("%08d", userID),
-		},
+		ID: nationalid.Generate(nat, gender, dob, idRnd),
 		Picture: model.Picture{
 			Large:     largeURL,
 			Medium:    mediumURL,
 			Thumbnail: thumbnailURL,
 		},
-		NAT: "US",
+		NAT: nat,
+	}
+	if opts.State != "" {
+		u.Location.State = opts.State
 	}
+	if opts.City != "" {
+		u.Location.City = opts.City
+	}
+	// 電話番号は以前の形式と同じだけ rnd を消費して作る別の系列で生成し、同じシードで他の項目が変わらないようにする
+	phone, cell := generatePhones(nat, u.Location.State, mathrand.New(newSplitMix(legacyPhoneSeed(rnd))))
+	u.Phone, u.PhoneE164 = phone.display, phone.e164
+	u.Cell, u.CellE164 = cell.display, cell.e164
+
+	u.Internet = generateInternet(u, opts, userRand(u, "internet"))
+	u.Email = generateEmail(u, opts, userRand(u, "email"))
+	return u
 }
 
-// 決定論的なヘルパー関数
-func generateRandomStreetWithRand(rnd *mathrand.Rand) string {
-	streets := []string{"Main Street", "Park Avenue", "Oak Street", "Maple Avenue", "Cedar Road"}
-	return streets[rnd.Intn(len(streets))]
+// placeholderPicture は性別ごとの仮の画像を返す
+func placeholderPicture(gender string) model.Picture {
+	return model.Picture{
+		Large:     fmt.Sprintf("https://example.com/placeholder/%s/large.png", gender),
+		Medium:    fmt.Sprintf("https://example.com/placeholder/%s/medium.png", gender),
+		Thumbnail: fmt.Sprintf("https://example.com/placeholder/%s/thumbnail.png", gender),
+	}
 }
 
-func generateRandomCityWithRand(rnd *mathrand.Rand) string {
-	cities := []string{"New York", "Los Angeles", "Chicago", "Houston", "Phoenix"}
-	return cities[rnd.Intn(len(cities))]
+// legacyPhoneSeed は以前の電話番号の生成と同じ順序で rnd を使い、その値から電話番号の系列のシードを作る
+func legacyPhoneSeed(rnd *mathrand.Rand) int64 {
+	var seed int64
+	for _, n := range []int{1000, 1000, 10000, 1000, 1000, 10000} {
+		seed = seed*int64(n) ^ int64(rnd.Intn(n))
+	}
+	return seed
 }
 
-func generateRandomStateWithRand(rnd *mathrand.Rand) string {
-	states := []string{"California", "New York", "Texas", "Florida", "Illinois"}
-	return states[rnd.Intn(len(states))]
+// decadeFirstNameRate は年代別のリストがある年代で、名前をそのリストから選ぶ割合
+const decadeFirstNameRate = 0.7
+
+// pickFirstName は出生年代の名前を選ぶ。年代別のリストを decadeFirstNameRate の割合で使い、
+// 残りはより後の年代にだけ流行した名前を除いた全体のリストから選ぶ。年代別のリストが無い年代は全体のリストから選ぶ
+func pickFirstName(data *dataset.Dataset, gender string, birthYear int, rnd *mathrand.Rand) string {
+	if decade := data.List(dataset.DecadeFirstNames(gender, birthYear)); decade != nil {
+		fallback := data.FallbackFirstNames(gender, birthYear)
+		if fallback == nil || rnd.Float64() < decadeFirstNameRate {
+			return decade.Pick(rnd)
+		}
+		return fallback.Pick(rnd)
+	}
+	if gender == "female" {
+		return data.List(dataset.FemaleFirstNames).Pick(rnd)
+	}
+	return data.List(dataset.MaleFirstNames).Pick(rnd)
 }
 
 // シード値に依存したUUIDを生成
@@ -259,6 +401,30 @@ func generateUUIDWithRand(rnd *mathrand.Rand) string {
 	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
 }
 
+// splitMix は SplitMix64 による軽量な乱数源。ユーザーごとに作るため rand.NewSource より初期化が安い
+type splitMix uint64
+
+func newSplitMix(seed int64) *splitMix {
+	s := splitMix(seed)
+	return &s
+}
+
+func (s *splitMix) Seed(seed int64) {
+	*s = splitMix(seed)
+}
+
+func (s *splitMix) Uint64() uint64 {
+	*s += 0x9e3779b97f4a7c15
+	z := uint64(*s)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *splitMix) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
 func generateRandomPasswordWithRand(rnd *mathrand.Rand) string {
 	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	result := make([]byte, 12)
@@ -278,6 +444,31 @@ func generateRandomStringWithRand(rnd *mathrand.Rand, length int) string {
 }
 
 // 署名付きURLを生成する関数
+// portraitURL は顔写真のキーの URL を返す。基点が無い場合は10分間有効な署名付き URL にする
+func (s *snapshot) portraitURL(key, base string) string {
+	if key == "" {
+		return ""
+	}
+	if base != "" {
+		segments := strings.Split(key, "/")
+		for i, seg := range segments {
+			segments[i] = url.PathEscape(seg)
+		}
+		return strings.TrimSuffix(base, "/") + "/" + strings.Join(segments, "/")
+	}
+	signed, _ := generateSignedURL(s.bucket, key, 10*time.Minute)
+	return signed
+}
+
+// SignPortrait は顔写真のキーの署名付き URL を返す。索引に無いキーは ErrUnknownPortrait を返す
+func (g *Generator) SignPortrait(key string) (string, error) {
+	s := g.current()
+	if !s.portraits.has(key) {
+		return "", fmt.Errorf("%w: %q", ErrUnknownPortrait, key)
+	}
+	return generateSignedURL(s.bucket, key, 10*time.Minute)
+}
+
 func generateSignedURL(bucket, key string, duration time.Duration) (string, error) {
 	// クライアントの初期化（一度だけ実行される）
 	initS3Client()
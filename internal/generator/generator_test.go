@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+)
+
+func TestGenerateFinance(t *testing.T) {
+	g := &Generator{}
+	asOf := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := Options{Nat: "NL", Include: []string{IncludeFinance}, AsOf: asOf}
+
+	users, err := g.Generate(20, 42, 1, opts)
+	require.NoError(t, err)
+	again, err := g.Generate(20, 42, 1, opts)
+	require.NoError(t, err)
+	plain, err := g.Generate(20, 42, 1, Options{Nat: "NL"})
+	require.NoError(t, err)
+
+	for i, u := range users {
+		require.NotNil(t, u.Finance)
+		assert.Equal(t, again[i].Finance, u.Finance, "同じシードであれば同じ決済情報になる")
+		assert.NotEmpty(t, u.Finance.IBAN)
+		assert.Nil(t, plain[i].Finance)
+		assert.Equal(t, plain[i].Login.UUID, u.Login.UUID, "指定の有無によって他の項目は変わらない")
+	}
+
+	_, err = g.Generate(1, 1, 1, Options{Include: []string{"unknown"}})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestGenerateFirstNamesByDecade(t *testing.T) {
+	data, err := dataset.Load("../data")
+	require.NoError(t, err)
+	s := builtinSnapshot()
+	s.data = data
+	g := &Generator{}
+	g.snap.Store(s)
+
+	// 1930年代前後に生まれた人の名前は主にその年代のリストから選び、残りも後の年代に流行した名前にはしない
+	users, err := g.Generate(1000, 1, 1, Options{Age: &AgeRange{Min: 88, Max: 95}})
+	require.NoError(t, err)
+	fromDecade := 0
+	names := map[string]bool{}
+	for _, u := range users {
+		dob, err := time.Parse(time.RFC3339, u.Dob.Date)
+		require.NoError(t, err)
+		decade := data.List(dataset.DecadeFirstNames(u.Gender, dob.Year()))
+		require.NotNil(t, decade, dob.Year())
+		if _, ok := decade.Find(u.Name.First); ok {
+			fromDecade++
+		}
+		// 年代別のリストに現れる名前は、生まれた年代以前のリストにも現れる
+		for year := 1920; year <= 2010; year += 10 {
+			if _, ok := data.List(dataset.DecadeFirstNames(u.Gender, year)).Find(u.Name.First); ok {
+				assert.LessOrEqual(t, year, dob.Year(), "%d 年生まれの %s", dob.Year(), u.Name.First)
+				break
+			}
+		}
+		assert.NotEqual(t, "Addison", u.Name.First)
+		names[u.Name.First] = true
+	}
+	// 全体のリストからも年代別のリストにある人気の名前が選ばれるため、割合は decadeFirstNameRate 以上になる
+	rate := float64(fromDecade) / float64(len(users))
+	assert.GreaterOrEqual(t, rate, decadeFirstNameRate-0.05)
+	assert.Less(t, rate, 0.9)
+	// 年代別のリストの 20 件ずつに限らず、全体のリストの名前も使う
+	assert.Greater(t, len(names), 100)
+}
+
+func TestGeneratePictureModes(t *testing.T) {
+	g := &Generator{}
+	portrait, err := g.Generate(20, 5, 1, Options{PortraitBaseURL: "https://example.test/portraits"})
+	require.NoError(t, err)
+
+	// 顔写真の種類によって他の項目は変わらない
+	for _, picture := range []string{PicturePlaceholder, PictureNone} {
+		users, err := g.Generate(20, 5, 1, Options{Picture: picture})
+		require.NoError(t, err)
+		for i, u := range users {
+			assert.NotContains(t, u.Picture.Thumbnail, "example.test", picture)
+			want := portrait[i]
+			want.Picture = u.Picture
+			assert.Equal(t, want, u, picture)
+		}
+	}
+}
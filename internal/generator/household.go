@@ -0,0 +1,283 @@
+package generator
+
+import (
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// 世帯の種類
+const (
+	HouseholdSingle            = "single"
+	HouseholdCouple            = "couple"
+	HouseholdFamily            = "family"
+	HouseholdSingleParent      = "single_parent"
+	HouseholdMultigenerational = "multigenerational"
+)
+
+// 世帯主から見た続柄
+const (
+	RoleHead   = "head"
+	RoleSpouse = "spouse"
+	RoleChild  = "child"
+	// RoleParent は同居している世帯主の親
+	RoleParent = "parent"
+)
+
+// householdTypes は世帯の種類と出現の重み
+var householdTypes = []struct {
+	name   string
+	weight int
+}{
+	{HouseholdSingle, 28},
+	{HouseholdCouple, 25},
+	{HouseholdFamily, 30},
+	{HouseholdSingleParent, 10},
+	{HouseholdMultigenerational, 7},
+}
+
+// relations は続柄の組から、前の世帯員にとって後の世帯員が何にあたるかを引く
+var relations = map[[2]string]string{
+	{RoleHead, RoleSpouse}:   "spouse",
+	{RoleSpouse, RoleHead}:   "spouse",
+	{RoleHead, RoleChild}:    "child",
+	{RoleSpouse, RoleChild}:  "child",
+	{RoleChild, RoleHead}:    "parent",
+	{RoleChild, RoleSpouse}:  "parent",
+	{RoleChild, RoleChild}:   "sibling",
+	{RoleHead, RoleParent}:   "parent",
+	{RoleParent, RoleHead}:   "child",
+	{RoleSpouse, RoleParent}: "parent_in_law",
+	{RoleParent, RoleSpouse}: "child_in_law",
+	{RoleChild, RoleParent}:  "grandparent",
+	{RoleParent, RoleChild}:  "grandchild",
+}
+
+const (
+	// sharedSurnameRate は配偶者が世帯主と同じ姓を名乗る割合
+	sharedSurnameRate = 0.8
+	// sameSexCoupleRate は同性の夫婦の割合
+	sameSexCoupleRate = 0.05
+	// otherSurnameChildRate はひとり親の世帯で子が別の姓を名乗る割合
+	otherSurnameChildRate = 0.15
+	// minCellAge は携帯電話を持つ最低年齢
+	minCellAge = 13
+)
+
+// GenerateHouseholds は指定された数の世帯を生成する。
+// 世帯員は姓・住所・固定電話を共有し、親は子より 18 歳以上年上になる。性別と年齢は世帯の構成から決めるため指定できない。
+// 州・市区町村・姓の条件は世帯主に、顔写真の種類と追加の項目は世帯員全員に適用する。
+// ユーザー名とメールアドレスは全世帯を通して重複しない
+func (g *Generator) GenerateHouseholds(count int, seed int64, opts Options) ([]model.Household, error) {
+	opts, err := opts.normalizeGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	rnd := mathrand.New(mathrand.NewSource(seed))
+	s := g.current()
+	if opts, err = s.constrain(opts); err != nil {
+		return nil, err
+	}
+
+	logins := newUniqueLogins()
+	households := make([]model.Household, 0, count)
+	for i := 0; i < count; i++ {
+		h := s.generateHousehold(opts, rnd)
+		for j := range h.Members {
+			logins.claim(&h.Members[j].User, opts)
+			applyExtras(&h.Members[j].User, opts)
+		}
+		households = append(households, h)
+	}
+	return households, nil
+}
+
+// householdBuilder は1つの世帯の世帯員を順に作る
+type householdBuilder struct {
+	s       *snapshot
+	opts    Options
+	rnd     *mathrand.Rand
+	members []model.Member
+}
+
+func (s *snapshot) generateHousehold(opts Options, rnd *mathrand.Rand) model.Household {
+	h := model.Household{
+		ID:   generateUUIDWithRand(rnd),
+		Type: pickHouseholdType(rnd),
+	}
+	b := &householdBuilder{s: s, opts: opts, rnd: rnd}
+
+	switch h.Type {
+	case HouseholdSingle:
+		b.addHead(18, 92)
+	case HouseholdCouple:
+		b.addSpouse(b.addHead(20, 90))
+	case HouseholdFamily:
+		head := b.addHead(25, 58)
+		spouse := b.addSpouse(head)
+		b.addChildren(min(head.Dob.Age, spouse.Dob.Age), head.Name.Last, 1+rnd.Intn(4))
+	case HouseholdSingleParent:
+		head := b.addHead(22, 58)
+		b.addChildren(head.Dob.Age, head.Name.Last, 1+rnd.Intn(3))
+	case HouseholdMultigenerational:
+		head := b.addHead(28, 55)
+		spouse := b.addSpouse(head)
+		b.addChildren(min(head.Dob.Age, spouse.Dob.Age), head.Name.Last, 1+rnd.Intn(3))
+		b.addParent(head)
+	}
+
+	h.Members = b.link()
+	return h
+}
+
+func pickHouseholdType(rnd *mathrand.Rand) string {
+	total := 0
+	for _, t := range householdTypes {
+		total += t.weight
+	}
+	n := rnd.Intn(total)
+	for _, t := range householdTypes {
+		if n < t.weight {
+			return t.name
+		}
+		n -= t.weight
+	}
+	return HouseholdSingle
+}
+
+// addHead は minAge 歳から maxAge 歳の世帯主を加える。世帯主の住所と固定電話を世帯で共有する
+func (b *householdBuilder) addHead(minAge, maxAge int) model.User {
+	gender := randomGender(b.rnd)
+	u := b.s.generatePerson(gender, minAge+b.rnd.Intn(maxAge-minAge+1), b.opts, b.rnd)
+	b.members = append(b.members, model.Member{Role: RoleHead, User: u})
+	return u
+}
+
+// addSpouse は世帯主と年の近い配偶者を加える
+func (b *householdBuilder) addSpouse(head model.User) model.User {
+	gender := oppositeGender(head.Gender)
+	if b.rnd.Float64() < sameSexCoupleRate {
+		gender = head.Gender
+	}
+	age := max(head.Dob.Age+b.rnd.Intn(17)-8, 18)
+
+	u := b.newMember(gender, age)
+	if b.rnd.Float64() < sharedSurnameRate {
+		b.setLastName(&u, head.Name.Last)
+		if gender == "female" {
+			u.Name.Title = "Mrs"
+		}
+	}
+	b.members = append(b.members, model.Member{Role: RoleSpouse, User: u})
+	return u
+}
+
+// addChildren は親の年齢から 18 歳以上年下になる子を n 人加える。成人した子も 24 歳までは同居する
+func (b *householdBuilder) addChildren(parentAge int, lastName string, n int) {
+	maxAge := min(parentAge-18, 24)
+	if maxAge < 0 {
+		return
+	}
+	// ひとり親の世帯では、別れた親の姓を名乗る子もいる
+	singleParent := len(b.members) == 1
+	otherSurname := ""
+	for i := 0; i < n; i++ {
+		u := b.newMember(randomGender(b.rnd), b.rnd.Intn(maxAge+1))
+		// 兄弟で同じ名前にならないよう、数回まで選び直す
+		for retry := 0; retry < 3 && b.hasFirstName(u.Name.First); retry++ {
+			u = b.newMember(u.Gender, u.Dob.Age)
+		}
+		surname := lastName
+		if singleParent && b.rnd.Float64() < otherSurnameChildRate {
+			if otherSurname == "" {
+				otherSurname = b.lastNames().Pick(b.rnd)
+			}
+			surname = otherSurname
+		}
+		b.setLastName(&u, surname)
+		b.members = append(b.members, model.Member{Role: RoleChild, User: u})
+	}
+}
+
+// addParent は世帯主より 20 歳以上年上の世帯主の親を加える
+func (b *householdBuilder) addParent(head model.User) {
+	age := min(head.Dob.Age+20+b.rnd.Intn(20), 100)
+	u := b.newMember(randomGender(b.rnd), age)
+	b.setLastName(&u, head.Name.Last)
+	if u.Gender == "female" {
+		u.Name.Title = "Mrs"
+	}
+	b.members = append(b.members, model.Member{Role: RoleParent, User: u})
+}
+
+// lastNames は世帯員の姓を選ぶリスト。姓の条件がある場合は条件に合う姓だけにする
+func (b *householdBuilder) lastNames() *dataset.List {
+	if b.opts.lastNames != nil {
+		return b.opts.lastNames
+	}
+	return b.s.data.List(dataset.LastNames)
+}
+
+func (b *householdBuilder) hasFirstName(first string) bool {
+	for _, m := range b.members {
+		if m.User.Name.First == first {
+			return true
+		}
+	}
+	return false
+}
+
+// newMember は世帯主と住所・固定電話を共有する世帯員を作る
+func (b *householdBuilder) newMember(gender string, age int) model.User {
+	u := b.s.generatePerson(gender, age, b.opts, b.rnd)
+	head := b.members[0].User
+	u.Location = head.Location
+	u.Phone, u.PhoneE164 = head.Phone, head.PhoneE164
+	if age < minCellAge {
+		u.Cell, u.CellE164 = "", ""
+	}
+	return u
+}
+
+// setLastName は姓を変え、姓から作るメールアドレスとユーザー名、ユーザー名から作る Web サイトと SNS のアカウントも揃える
+func (b *householdBuilder) setLastName(u *model.User, lastName string) {
+	first := strings.ToLower(u.Name.First)
+	last := strings.ToLower(lastName)
+	u.Name.Last = lastName
+	setUsername(u, first+last+strconv.Itoa(b.rnd.Intn(99)))
+	u.Email = generateEmail(*u, b.opts, userRand(*u, "email"))
+}
+
+// link は世帯員どうしの関係を加えた世帯員の一覧を返す
+func (b *householdBuilder) link() []model.Member {
+	for i := range b.members {
+		b.members[i].Relationships = []model.Relationship{}
+		for j, other := range b.members {
+			if i == j {
+				continue
+			}
+			if t, ok := relations[[2]string{b.members[i].Role, other.Role}]; ok {
+				b.members[i].Relationships = append(b.members[i].Relationships, model.Relationship{Type: t, UUID: other.User.Login.UUID})
+			}
+		}
+	}
+	return b.members
+}
+
+func randomGender(rnd *mathrand.Rand) string {
+	if rnd.Intn(2) == 1 {
+		return "male"
+	}
+	return "female"
+}
+
+func oppositeGender(gender string) string {
+	if gender == "male" {
+		return "female"
+	}
+	return "male"
+}
@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateHouseholds(t *testing.T) {
+	g := &Generator{}
+	households, err := g.GenerateHouseholds(300, 42, Options{})
+	require.NoError(t, err)
+	require.Len(t, households, 300)
+
+	again, err := g.GenerateHouseholds(300, 42, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, households[0].ID, again[0].ID)
+	assert.Equal(t, households[299].Members[0].User.Login.UUID, again[299].Members[0].User.Login.UUID)
+
+	types := map[string]int{}
+	sharedSurnames := 0
+	for _, h := range households {
+		types[h.Type]++
+		require.NotEmpty(t, h.Members)
+		head := h.Members[0]
+		assert.Equal(t, RoleHead, head.Role)
+
+		byUUID := map[string]model.Member{}
+		for _, m := range h.Members {
+			byUUID[m.User.Login.UUID] = m
+		}
+		for _, m := range h.Members {
+			u := m.User
+			assert.Equal(t, head.User.Location, u.Location, "住所を共有する")
+			assert.Equal(t, head.User.Phone, u.Phone, "固定電話を共有する")
+			if m.Role == RoleChild {
+				assert.Less(t, u.Dob.Age, 25)
+			}
+			if m.Role == RoleSpouse && u.Name.Last == head.User.Name.Last {
+				sharedSurnames++
+			}
+
+			for _, r := range m.Relationships {
+				other, ok := byUUID[r.UUID]
+				require.True(t, ok, "関係の相手は同じ世帯にいる")
+				switch r.Type {
+				case "child", "grandchild":
+					assert.GreaterOrEqual(t, u.Dob.Age-other.User.Dob.Age, 18, "親は子より年上")
+				case "parent", "grandparent":
+					assert.GreaterOrEqual(t, other.User.Dob.Age-u.Dob.Age, 18, "親は子より年上")
+				}
+				// 関係は双方向に張る
+				assert.True(t, hasRelationship(other, u.Login.UUID))
+			}
+		}
+	}
+
+	for _, ht := range []string{HouseholdSingle, HouseholdCouple, HouseholdFamily, HouseholdSingleParent, HouseholdMultigenerational} {
+		assert.Positive(t, types[ht], ht)
+	}
+	assert.Greater(t, sharedSurnames, 0)
+	assert.Less(t, sharedSurnames, types[HouseholdCouple]+types[HouseholdFamily]+types[HouseholdMultigenerational], "別姓の夫婦もいる")
+}
+
+func TestGenerateHouseholdsOptions(t *testing.T) {
+	g := &Generator{}
+	opts := Options{State: "Texas", LastNameStartsWith: "j", Picture: PicturePlaceholder, Include: []string{IncludeFinance}}
+	households, err := g.GenerateHouseholds(50, 42, opts)
+	require.NoError(t, err)
+	for _, h := range households {
+		for _, m := range h.Members {
+			u := m.User
+			assert.Equal(t, "Texas", u.Location.State)
+			assert.True(t, strings.HasPrefix(u.Name.Last, "J"), u.Name.Last)
+			assert.Equal(t, placeholderPicture(u.Gender), u.Picture)
+			assert.NotNil(t, u.Finance)
+		}
+	}
+}
+
+func TestGenerateHouseholdsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "未対応の国籍", opts: Options{Nat: "XX"}},
+		{name: "性別", opts: Options{Gender: "male"}},
+		{name: "年齢", opts: Options{Age: &AgeRange{Min: 20, Max: 30}}},
+		{name: "プロファイル", opts: Options{Profile: ProfileEdge}},
+		{name: "破損", opts: Options{Dirty: "0.1"}},
+		{name: "近似重複", opts: Options{Duplicates: 0.1}},
+		{name: "データセットに無い州", opts: Options{State: "Nowhere"}},
+	}
+
+	g := &Generator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := g.GenerateHouseholds(1, 1, tt.opts)
+			assert.ErrorIs(t, err, ErrInvalidOptions)
+		})
+	}
+}
+
+func hasRelationship(m model.Member, uuid string) bool {
+	for _, r := range m.Relationships {
+		if r.UUID == uuid {
+			return true
+		}
+	}
+	return false
+}
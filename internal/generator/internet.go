@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"net/netip"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// 端末の種類
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+)
+
+// documentationRanges は IP アドレスの範囲が設定されていない場合に使う文書用のアドレス (RFC 5737, RFC 3849)
+var documentationRanges = []netip.Prefix{
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// platform は端末の OS とブラウザーの組み合わせ。userAgent の %s には OS とブラウザーのバージョンが入る
+type platform struct {
+	device    string
+	weight    int
+	os        string
+	browser   string
+	userAgent string
+	versions  func(rnd *mathrand.Rand) []any
+}
+
+var platforms = []platform{
+	{DeviceDesktop, 16, "Windows", "Chrome", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36", chromeVersion},
+	{DeviceDesktop, 5, "Windows", "Edge", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%[1]d.0.0.0 Safari/537.36 Edg/%[1]d.0.0.0", chromeVersion},
+	{DeviceDesktop, 4, "Windows", "Firefox", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:%[1]d.0) Gecko/20100101 Firefox/%[1]d.0", firefoxVersion},
+	{DeviceDesktop, 6, "macOS", "Safari", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%d.%d Safari/605.1.15", safariVersion},
+	{DeviceDesktop, 5, "macOS", "Chrome", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36", chromeVersion},
+	{DeviceDesktop, 2, "Linux", "Firefox", "Mozilla/5.0 (X11; Linux x86_64; rv:%[1]d.0) Gecko/20100101 Firefox/%[1]d.0", firefoxVersion},
+	{DeviceMobile, 25, "iOS", "Safari", "Mozilla/5.0 (iPhone; CPU iPhone OS %d_%d like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%[1]d.%[2]d Mobile/15E148 Safari/604.1", iosVersion},
+	{DeviceMobile, 25, "Android", "Chrome", "Mozilla/5.0 (Linux; Android %d; %s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Mobile Safari/537.36", androidPhoneVersion},
+	{DeviceMobile, 3, "Android", "Samsung Internet", "Mozilla/5.0 (Linux; Android %d; %s) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/%d.0 Chrome/%d.0.0.0 Mobile Safari/537.36", samsungVersion},
+	{DeviceTablet, 5, "iPadOS", "Safari", "Mozilla/5.0 (iPad; CPU OS %d_%d like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%[1]d.%[2]d Mobile/15E148 Safari/604.1", iosVersion},
+	{DeviceTablet, 2, "Android", "Chrome", "Mozilla/5.0 (Linux; Android %d; %s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36", androidTabletVersion},
+}
+
+var (
+	androidPhones  = []string{"Pixel 8", "Pixel 7a", "SM-S921B", "SM-A546B", "SM-G991B", "moto g54 5G", "2201116SG"}
+	androidTablets = []string{"SM-X710", "SM-X200", "Lenovo TB-X606F", "Pixel Tablet"}
+)
+
+func chromeVersion(rnd *mathrand.Rand) []any  { return []any{120 + rnd.Intn(11)} }
+func firefoxVersion(rnd *mathrand.Rand) []any { return []any{121 + rnd.Intn(10)} }
+func safariVersion(rnd *mathrand.Rand) []any  { return []any{16 + rnd.Intn(3), rnd.Intn(6)} }
+func iosVersion(rnd *mathrand.Rand) []any     { return []any{16 + rnd.Intn(3), rnd.Intn(6)} }
+
+func androidPhoneVersion(rnd *mathrand.Rand) []any {
+	return []any{12 + rnd.Intn(3), androidPhones[rnd.Intn(len(androidPhones))], 120 + rnd.Intn(11)}
+}
+
+func androidTabletVersion(rnd *mathrand.Rand) []any {
+	return []any{12 + rnd.Intn(3), androidTablets[rnd.Intn(len(androidTablets))], 120 + rnd.Intn(11)}
+}
+
+func samsungVersion(rnd *mathrand.Rand) []any {
+	return []any{12 + rnd.Intn(3), androidPhones[2+rnd.Intn(3)], 23 + rnd.Intn(3), 115 + rnd.Intn(6)}
+}
+
+// parseIPRanges は CIDR の一覧を読み取り、IPv4 と IPv6 に分ける。どちらかが空の場合は文書用のアドレスを使う
+func parseIPRanges(cidrs []string) (v4, v6 []netip.Prefix, err error) {
+	for _, c := range cidrs {
+		p, err := netip.ParsePrefix(strings.TrimSpace(c))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: IP アドレスの範囲は CIDR で指定してください: %q", ErrInvalidOptions, c)
+		}
+		if p.Addr().Is4() {
+			v4 = append(v4, p.Masked())
+		} else {
+			v6 = append(v6, p.Masked())
+		}
+	}
+	if len(v4) == 0 {
+		v4 = documentationRanges[:3]
+	}
+	if len(v6) == 0 {
+		v6 = documentationRanges[3:]
+	}
+	return v4, v6, nil
+}
+
+// generateInternet は端末・ブラウザー・IP アドレスなどを生成する。
+// ユーザーエージェントは端末の種類に合ったものを選び、SNS のアカウント名はユーザー名から作る
+func generateInternet(u model.User, opts Options, rnd *mathrand.Rand) model.Internet {
+	p := pickPlatform(rnd)
+	return model.Internet{
+		IPv4:      randomAddr(opts.ipv4[rnd.Intn(len(opts.ipv4))], rnd).String(),
+		IPv6:      randomAddr(opts.ipv6[rnd.Intn(len(opts.ipv6))], rnd).String(),
+		MAC:       randomMAC(rnd),
+		Device:    p.device,
+		OS:        p.os,
+		Browser:   p.browser,
+		UserAgent: fmt.Sprintf(p.userAgent, p.versions(rnd)...),
+		Website:   "https://" + u.Login.Username + ".example",
+		Social:    socialHandles(u.Login.Username),
+	}
+}
+
+// socialHandles はユーザー名から SNS のアカウントを作る
+func socialHandles(username string) model.Social {
+	return model.Social{
+		X:         "@" + username,
+		Instagram: username,
+		GitHub:    username,
+		LinkedIn:  "https://www.linkedin.com/in/" + username,
+	}
+}
+
+func pickPlatform(rnd *mathrand.Rand) platform {
+	total := 0
+	for _, p := range platforms {
+		total += p.weight
+	}
+	n := rnd.Intn(total)
+	for _, p := range platforms {
+		if n < p.weight {
+			return p
+		}
+		n -= p.weight
+	}
+	return platforms[0]
+}
+
+// randomAddr は範囲 p のアドレスを選ぶ。IPv4 で 4 個以上のアドレスがある範囲では、ネットワークアドレスとブロードキャストアドレスを避ける
+func randomAddr(p netip.Prefix, rnd *mathrand.Rand) netip.Addr {
+	b := p.Addr().AsSlice()
+	hostBits := len(b)*8 - p.Bits()
+	for {
+		for i := range b {
+			bits := min(max(hostBits-(len(b)-1-i)*8, 0), 8)
+			if bits > 0 {
+				mask := byte(1<<bits - 1)
+				b[i] = b[i]&^mask | byte(rnd.Intn(256))&mask
+			}
+		}
+		addr, _ := netip.AddrFromSlice(b)
+		if !addr.Is4() || hostBits < 2 || (addr != p.Addr() && addr.Next().IsValid() && p.Contains(addr.Next())) {
+			return addr
+		}
+	}
+}
+
+// randomMAC はローカル管理のユニキャストの MAC アドレスを作る。スマートフォンなどのランダム化された MAC アドレスと同じ形式になる
+func randomMAC(rnd *mathrand.Rand) string {
+	b := make([]byte, 6)
+	for i := range b {
+		b[i] = byte(rnd.Intn(256))
+	}
+	b[0] = b[0]&^0x01 | 0x02
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", b[0], b[1], b[2], b[3], b[4], b[5])
+}
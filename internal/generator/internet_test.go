@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateInternet(t *testing.T) {
+	g := &Generator{}
+	users, err := g.Generate(500, 42, 1, Options{})
+	require.NoError(t, err)
+
+	devices := map[string]int{}
+	for _, u := range users {
+		in := u.Internet
+		devices[in.Device]++
+
+		v4 := netip.MustParseAddr(in.IPv4)
+		assert.True(t, contains(documentationRanges[:3], v4), in.IPv4)
+		assert.NotEqual(t, byte(0), v4.As4()[3], "ネットワークアドレスを避ける")
+		assert.NotEqual(t, byte(255), v4.As4()[3], "ブロードキャストアドレスを避ける")
+		assert.True(t, documentationRanges[3].Contains(netip.MustParseAddr(in.IPv6)), in.IPv6)
+		assert.Regexp(t, `^[0-9a-f][26ae](:[0-9a-f]{2}){5}$`, in.MAC, "ローカル管理のユニキャスト")
+
+		switch in.Device {
+		case DeviceMobile:
+			assert.Contains(t, in.UserAgent, "Mobile")
+			assert.NotContains(t, in.UserAgent, "iPad")
+		case DeviceTablet:
+			assert.True(t, strings.Contains(in.UserAgent, "iPad") || !strings.Contains(in.UserAgent, "Mobile"), in.UserAgent)
+		case DeviceDesktop:
+			assert.NotContains(t, in.UserAgent, "Mobile")
+		}
+		assert.Equal(t, "@"+u.Login.Username, in.Social.X)
+		assert.Equal(t, u.Login.Username, in.Social.GitHub)
+		assert.Contains(t, in.Website, u.Login.Username)
+	}
+	for _, d := range []string{DeviceDesktop, DeviceMobile, DeviceTablet} {
+		assert.Positive(t, devices[d], d)
+	}
+}
+
+func TestGenerateInternetIPRanges(t *testing.T) {
+	g := &Generator{}
+	users, err := g.Generate(100, 1, 1, Options{IPRanges: []string{"10.20.0.0/16", "fd00:1::/64"}})
+	require.NoError(t, err)
+	v4 := netip.MustParsePrefix("10.20.0.0/16")
+	v6 := netip.MustParsePrefix("fd00:1::/64")
+	for _, u := range users {
+		assert.True(t, v4.Contains(netip.MustParseAddr(u.Internet.IPv4)), u.Internet.IPv4)
+		assert.True(t, v6.Contains(netip.MustParseAddr(u.Internet.IPv6)), u.Internet.IPv6)
+	}
+
+	_, err = g.Generate(1, 1, 1, Options{IPRanges: []string{"10.0.0.0"}})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestHouseholdSocialHandles(t *testing.T) {
+	g := &Generator{}
+	households, err := g.GenerateHouseholds(50, 3, Options{})
+	require.NoError(t, err)
+	for _, h := range households {
+		for _, m := range h.Members {
+			assert.Equal(t, m.User.Login.Username, m.User.Internet.Social.GitHub, "姓を変えた世帯員もユーザー名と揃える")
+		}
+	}
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
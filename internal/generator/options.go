@@ -0,0 +1,213 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/ryuhei/randomuser-go/internal/dirty"
+)
+
+// ErrInvalidOptions は生成条件が不正な場合のエラー
+var ErrInvalidOptions = errors.New("生成条件が不正です")
+
+// Options はユーザー生成の条件
+type Options struct {
+	// Gender は "male" または "female"。空の場合はランダム
+	Gender string
+	// Nat は国籍コード。空の場合は既定の国籍
+	Nat string
+	// Include は追加で生成する項目 (IncludeFinance)
+	Include []string
+	// AsOf は生年月日・登録日・カードの有効期限などの基準日。ゼロ値の場合は現在時刻
+	AsOf time.Time
+	// IPRanges は IP アドレスを選ぶ CIDR の一覧。IPv4 と IPv6 を混ぜて指定でき、無い方は文書用のアドレスを使う
+	IPRanges []string
+	// EmailPatterns はメールアドレスのローカル部の書式。"{first}.{last}:3" のように重みを付けられる。空の場合は既定の書式を使う
+	EmailPatterns []string
+	// EmailDomains はメールアドレスのドメイン。"example.com:3" のように重みを付けられ、国際化ドメイン名も使える。空の場合は既定のドメインを使う
+	EmailDomains []string
+	// Dirty は破損させるユーザーの割合 (例: "0.05,email:0.2")。空の場合は破損させない
+	Dirty string
+	// Duplicates は近似重複のレコードを作るユーザーの割合 (0 から 1)。0 の場合は作らない
+	Duplicates float64
+	// Variants は選ばれたユーザー1人から作る近似重複のレコードの数。0 の場合は defaultVariants
+	Variants int
+	// Profile は生成するユーザーの傾向 (ProfileEdge)。空の場合は通常のユーザー
+	Profile string
+	// Age は年齢の範囲。nil の場合は 18 歳から 97 歳
+	Age *AgeRange
+	// State は住所の州。空の場合はランダム
+	State string
+	// City は住所の市区町村。空の場合はランダム
+	City string
+	// LastNameStartsWith は姓の先頭の文字列。大文字小文字は区別しない
+	LastNameStartsWith string
+	// Picture は顔写真の種類 (PicturePortrait, PicturePlaceholder, PictureNone)。空の場合は PicturePortrait
+	Picture string
+	// PortraitBaseURL は顔写真の URL の基点。指定した場合は10分で期限の切れる署名付き URL の代わりに、
+	// 基点に顔写真のキーを付けた URL を返す。基点は署名付き URL へ転送するエンドポイントを指す
+	PortraitBaseURL string
+
+	// normalize で IPRanges から作る
+	ipv4, ipv6 []netip.Prefix
+	// normalize で EmailPatterns と EmailDomains から作る
+	emailPatterns, emailDomains []weighted
+	// normalize で Dirty から作る
+	dirty dirty.Rates
+	// constrain で LastNameStartsWith から作る、条件に合う姓のリスト。nil の場合はすべての姓から選ぶ
+	lastNames *dataset.List
+	// constrain で State と City から作る、条件に合う市区町村と州のリスト。nil の場合はすべてから選ぶ
+	cities, states *dataset.List
+}
+
+// Include に指定できる項目
+const (
+	// IncludeFinance はテスト用の決済カードと IBAN
+	IncludeFinance = "finance"
+)
+
+var includes = []string{IncludeFinance}
+
+// includes は項目 name を追加で生成するかを返す
+func (o Options) includes(name string) bool {
+	return slices.Contains(o.Include, name)
+}
+
+// Picture に指定できる顔写真の種類
+const (
+	// PicturePortrait は性別・年齢・国籍に合った顔写真
+	PicturePortrait = "portrait"
+	// PicturePlaceholder は性別ごとの仮の画像
+	PicturePlaceholder = "placeholder"
+	// PictureNone は顔写真なし
+	PictureNone = "none"
+)
+
+var pictures = []string{PicturePortrait, PicturePlaceholder, PictureNone}
+
+// AgeRange は Min 歳から Max 歳までの年齢の範囲。0 歳から MaxAge 歳まで指定できる
+type AgeRange struct {
+	Min, Max int
+}
+
+// 年齢の範囲
+const (
+	defaultMinAge = 18
+	defaultMaxAge = 97
+	// MaxAge は指定できる年齢の上限
+	MaxAge = 120
+)
+
+// defaultVariants は近似重複のレコードの数が指定されない場合の数
+const defaultVariants = 2
+
+// defaultNat は国籍が指定されない場合の国籍
+const defaultNat = "US"
+
+// nationalities は生成に対応している国籍。名前と住所は国籍によらず同じデータセットから選び、
+// 識別番号は国籍ごとの形式で生成する
+var nationalities = []string{"BR", "FR", "GB", "JP", "NL", "US"}
+
+// Nationalities は生成に対応している国籍コードの一覧を返す
+func Nationalities() []string {
+	return append([]string(nil), nationalities...)
+}
+
+// normalizeGroup は世帯や組織の生成の条件を検証し、既定値を補った条件を返す。
+// 性別と年齢は構成から決め、プロファイル・破損・近似重複は世帯員や従業員の関係を崩すため、指定された場合はエラーにする
+func (o Options) normalizeGroup() (Options, error) {
+	var unsupported []string
+	if o.Gender != "" {
+		unsupported = append(unsupported, "gender")
+	}
+	if o.Age != nil {
+		unsupported = append(unsupported, "minAge/maxAge")
+	}
+	if o.Profile != "" {
+		unsupported = append(unsupported, "profile")
+	}
+	if o.Dirty != "" {
+		unsupported = append(unsupported, "dirty")
+	}
+	if o.Duplicates != 0 {
+		unsupported = append(unsupported, "duplicates")
+	}
+	if len(unsupported) > 0 {
+		return o, fmt.Errorf("%w: 世帯と組織の生成では %s を指定できません", ErrInvalidOptions, strings.Join(unsupported, ", "))
+	}
+	return o.normalize()
+}
+
+// normalize は条件を検証し、既定値を補った条件を返す
+func (o Options) normalize() (Options, error) {
+	switch o.Gender {
+	case "", "male", "female":
+	default:
+		return o, fmt.Errorf("%w: 性別は male または female を指定してください: %q", ErrInvalidOptions, o.Gender)
+	}
+
+	for _, inc := range o.Include {
+		if !slices.Contains(includes, inc) {
+			return o, fmt.Errorf("%w: 追加の項目は %s のいずれかを指定してください: %q", ErrInvalidOptions, strings.Join(includes, ", "), inc)
+		}
+	}
+	if o.Profile != "" && !slices.Contains(profiles, o.Profile) {
+		return o, fmt.Errorf("%w: プロファイルは %s のいずれかを指定してください: %q", ErrInvalidOptions, strings.Join(profiles, ", "), o.Profile)
+	}
+	var err error
+	if o.ipv4, o.ipv6, err = parseIPRanges(o.IPRanges); err != nil {
+		return o, err
+	}
+	if o.emailPatterns, err = parseEmailPatterns(o.EmailPatterns); err != nil {
+		return o, err
+	}
+	if o.emailDomains, err = parseEmailDomains(o.EmailDomains); err != nil {
+		return o, err
+	}
+	if o.dirty, err = dirty.ParseRates(o.Dirty); err != nil {
+		return o, fmt.Errorf("%w: dirty: %v", ErrInvalidOptions, err)
+	}
+	if o.Duplicates < 0 || o.Duplicates > 1 {
+		return o, fmt.Errorf("%w: 近似重複の割合は 0 から 1 の範囲で指定してください: %v", ErrInvalidOptions, o.Duplicates)
+	}
+	if o.Variants == 0 {
+		o.Variants = defaultVariants
+	}
+	if o.Variants < 1 || o.Variants > MaxVariants {
+		return o, fmt.Errorf("%w: 近似重複のレコードの数は 1 から %d の範囲で指定してください: %d", ErrInvalidOptions, MaxVariants, o.Variants)
+	}
+	if o.Age == nil {
+		o.Age = &AgeRange{Min: defaultMinAge, Max: defaultMaxAge}
+	}
+	if o.Age.Min < 0 || o.Age.Max > MaxAge || o.Age.Min > o.Age.Max {
+		return o, fmt.Errorf("%w: 年齢の範囲は 0 から %d 歳の間で、下限を上限以下にしてください: %d-%d", ErrInvalidOptions, MaxAge, o.Age.Min, o.Age.Max)
+	}
+	o.State = strings.TrimSpace(o.State)
+	o.City = strings.TrimSpace(o.City)
+	o.LastNameStartsWith = strings.TrimSpace(o.LastNameStartsWith)
+	if o.Picture == "" {
+		o.Picture = PicturePortrait
+	}
+	if !slices.Contains(pictures, o.Picture) {
+		return o, fmt.Errorf("%w: 顔写真は %s のいずれかを指定してください: %q", ErrInvalidOptions, strings.Join(pictures, ", "), o.Picture)
+	}
+	if o.AsOf.IsZero() {
+		o.AsOf = time.Now()
+	}
+
+	if o.Nat == "" {
+		o.Nat = defaultNat
+	}
+	o.Nat = strings.ToUpper(o.Nat)
+	for _, nat := range nationalities {
+		if o.Nat == nat {
+			return o, nil
+		}
+	}
+	return o, fmt.Errorf("%w: 未対応の国籍です: %q (対応: %s)", ErrInvalidOptions, o.Nat, strings.Join(nationalities, ", "))
+}
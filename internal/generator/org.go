@@ -0,0 +1,280 @@
+package generator
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// MaxOrgDepth は組織図の階層の最大の深さ
+const MaxOrgDepth = 10
+
+// department は部署と、その部署の役職
+type department struct {
+	name string
+	// weight は従業員の配分の重み
+	weight int
+	// manager は部下のいる従業員の役職
+	manager string
+	// staff は部下のいない従業員の役職
+	staff []string
+}
+
+var departments = []department{
+	{"Engineering", 30, "Engineering Manager", []string{"Software Engineer", "Senior Software Engineer", "QA Engineer", "Site Reliability Engineer", "Data Engineer"}},
+	{"Sales", 18, "Sales Manager", []string{"Account Executive", "Sales Development Representative", "Account Manager"}},
+	{"Customer Support", 12, "Support Manager", []string{"Support Specialist", "Customer Success Manager", "Technical Support Engineer"}},
+	{"Marketing", 9, "Marketing Manager", []string{"Marketing Specialist", "Content Strategist", "Product Marketing Manager", "Designer"}},
+	{"Product", 7, "Group Product Manager", []string{"Product Manager", "Product Designer", "UX Researcher"}},
+	{"Operations", 8, "Operations Manager", []string{"Operations Analyst", "Project Manager", "Facilities Coordinator"}},
+	{"Finance", 6, "Finance Manager", []string{"Accountant", "Financial Analyst", "Payroll Specialist"}},
+	{"Human Resources", 5, "HR Manager", []string{"HR Generalist", "Recruiter", "People Partner"}},
+	{"IT", 4, "IT Manager", []string{"Systems Administrator", "IT Support Technician", "Security Analyst"}},
+	{"Legal", 2, "Legal Manager", []string{"Counsel", "Paralegal", "Compliance Analyst"}},
+}
+
+// companySuffixes は会社名の末尾
+var companySuffixes = []string{"Inc.", "LLC", "Group", "Holdings", "Partners", "Corporation", "Co."}
+
+// GenerateOrganization は size 人の従業員と、最大 depth 階層の組織図を生成する。
+// 社長の直下に部署の責任者を置き、その下に部署ごとの階層を作る。同じシードであれば同じ組織になる。
+// 性別と年齢は役職から決めるため指定できない。州・市区町村・姓の条件、顔写真の種類と追加の項目は従業員全員に適用する
+func (g *Generator) GenerateOrganization(size, depth int, seed int64, opts Options) (model.Organization, error) {
+	opts, err := opts.normalizeGroup()
+	if err != nil {
+		return model.Organization{}, err
+	}
+	switch {
+	case size < 1:
+		return model.Organization{}, fmt.Errorf("%w: 従業員数は 1 以上を指定してください: %d", ErrInvalidOptions, size)
+	case depth < 1 || depth > MaxOrgDepth:
+		return model.Organization{}, fmt.Errorf("%w: 階層の深さは 1 から %d の範囲で指定してください: %d", ErrInvalidOptions, MaxOrgDepth, depth)
+	case depth == 1 && size > 1:
+		return model.Organization{}, fmt.Errorf("%w: 2 人以上の組織は階層の深さを 2 以上にしてください", ErrInvalidOptions)
+	}
+
+	rnd := mathrand.New(mathrand.NewSource(seed))
+	s := g.current()
+	if opts, err = s.constrain(opts); err != nil {
+		return model.Organization{}, err
+	}
+	b := &orgBuilder{s: s, opts: opts, rnd: rnd, emails: make(map[string]bool)}
+	b.name, b.domain = companyName(s.data, rnd)
+	b.build(size, depth)
+	logins := newUniqueLogins()
+	for i := range b.employees {
+		logins.claim(&b.employees[i], opts)
+		applyExtras(&b.employees[i], opts)
+	}
+
+	return model.Organization{Name: b.name, Domain: b.domain, Employees: b.employees}, nil
+}
+
+// companyName は姓から会社名とドメインを作る。ドメインは予約済みの .example を使う
+func companyName(data *dataset.Dataset, rnd *mathrand.Rand) (string, string) {
+	last := data.List(dataset.LastNames)
+	var parts []string
+	if rnd.Intn(3) == 0 {
+		parts = []string{last.Pick(rnd), last.Pick(rnd)}
+	} else {
+		parts = []string{last.Pick(rnd)}
+	}
+	name := strings.Join(parts, " & ") + " " + companySuffixes[rnd.Intn(len(companySuffixes))]
+	domain := strings.ToLower(strings.Join(parts, "")) + ".example"
+	return name, domain
+}
+
+// orgNode は組織図を組み立てる途中の従業員
+type orgNode struct {
+	user    model.User
+	dept    int
+	level   int
+	manager int
+	reports int
+	// quota は部下の上限。階層を浅く保つため、上限に達した上司には割り当てない
+	quota int
+}
+
+type orgBuilder struct {
+	s         *snapshot
+	opts      Options
+	rnd       *mathrand.Rand
+	name      string
+	domain    string
+	nodes     []orgNode
+	emails    map[string]bool
+	employees []model.User
+}
+
+func (b *orgBuilder) build(size, depth int) {
+	// 社長
+	b.add(-1, -1, 0, 40+b.rnd.Intn(26))
+	if size == 1 {
+		b.finish()
+		return
+	}
+
+	// 2 階層の場合は全員が社長の直属になる
+	if depth == 2 {
+		for i := 1; i < size; i++ {
+			b.add(0, pickDepartment(b.rnd), 1, 22+b.rnd.Intn(44))
+		}
+		b.finish()
+		return
+	}
+
+	// 部署の責任者。人数が少ない場合は重みの大きい部署から置く
+	deptCount := min(len(departments), max(1, (size-1)/8), size-1)
+	heads := make([]int, deptCount)
+	for d := 0; d < deptCount; d++ {
+		heads[d] = len(b.nodes)
+		b.add(0, d, 1, 35+b.rnd.Intn(28))
+	}
+
+	// 残りの従業員を部署に配り、部署ごとに上から順に上司を割り当てる
+	remaining := size - 1 - deptCount
+	members := make([]int, deptCount)
+	total := 0
+	for d := 0; d < deptCount; d++ {
+		total += departments[d].weight
+	}
+	for i := 0; i < remaining; i++ {
+		n := b.rnd.Intn(total)
+		for d := 0; d < deptCount; d++ {
+			if n < departments[d].weight {
+				members[d]++
+				break
+			}
+			n -= departments[d].weight
+		}
+	}
+	for d := 0; d < deptCount; d++ {
+		b.fillDepartment(heads[d], d, members[d], depth)
+	}
+	b.finish()
+}
+
+// fillDepartment は部署の責任者 head の下に n 人を幅優先で配置する。
+// 部下の人数は部署の人数と残りの階層から決め、深さ depth を超えないようにする
+func (b *orgBuilder) fillDepartment(head, dept, n, depth int) {
+	levels := depth - 2
+	span := 2
+	// 上限は span-1 人まで下がるため、その場合でも全員が収まるようにする
+	for capacity(span-1, levels) < n {
+		span++
+	}
+
+	b.nodes[head].quota = b.quota(span)
+	queue := []int{head}
+	pos := 0
+	for i := 0; i < n; i++ {
+		// 上限に達した上司を飛ばす。全員が上限に達した場合は上限を1人ずつ増やして先頭からやり直す
+		for b.nodes[queue[pos]].reports >= b.nodes[queue[pos]].quota {
+			if pos++; pos == len(queue) {
+				for _, q := range queue {
+					b.nodes[q].quota++
+				}
+				pos = 0
+			}
+		}
+		manager := queue[pos]
+		level := b.nodes[manager].level + 1
+		idx := b.add(manager, dept, level, 22+b.rnd.Intn(44))
+		if level < depth-1 {
+			b.nodes[idx].quota = b.quota(span)
+			queue = append(queue, idx)
+		}
+	}
+}
+
+// quota は span の前後で部下の上限を決める
+func (b *orgBuilder) quota(span int) int {
+	return max(1, span-1+b.rnd.Intn(3))
+}
+
+// capacity は部下 span 人ずつで levels 階層に置ける人数
+func capacity(span, levels int) int {
+	total, width := 0, 1
+	for i := 0; i < levels; i++ {
+		width *= span
+		total += width
+	}
+	return total
+}
+
+func pickDepartment(rnd *mathrand.Rand) int {
+	total := 0
+	for _, d := range departments {
+		total += d.weight
+	}
+	n := rnd.Intn(total)
+	for i, d := range departments {
+		if n < d.weight {
+			return i
+		}
+		n -= d.weight
+	}
+	return 0
+}
+
+// add は従業員を加えて位置を返す
+func (b *orgBuilder) add(manager, dept, level, age int) int {
+	u := b.s.generatePerson(randomGender(b.rnd), age, b.opts, b.rnd)
+	if manager >= 0 {
+		b.nodes[manager].reports++
+	}
+	b.nodes = append(b.nodes, orgNode{user: u, dept: dept, level: level, manager: manager})
+	return len(b.nodes) - 1
+}
+
+// finish は部下の有無から役職を決め、Employment を設定した従業員の一覧を作る
+func (b *orgBuilder) finish() {
+	b.employees = make([]model.User, len(b.nodes))
+	for i, n := range b.nodes {
+		u := n.user
+		e := &model.Employment{
+			Employer:   b.name,
+			EmployeeID: employeeID(i),
+			Email:      b.workEmail(u),
+		}
+		switch {
+		case n.manager < 0:
+			e.Department = "Executive"
+			e.Title = "Chief Executive Officer"
+		case n.level == 1 && n.reports > 0:
+			e.Department = departments[n.dept].name
+			e.Title = "VP of " + departments[n.dept].name
+		case n.reports > 0:
+			e.Department = departments[n.dept].name
+			e.Title = departments[n.dept].manager
+		default:
+			d := departments[n.dept]
+			e.Department = d.name
+			e.Title = d.staff[b.rnd.Intn(len(d.staff))]
+		}
+		if n.manager >= 0 {
+			e.ManagerID = employeeID(n.manager)
+		}
+		u.Employment = e
+		b.employees[i] = u
+	}
+}
+
+// workEmail は会社のドメインで重複しない仕事用のメールアドレスを作る
+func (b *orgBuilder) workEmail(u model.User) string {
+	local := strings.ToLower(u.Name.First + "." + u.Name.Last)
+	email := local + "@" + b.domain
+	for n := 2; b.emails[email]; n++ {
+		email = local + strconv.Itoa(n) + "@" + b.domain
+	}
+	b.emails[email] = true
+	return email
+}
+
+func employeeID(i int) string {
+	return fmt.Sprintf("E%05d", i+1)
+}
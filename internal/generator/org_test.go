@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+func TestGenerateOrganization(t *testing.T) {
+	g := &Generator{}
+	for _, tt := range []struct{ size, depth int }{{500, 5}, {1, 1}, {30, 2}, {200, 3}, {1000, 10}} {
+		org, err := g.GenerateOrganization(tt.size, tt.depth, 42, Options{})
+		require.NoError(t, err)
+		require.Len(t, org.Employees, tt.size)
+		assert.True(t, strings.HasSuffix(org.Domain, ".example"))
+
+		levels := map[string]int{}
+		reports := map[string]int{}
+		emails := map[string]bool{}
+		for i, u := range org.Employees {
+			e := u.Employment
+			require.NotNil(t, e)
+			assert.Equal(t, org.Name, e.Employer)
+			assert.True(t, strings.HasSuffix(e.Email, "@"+org.Domain))
+			assert.False(t, emails[e.Email], "仕事用のメールアドレスは重複しない")
+			emails[e.Email] = true
+
+			if i == 0 {
+				assert.Empty(t, e.ManagerID)
+				assert.Equal(t, "Chief Executive Officer", e.Title)
+				levels[e.EmployeeID] = 0
+				continue
+			}
+			level, ok := levels[e.ManagerID]
+			require.True(t, ok, "上司は部下より前に並ぶ")
+			levels[e.EmployeeID] = level + 1
+			reports[e.ManagerID]++
+			assert.Less(t, level+1, tt.depth, "階層は depth を超えない")
+		}
+		for _, u := range org.Employees[1:] {
+			e := u.Employment
+			if reports[e.EmployeeID] > 0 {
+				assert.NotContains(t, []string{"Software Engineer", "Account Executive", "Recruiter"}, e.Title)
+			}
+		}
+	}
+
+	a, err := g.GenerateOrganization(100, 4, 7, Options{})
+	require.NoError(t, err)
+	b, err := g.GenerateOrganization(100, 4, 7, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, a, b, "同じシードであれば同じ組織になる")
+}
+
+func TestGenerateOrganizationInvalid(t *testing.T) {
+	g := &Generator{}
+	for _, tt := range []struct{ size, depth int }{{0, 5}, {10, 0}, {10, MaxOrgDepth + 1}, {2, 1}} {
+		_, err := g.GenerateOrganization(tt.size, tt.depth, 1, Options{})
+		assert.ErrorIs(t, err, ErrInvalidOptions, "size=%d depth=%d", tt.size, tt.depth)
+	}
+}
+
+func TestGenerateOrganizationOptions(t *testing.T) {
+	g := &Generator{}
+	asOf := time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC)
+	opts := Options{AsOf: asOf, State: "Texas", LastNameStartsWith: "j", Picture: PictureNone, Include: []string{IncludeFinance}}
+	org, err := g.GenerateOrganization(30, 3, 42, opts)
+	require.NoError(t, err)
+	for _, u := range org.Employees {
+		assert.Equal(t, "Texas", u.Location.State)
+		assert.True(t, strings.HasPrefix(u.Name.Last, "J"), u.Name.Last)
+		assert.Equal(t, model.Picture{}, u.Picture)
+		assert.NotNil(t, u.Finance)
+		registered, err := time.Parse(time.RFC3339, u.Registered.Date)
+		require.NoError(t, err)
+		assert.False(t, registered.After(asOf), "登録日は基準日以前: %s", u.Registered.Date)
+	}
+
+	_, err = g.GenerateOrganization(30, 3, 42, Options{Age: &AgeRange{Min: 20, Max: 30}})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
@@ -0,0 +1,248 @@
+package generator
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"slices"
+	"strings"
+)
+
+// phoneNumber は国内表記と E.164 の電話番号
+type phoneNumber struct {
+	display string
+	e164    string
+}
+
+// numberPlan は国ごとの電話番号の体系。
+// 各関数は国内の番号から先頭の 0 (国内プレフィックス) を除いた番号と、国内表記を返す
+type numberPlan struct {
+	countryCode string
+	landline    func(state string, rnd *mathrand.Rand) (nsn, display string)
+	mobile      func(state string, rnd *mathrand.Rand) (nsn, display string)
+}
+
+func (p numberPlan) number(f func(string, *mathrand.Rand) (string, string), state string, rnd *mathrand.Rand) phoneNumber {
+	nsn, display := f(state, rnd)
+	return phoneNumber{display: display, e164: "+" + p.countryCode + nsn}
+}
+
+// numberPlans は国籍ごとの電話番号の体系
+var numberPlans = map[string]numberPlan{
+	"US": {"1", nanpNumber, nanpNumber},
+	"GB": {"44", gbLandline, gbMobile},
+	"FR": {"33", frLandline, frMobile},
+	"NL": {"31", nlLandline, nlMobile},
+	"JP": {"81", jpLandline, jpMobile},
+	"BR": {"55", brLandline, brMobile},
+}
+
+// generatePhones は国籍 nat の固定電話と携帯電話の番号を生成する。
+// 米国の番号は州に対応する市外局番を使う
+func generatePhones(nat, state string, rnd *mathrand.Rand) (phone, cell phoneNumber) {
+	p, ok := numberPlans[nat]
+	if !ok {
+		p = numberPlans[defaultNat]
+	}
+	return p.number(p.landline, state, rnd), p.number(p.mobile, state, rnd)
+}
+
+// digitsFrom は先頭が first の範囲 (例: "2-9") の数字で、続く n-1 桁が任意の数字の列を作る
+func digitsFrom(rnd *mathrand.Rand, first string, n int) string {
+	lo, hi := first[0], first[len(first)-1]
+	var b strings.Builder
+	b.WriteByte(lo + byte(rnd.Intn(int(hi-lo)+1)))
+	for i := 1; i < n; i++ {
+		b.WriteByte(byte('0' + rnd.Intn(10)))
+	}
+	return b.String()
+}
+
+func pick(rnd *mathrand.Rand, s []string) string {
+	return s[rnd.Intn(len(s))]
+}
+
+// 北米番号計画 (NANP)
+
+// areaCodes は州ごとの市外局番
+var areaCodes = map[string][]string{
+	"Alabama":              {"205", "251", "256", "334"},
+	"Alaska":               {"907"},
+	"Arizona":              {"480", "520", "602", "623", "928"},
+	"Arkansas":             {"479", "501", "870"},
+	"California":           {"213", "310", "415", "510", "619", "714", "818", "916"},
+	"Colorado":             {"303", "719", "970"},
+	"Connecticut":          {"203", "860"},
+	"Delaware":             {"302"},
+	"District of Columbia": {"202"},
+	"Florida":              {"305", "407", "561", "727", "813", "904"},
+	"Georgia":              {"404", "470", "678", "706", "912"},
+	"Hawaii":               {"808"},
+	"Idaho":                {"208"},
+	"Illinois":             {"217", "309", "312", "630", "773", "847"},
+	"Indiana":              {"219", "317", "574", "765", "812"},
+	"Iowa":                 {"319", "515", "563", "712"},
+	"Kansas":               {"316", "620", "785", "913"},
+	"Kentucky":             {"270", "502", "606", "859"},
+	"Louisiana":            {"225", "318", "337", "504", "985"},
+	"Maine":                {"207"},
+	"Maryland":             {"240", "301", "410", "443"},
+	"Massachusetts":        {"413", "508", "617", "781", "978"},
+	"Michigan":             {"231", "248", "313", "517", "616", "734"},
+	"Minnesota":            {"218", "507", "612", "651", "763"},
+	"Mississippi":          {"228", "601", "662"},
+	"Missouri":             {"314", "417", "573", "636", "816"},
+	"Montana":              {"406"},
+	"Nebraska":             {"308", "402"},
+	"Nevada":               {"702", "775"},
+	"New Hampshire":        {"603"},
+	"New Jersey":           {"201", "609", "732", "856", "908", "973"},
+	"New Mexico":           {"505", "575"},
+	"New York":             {"212", "315", "516", "518", "585", "607", "716", "718", "914"},
+	"North Carolina":       {"252", "336", "704", "828", "910", "919"},
+	"North Dakota":         {"701"},
+	"Ohio":                 {"216", "330", "419", "513", "614", "740", "937"},
+	"Oklahoma":             {"405", "580", "918"},
+	"Oregon":               {"503", "541", "971"},
+	"Pennsylvania":         {"215", "412", "570", "610", "717", "724", "814"},
+	"Rhode Island":         {"401"},
+	"South Carolina":       {"803", "843", "864"},
+	"South Dakota":         {"605"},
+	"Tennessee":            {"423", "615", "731", "865", "901", "931"},
+	"Texas":                {"210", "214", "281", "512", "713", "817", "915", "972"},
+	"Utah":                 {"385", "435", "801"},
+	"Vermont":              {"802"},
+	"Virginia":             {"276", "434", "540", "703", "757", "804"},
+	"Washington":           {"206", "253", "360", "425", "509"},
+	"West Virginia":        {"304", "681"},
+	"Wisconsin":            {"262", "414", "608", "715", "920"},
+	"Wyoming":              {"307"},
+}
+
+// allAreaCodes は州が不明な場合に使う市外局番
+var allAreaCodes = func() []string {
+	var codes []string
+	for _, c := range areaCodes {
+		codes = append(codes, c...)
+	}
+	// map の順序に依存しないよう並べる
+	slices.Sort(codes)
+	return codes
+}()
+
+// reservedExchanges は加入者の局番に使わない番号。N11 はサービス番号、555 は架空の番号、950・958・959・976 は特殊な用途
+var reservedExchanges = map[string]bool{"555": true, "950": true, "958": true, "959": true, "976": true}
+
+// nanpNumber は NPA-NXX-XXXX の番号を作る。局番は 2 から 9 で始まり、N11 と予約された局番を避ける。
+// 北米では固定電話と携帯電話の番号の区別が無い
+func nanpNumber(state string, rnd *mathrand.Rand) (string, string) {
+	codes, ok := areaCodes[state]
+	if !ok {
+		codes = allAreaCodes
+	}
+	npa := pick(rnd, codes)
+	nxx := digitsFrom(rnd, "2-9", 3)
+	for nxx[1:] == "11" || reservedExchanges[nxx] {
+		nxx = digitsFrom(rnd, "2-9", 3)
+	}
+	line := digitsFrom(rnd, "0-9", 4)
+	return npa + nxx + line, fmt.Sprintf("(%s) %s-%s", npa, nxx, line)
+}
+
+// 英国
+
+// gbAreas は市外局番と、市外局番を除いた番号の桁数
+var gbAreas = []struct {
+	code   string
+	digits int
+}{
+	{"20", 8}, {"121", 7}, {"161", 7}, {"113", 7}, {"131", 7}, {"141", 7}, {"117", 7}, {"29", 8},
+}
+
+func gbLandline(_ string, rnd *mathrand.Rand) (string, string) {
+	a := gbAreas[rnd.Intn(len(gbAreas))]
+	local := digitsFrom(rnd, "2-8", a.digits)
+	if a.digits == 8 {
+		return a.code + local, fmt.Sprintf("0%s %s %s", a.code, local[:4], local[4:])
+	}
+	return a.code + local, fmt.Sprintf("0%s %s %s", a.code, local[:3], local[3:])
+}
+
+// gbMobile は 07 で始まる携帯電話の番号を作る。070 (個人番号) と 076 (ページャー) は避ける
+func gbMobile(_ string, rnd *mathrand.Rand) (string, string) {
+	nsn := "7" + pick(rnd, []string{"1", "2", "3", "4", "5", "7", "8", "9"}) + digitsFrom(rnd, "0-9", 8)
+	return nsn, "0" + nsn[:4] + " " + nsn[4:]
+}
+
+// フランス
+
+// frLandline は 01 から 05 (地域) で始まる固定電話の番号を作る
+func frLandline(_ string, rnd *mathrand.Rand) (string, string) {
+	nsn := digitsFrom(rnd, "1-5", 1) + digitsFrom(rnd, "0-9", 8)
+	return nsn, frDisplay(nsn)
+}
+
+// frMobile は 06 か 07 で始まる携帯電話の番号を作る
+func frMobile(_ string, rnd *mathrand.Rand) (string, string) {
+	nsn := digitsFrom(rnd, "6-7", 1) + digitsFrom(rnd, "0-9", 8)
+	return nsn, frDisplay(nsn)
+}
+
+func frDisplay(nsn string) string {
+	return fmt.Sprintf("0%s %s %s %s %s", nsn[:1], nsn[1:3], nsn[3:5], nsn[5:7], nsn[7:])
+}
+
+// オランダ
+
+func nlLandline(_ string, rnd *mathrand.Rand) (string, string) {
+	area := pick(rnd, []string{"20", "10", "70", "30", "40"})
+	local := digitsFrom(rnd, "2-9", 7)
+	return area + local, fmt.Sprintf("0%s %s %s", area, local[:3], local[3:])
+}
+
+// nlMobile は 06-1 から 06-5 で始まる携帯電話の番号を作る
+func nlMobile(_ string, rnd *mathrand.Rand) (string, string) {
+	nsn := "6" + digitsFrom(rnd, "1-5", 8)
+	return nsn, "0" + nsn[:1] + " " + nsn[1:]
+}
+
+// 日本
+
+// jpAreas は市外局番と、市外局番を除いた番号の桁数
+var jpAreas = []struct {
+	code   string
+	digits int
+}{
+	{"3", 8}, {"6", 8}, {"52", 7}, {"11", 7}, {"92", 7}, {"45", 7}, {"75", 7},
+}
+
+func jpLandline(_ string, rnd *mathrand.Rand) (string, string) {
+	a := jpAreas[rnd.Intn(len(jpAreas))]
+	local := digitsFrom(rnd, "2-9", a.digits)
+	split := a.digits - 4
+	return a.code + local, fmt.Sprintf("0%s-%s-%s", a.code, local[:split], local[split:])
+}
+
+// jpMobile は 070・080・090 で始まる携帯電話の番号を作る
+func jpMobile(_ string, rnd *mathrand.Rand) (string, string) {
+	nsn := pick(rnd, []string{"70", "80", "90"}) + digitsFrom(rnd, "1-9", 8)
+	return nsn, fmt.Sprintf("0%s-%s-%s", nsn[:2], nsn[2:6], nsn[6:])
+}
+
+// ブラジル
+
+// brAreas は主要都市の DDD (市外局番)
+var brAreas = []string{"11", "21", "31", "41", "51", "61", "71", "81", "85", "19", "27", "48"}
+
+// brLandline は 2 から 5 で始まる 8 桁の固定電話の番号を作る
+func brLandline(_ string, rnd *mathrand.Rand) (string, string) {
+	ddd := pick(rnd, brAreas)
+	local := digitsFrom(rnd, "2-5", 8)
+	return ddd + local, fmt.Sprintf("(%s) %s-%s", ddd, local[:4], local[4:])
+}
+
+// brMobile は 9 で始まる 9 桁の携帯電話の番号を作る
+func brMobile(_ string, rnd *mathrand.Rand) (string, string) {
+	ddd := pick(rnd, brAreas)
+	local := "9" + digitsFrom(rnd, "6-9", 8)
+	return ddd + local, fmt.Sprintf("(%s) %s-%s", ddd, local[:5], local[5:])
+}
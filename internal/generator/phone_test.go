@@ -0,0 +1,74 @@
+package generator
+
+import (
+	mathrand "math/rand"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePhones(t *testing.T) {
+	tests := []struct {
+		nat     string
+		code    string
+		display *regexp.Regexp
+		cell    *regexp.Regexp
+	}{
+		{"US", "+1", regexp.MustCompile(`^\(\d{3}\) \d{3}-\d{4}$`), regexp.MustCompile(`^\(\d{3}\) \d{3}-\d{4}$`)},
+		{"GB", "+44", regexp.MustCompile(`^0\d{2,3} \d{3,4} \d{4}$`), regexp.MustCompile(`^07[1-57-9]\d{2} \d{6}$`)},
+		{"FR", "+33", regexp.MustCompile(`^0[1-5]( \d{2}){4}$`), regexp.MustCompile(`^0[67]( \d{2}){4}$`)},
+		{"NL", "+31", regexp.MustCompile(`^0\d{2} \d{3} \d{4}$`), regexp.MustCompile(`^06 [1-5]\d{7}$`)},
+		{"JP", "+81", regexp.MustCompile(`^0\d{1,2}-\d{3,4}-\d{4}$`), regexp.MustCompile(`^0[789]0-\d{4}-\d{4}$`)},
+		{"BR", "+55", regexp.MustCompile(`^\(\d{2}\) [2-5]\d{3}-\d{4}$`), regexp.MustCompile(`^\(\d{2}\) 9\d{4}-\d{4}$`)},
+	}
+	e164 := regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+	for _, tt := range tests {
+		t.Run(tt.nat, func(t *testing.T) {
+			rnd := mathrand.New(mathrand.NewSource(1))
+			for range 200 {
+				phone, cell := generatePhones(tt.nat, "California", rnd)
+				assert.Regexp(t, tt.display, phone.display)
+				assert.Regexp(t, tt.cell, cell.display)
+				for _, n := range []phoneNumber{phone, cell} {
+					assert.Regexp(t, e164, n.e164)
+					assert.True(t, strings.HasPrefix(n.e164, tt.code), n.e164)
+					// 国内表記の数字から先頭の 0 を除くと、E.164 の国番号の後ろと一致する
+					digits := strings.TrimPrefix(regexp.MustCompile(`\D`).ReplaceAllString(n.display, ""), "0")
+					assert.Equal(t, tt.code+digits, n.e164)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePhonesNANP(t *testing.T) {
+	rnd := mathrand.New(mathrand.NewSource(7))
+	for state, codes := range areaCodes {
+		for range 50 {
+			phone, _ := generatePhones("US", state, rnd)
+			npa, nxx := phone.e164[2:5], phone.e164[5:8]
+			assert.Contains(t, codes, npa, state)
+			assert.NotEqual(t, "11", nxx[1:], "N11 はサービス番号")
+			assert.False(t, reservedExchanges[nxx], nxx)
+			assert.GreaterOrEqual(t, nxx[0], byte('2'))
+		}
+	}
+
+	// 州が不明な場合はいずれかの州の市外局番を使う
+	phone, _ := generatePhones("US", "", rnd)
+	assert.True(t, slices.Contains(allAreaCodes, phone.e164[2:5]), phone.e164)
+}
+
+func TestGeneratePhonesUser(t *testing.T) {
+	g := &Generator{}
+	users, err := g.Generate(50, 3, 1, Options{})
+	require.NoError(t, err)
+	for _, u := range users {
+		assert.Contains(t, areaCodes[u.Location.State], u.PhoneE164[2:5], u.Location.State)
+		assert.NotEmpty(t, u.CellE164)
+	}
+}
@@ -0,0 +1,213 @@
+package generator
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ryuhei/randomuser-go/internal/dirty"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// Population は集団ごとの割合で構成を決めた母集団の指定。JSON と YAML で書ける
+type Population struct {
+	// Size は生成するユーザーの総数
+	Size    int      `json:"size" yaml:"size"`
+	Cohorts []Cohort `json:"cohorts" yaml:"cohorts"`
+}
+
+// Cohort は母集団の中の1つの集団。空の条件は共通の条件のままにする
+type Cohort struct {
+	// Name は生成したユーザーの Cohort に記録する名前。空の場合は "cohort-1" のような連番
+	Name string `json:"name" yaml:"name"`
+	// Weight は母集団に占める割合の重み。60, 30, 10 のような百分率でも 0.6, 0.3, 0.1 のような比率でもよい
+	Weight float64 `json:"weight" yaml:"weight"`
+	Nat    string  `json:"nat" yaml:"nat"`
+	Gender string  `json:"gender" yaml:"gender"`
+	// MinAge と MaxAge は片方だけ指定した場合、もう片方を共通の条件か既定の 18 歳・97 歳にする
+	MinAge  *int   `json:"minAge" yaml:"minAge"`
+	MaxAge  *int   `json:"maxAge" yaml:"maxAge"`
+	State   string `json:"state" yaml:"state"`
+	City    string `json:"city" yaml:"city"`
+	Picture string `json:"picture" yaml:"picture"`
+	Profile string `json:"profile" yaml:"profile"`
+
+	LastNameStartsWith string `json:"lastNameStartsWith" yaml:"lastNameStartsWith"`
+}
+
+// ParsePopulation は JSON または YAML の母集団の指定を読み取る。未知の項目はエラーにする
+func ParsePopulation(data []byte) (Population, error) {
+	var p Population
+	// YAML は JSON を含むため、どちらも YAML として読む
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return Population{}, fmt.Errorf("%w: 母集団の指定を読み取れません: %v", ErrInvalidOptions, err)
+	}
+	return p, nil
+}
+
+// options は共通の条件に集団の条件を重ねる
+func (c Cohort) options(base Options) Options {
+	o := base
+	if c.Nat != "" {
+		o.Nat = c.Nat
+	}
+	if c.Gender != "" {
+		o.Gender = c.Gender
+	}
+	if c.MinAge != nil || c.MaxAge != nil {
+		age := AgeRange{Min: defaultMinAge, Max: defaultMaxAge}
+		if o.Age != nil {
+			age = *o.Age
+		}
+		if c.MinAge != nil {
+			age.Min = *c.MinAge
+		}
+		if c.MaxAge != nil {
+			age.Max = *c.MaxAge
+		}
+		o.Age = &age
+	}
+	if c.State != "" {
+		o.State = c.State
+	}
+	if c.City != "" {
+		o.City = c.City
+	}
+	if c.LastNameStartsWith != "" {
+		o.LastNameStartsWith = c.LastNameStartsWith
+	}
+	if c.Picture != "" {
+		o.Picture = c.Picture
+	}
+	if c.Profile != "" {
+		o.Profile = c.Profile
+	}
+	return o
+}
+
+func (p Population) totalWeight() float64 {
+	total := 0.0
+	for _, c := range p.Cohorts {
+		total += c.Weight
+	}
+	return total
+}
+
+// counts は最大剰余法で各集団の人数を決める。合計は必ず Size になる
+func (p Population) counts() []int {
+	total := p.totalWeight()
+	counts := make([]int, len(p.Cohorts))
+	remainders := make([]float64, len(p.Cohorts))
+	assigned := 0
+	for i, c := range p.Cohorts {
+		exact := float64(p.Size) * c.Weight / total
+		counts[i] = int(exact)
+		remainders[i] = exact - float64(counts[i])
+		assigned += counts[i]
+	}
+	// 余りの大きい集団から1人ずつ足す。余りが同じ場合は先に書いた集団を優先する
+	order := make([]int, len(p.Cohorts))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(remainders[b], remainders[a])
+	})
+	for _, i := range order[:p.Size-assigned] {
+		counts[i]++
+	}
+	return counts
+}
+
+// GeneratePopulation は母集団の指定どおりの人数の集団を混ぜてユーザーを生成する
+func (g *Generator) GeneratePopulation(p Population, seed int64, opts Options) ([]model.User, error) {
+	users := make([]model.User, 0, max(p.Size, 0))
+	err := g.StreamPopulation(p, seed, opts, func(u model.User) error {
+		users = append(users, u)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// StreamPopulation は母集団の指定どおりの人数の集団を混ぜてユーザーを1人ずつ生成し、fn に渡す。
+// 集団の並びはシードから決まり、同じシードと指定であれば同じ順序で同じユーザーが得られる。
+// 近似重複は人数の割合を崩すため指定できない
+func (g *Generator) StreamPopulation(p Population, seed int64, opts Options, fn func(model.User) error) error {
+	if p.Size < 1 {
+		return fmt.Errorf("%w: 母集団の人数は 1 以上を指定してください: %d", ErrInvalidOptions, p.Size)
+	}
+	if len(p.Cohorts) == 0 {
+		return fmt.Errorf("%w: 母集団には1つ以上の集団を指定してください", ErrInvalidOptions)
+	}
+	if opts.Duplicates != 0 {
+		return fmt.Errorf("%w: 母集団の指定では近似重複を作れません", ErrInvalidOptions)
+	}
+
+	// 生成中に再読み込みされても同じデータを使い続ける
+	s := g.current()
+	names := make([]string, len(p.Cohorts))
+	cohortOpts := make([]Options, len(p.Cohorts))
+	for i, c := range p.Cohorts {
+		names[i] = c.Name
+		if names[i] == "" {
+			names[i] = "cohort-" + strconv.Itoa(i+1)
+		}
+		if slices.Contains(names[:i], names[i]) {
+			return fmt.Errorf("%w: 集団の名前が重複しています: %q", ErrInvalidOptions, names[i])
+		}
+		if !(c.Weight > 0) || math.IsInf(c.Weight, 0) {
+			return fmt.Errorf("%w: 集団 %q の重みは正の有限の数で指定してください: %v", ErrInvalidOptions, names[i], c.Weight)
+		}
+		o, err := c.options(opts).normalize()
+		if err == nil {
+			o, err = s.constrain(o)
+		}
+		if err != nil {
+			return fmt.Errorf("集団 %q: %w", names[i], err)
+		}
+		cohortOpts[i] = o
+	}
+	// 個々の重みが有限でも、合計が桁あふれすると人数を割り振れない
+	if total := p.totalWeight(); math.IsInf(total, 0) || math.IsNaN(total) {
+		return fmt.Errorf("%w: 集団の重みの合計が大きすぎます", ErrInvalidOptions)
+	}
+
+	rnd := mathrand.New(mathrand.NewSource(seed))
+	logins := newUniqueLogins()
+
+	// 集団の並びは生成と同じ乱数で先に混ぜる
+	order := make([]int, 0, p.Size)
+	for i, n := range p.counts() {
+		for j := 0; j < n; j++ {
+			order = append(order, i)
+		}
+	}
+	rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+	for _, i := range order {
+		o := cohortOpts[i]
+		u := s.generateUser(o, rnd)
+		u.Cohort = names[i]
+		logins.claim(&u, o)
+		if o.dirty.Enabled() {
+			dirty.Apply(&u, o.dirty, userRand(u, "dirty"))
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
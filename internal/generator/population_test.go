@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePopulation(t *testing.T) {
+	g := &Generator{}
+	spec, err := ParsePopulation([]byte(`
+size: 101
+cohorts:
+  - {name: us-young, weight: 60, nat: US, minAge: 18, maxAge: 34}
+  - {name: jp, weight: 30, nat: JP, minAge: 35, maxAge: 60, state: texas, picture: none}
+  - {name: edge, weight: 10, profile: edge}
+`))
+	require.NoError(t, err)
+
+	users, err := g.GeneratePopulation(spec, 3, Options{})
+	require.NoError(t, err)
+	require.Len(t, users, 101)
+
+	counts := map[string]int{}
+	for _, u := range users {
+		counts[u.Cohort]++
+		switch u.Cohort {
+		case "us-young":
+			assert.Equal(t, "US", u.NAT)
+			assert.GreaterOrEqual(t, u.Dob.Age, 18)
+			assert.LessOrEqual(t, u.Dob.Age, 34)
+		case "jp":
+			assert.Equal(t, "JP", u.NAT)
+			assert.GreaterOrEqual(t, u.Dob.Age, 35)
+			assert.LessOrEqual(t, u.Dob.Age, 60)
+			assert.Equal(t, "Texas", u.Location.State)
+			assert.Empty(t, u.Picture.Large)
+		case "edge":
+			assert.NotEmpty(t, u.EdgeCases)
+		}
+	}
+	// 60.6, 30.3, 10.1 人の余りは us-young が最も大きい
+	assert.Equal(t, map[string]int{"us-young": 61, "jp": 30, "edge": 10}, counts)
+	// 集団は混ざって並ぶ
+	first := map[string]bool{}
+	for _, u := range users[:20] {
+		first[u.Cohort] = true
+	}
+	assert.Greater(t, len(first), 1)
+
+	again, err := g.GeneratePopulation(spec, 3, Options{})
+	require.NoError(t, err)
+	for i := range users {
+		assert.Equal(t, users[i].Login.UUID, again[i].Login.UUID)
+		assert.Equal(t, users[i].Cohort, again[i].Cohort)
+	}
+}
+
+func TestCohortAges(t *testing.T) {
+	o := Cohort{MaxAge: ptr(30)}.options(Options{})
+	assert.Equal(t, &AgeRange{Min: 18, Max: 30}, o.Age)
+	o = Cohort{MinAge: ptr(0), MaxAge: ptr(0)}.options(Options{})
+	assert.Equal(t, &AgeRange{Min: 0, Max: 0}, o.Age)
+	base := Options{Age: &AgeRange{Min: 20, Max: 60}}
+	o = Cohort{MinAge: ptr(40)}.options(base)
+	assert.Equal(t, &AgeRange{Min: 40, Max: 60}, o.Age)
+	// 共通の条件は変えない
+	assert.Equal(t, &AgeRange{Min: 20, Max: 60}, base.Age)
+	assert.Nil(t, Cohort{}.options(Options{}).Age)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestPopulationCounts(t *testing.T) {
+	p := Population{Size: 10, Cohorts: []Cohort{{Weight: 1}, {Weight: 1}, {Weight: 1}}}
+	assert.Equal(t, []int{4, 3, 3}, p.counts())
+
+	p = Population{Size: 7, Cohorts: []Cohort{{Weight: 0.6}, {Weight: 0.3}, {Weight: 0.1}}}
+	assert.Equal(t, []int{4, 2, 1}, p.counts())
+}
+
+func TestGeneratePopulationInvalid(t *testing.T) {
+	g := &Generator{}
+	for name, p := range map[string]Population{
+		"人数が 0":     {Size: 0, Cohorts: []Cohort{{Weight: 1}}},
+		"集団なし":      {Size: 1},
+		"重みが 0":     {Size: 1, Cohorts: []Cohort{{Weight: 0}}},
+		"重みが無限大":    {Size: 10, Cohorts: []Cohort{{Weight: math.Inf(1)}, {Weight: 1}}},
+		"重みが NaN":   {Size: 10, Cohorts: []Cohort{{Weight: math.NaN()}, {Weight: 1}}},
+		"重みの合計が無限大": {Size: 10, Cohorts: []Cohort{{Weight: math.MaxFloat64}, {Weight: math.MaxFloat64}}},
+		"名前の重複":     {Size: 1, Cohorts: []Cohort{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}},
+		"年齢の範囲":     {Size: 1, Cohorts: []Cohort{{Weight: 1, MinAge: ptr(60), MaxAge: ptr(30)}}},
+		"未対応の国籍":    {Size: 1, Cohorts: []Cohort{{Weight: 1, Nat: "XX"}}},
+		"未知の顔写真":    {Size: 1, Cohorts: []Cohort{{Weight: 1, Picture: "cartoon"}}},
+	} {
+		_, err := g.GeneratePopulation(p, 1, Options{})
+		assert.ErrorIs(t, err, ErrInvalidOptions, name)
+	}
+
+	_, err := ParsePopulation([]byte(`{"size": 1, "cohort": []}`))
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+
+	// YAML の .inf と .nan も生成前に弾く
+	for _, spec := range []string{"size: 10\ncohorts: [{weight: .inf}, {weight: 1}]", "size: 10\ncohorts: [{weight: .nan}]"} {
+		p, err := ParsePopulation([]byte(spec))
+		require.NoError(t, err)
+		_, err = g.GeneratePopulation(p, 1, Options{})
+		assert.ErrorIs(t, err, ErrInvalidOptions, spec)
+	}
+}
@@ -0,0 +1,338 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+)
+
+// portraitManifestFile はローカルおよびバケット上のマニフェストファイル名
+const portraitManifestFile = "portraits.json"
+
+// defaultPortraitLayout はマニフェストで layout が省略された場合のキー配置
+const defaultPortraitLayout = "{gender}/{band}/{nat}/portrait ({n}).png"
+
+// anyNat と anyBand は国籍・年齢を問わないプールを表すキー上の表記
+const (
+	anyNat  = "any"
+	anyBand = "all"
+)
+
+// ageBand は顔写真プールの年齢区分
+type ageBand struct {
+	Label string
+	Min   int
+	Max   int
+}
+
+// ageBands は年齢区分の一覧。Max が -1 の場合は上限なし
+var ageBands = []ageBand{
+	{Label: "0-12", Min: 0, Max: 12},
+	{Label: "13-17", Min: 13, Max: 17},
+	{Label: "18-29", Min: 18, Max: 29},
+	{Label: "30-44", Min: 30, Max: 44},
+	{Label: "45-59", Min: 45, Max: 59},
+	{Label: "60-74", Min: 60, Max: 74},
+	{Label: "75+", Min: 75, Max: -1},
+}
+
+// bandIndex は年齢に対応する年齢区分のインデックスを返す
+func bandIndex(age int) int {
+	for i, b := range ageBands {
+		if age >= b.Min && (b.Max < 0 || age <= b.Max) {
+			return i
+		}
+	}
+	return 0
+}
+
+// bandIndexByLabel はラベルから年齢区分のインデックスを返す
+func bandIndexByLabel(label string) (int, bool) {
+	for i, b := range ageBands {
+		if b.Label == label {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// PortraitManifest はバケット内の顔写真プールを記述するマニフェスト
+type PortraitManifest struct {
+	// Layout は {gender} {band} {nat} {n} を含むキーのテンプレート
+	Layout string         `json:"layout"`
+	Pools  []PortraitPool `json:"pools"`
+}
+
+// PortraitPool は性別・年齢区分・国籍ごとの顔写真の集合
+type PortraitPool struct {
+	Gender string `json:"gender"`
+	// Band は年齢区分のラベル。空の場合は年齢を問わない
+	Band string `json:"band,omitempty"`
+	// Nat は国籍コード。空の場合は国籍を問わない
+	Nat   string `json:"nat,omitempty"`
+	Count int    `json:"count,omitempty"`
+	// Keys を指定した場合は Layout と Count より優先される
+	Keys   []string `json:"keys,omitempty"`
+	Layout string   `json:"layout,omitempty"`
+}
+
+// portraitIndex は顔写真の選択に使うプールの索引
+type portraitIndex struct {
+	source string
+	pools  []portraitPool
+}
+
+type portraitPool struct {
+	gender string
+	band   int // -1 は年齢を問わない
+	nat    string
+	keys   []string
+}
+
+// ErrUnknownPortrait は顔写真の索引に無いキーを表す
+var ErrUnknownPortrait = errors.New("顔写真が見つかりません")
+
+// has は key が索引のいずれかのプールにあるかを返す
+func (idx *portraitIndex) has(key string) bool {
+	for _, p := range idx.pools {
+		if slices.Contains(p.keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyPortraitManifest はマニフェストが無い場合に使う従来の性別のみのプール
+func legacyPortraitManifest() *PortraitManifest {
+	return &PortraitManifest{
+		Layout: "{gender}/portrait ({n}).png",
+		Pools: []PortraitPool{
+			{Gender: "male", Count: 46},
+			{Gender: "female", Count: 24},
+		},
+	}
+}
+
+// newPortraitIndex はマニフェストを検証して索引を作成する
+func newPortraitIndex(m *PortraitManifest, source string) (*portraitIndex, error) {
+	layout := m.Layout
+	if layout == "" {
+		layout = defaultPortraitLayout
+	}
+
+	idx := &portraitIndex{source: source}
+	for i, p := range m.Pools {
+		if p.Gender != "male" && p.Gender != "female" {
+			return nil, fmt.Errorf("プール%dの性別が不正です: %q", i, p.Gender)
+		}
+		band := -1
+		if p.Band != "" {
+			b, ok := bandIndexByLabel(p.Band)
+			if !ok {
+				return nil, fmt.Errorf("プール%dの年齢区分が不正です: %q", i, p.Band)
+			}
+			band = b
+		}
+
+		keys := p.Keys
+		if len(keys) == 0 {
+			poolLayout := p.Layout
+			if poolLayout == "" {
+				poolLayout = layout
+			}
+			for n := 1; n <= p.Count; n++ {
+				keys = append(keys, expandPortraitLayout(poolLayout, p, n))
+			}
+		}
+		// 空のプールは選択肢にならないため登録しない
+		if len(keys) == 0 {
+			continue
+		}
+
+		idx.pools = append(idx.pools, portraitPool{
+			gender: p.Gender,
+			band:   band,
+			nat:    strings.ToUpper(p.Nat),
+			keys:   keys,
+		})
+	}
+	if len(idx.pools) == 0 {
+		return nil, fmt.Errorf("有効な顔写真プールがありません")
+	}
+	return idx, nil
+}
+
+// expandPortraitLayout はキーのテンプレートを展開する
+func expandPortraitLayout(layout string, p PortraitPool, n int) string {
+	nat := p.Nat
+	if nat == "" {
+		nat = anyNat
+	}
+	band := p.Band
+	if band == "" {
+		band = anyBand
+	}
+	return strings.NewReplacer(
+		"{gender}", p.Gender,
+		"{band}", band,
+		"{nat}", nat,
+		"{n}", strconv.Itoa(n),
+	).Replace(layout)
+}
+
+// pick は性別・年齢・国籍に最も近いプールから顔写真のキーを選ぶ。
+// 完全一致が無い場合は国籍不問、年齢不問、近い年齢区分の順に条件を緩める
+func (idx *portraitIndex) pick(gender string, age int, nat string, rnd *mathrand.Rand) string {
+	want := bandIndex(age)
+	nat = strings.ToUpper(nat)
+
+	best := -1
+	var candidates []portraitPool
+	for _, p := range idx.pools {
+		if p.gender != gender {
+			continue
+		}
+		score := portraitScore(p, want, nat)
+		if score < 0 {
+			continue
+		}
+		switch {
+		case best < 0 || score < best:
+			best = score
+			candidates = []portraitPool{p}
+		case score == best:
+			candidates = append(candidates, p)
+		}
+	}
+
+	// 国籍の異なるプールしか無い場合は性別のみで選ぶ
+	if len(candidates) == 0 {
+		for _, p := range idx.pools {
+			if p.gender == gender {
+				candidates = append(candidates, p)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	total := 0
+	for _, p := range candidates {
+		total += len(p.keys)
+	}
+	n := rnd.Intn(total)
+	for _, p := range candidates {
+		if n < len(p.keys) {
+			return p.keys[n]
+		}
+		n -= len(p.keys)
+	}
+	return ""
+}
+
+// portraitScore はプールの適合度を返す。小さいほど適合し、-1 は対象外
+func portraitScore(p portraitPool, band int, nat string) int {
+	natScore := 0
+	switch p.nat {
+	case nat:
+	case "":
+		natScore = 1
+	default:
+		return -1
+	}
+
+	switch p.band {
+	case band:
+		return natScore
+	case -1:
+		return 2 + natScore
+	}
+
+	distance := p.band - band
+	if distance < 0 {
+		distance = -distance
+	}
+	return 2 + 2*distance + natScore
+}
+
+// loadPortraitIndex はローカルまたはバケットのマニフェストから索引を作成する。
+// どちらも利用できない場合は従来の性別のみのプールを使う
+func loadPortraitIndex(dataDir, bucket string) *portraitIndex {
+	manifest, source, err := readPortraitManifest(dataDir, bucket)
+	if err != nil {
+		log.Printf("顔写真マニフェストの読み込みに失敗: %v", err)
+	}
+	if manifest != nil {
+		idx, err := newPortraitIndex(manifest, source)
+		if err == nil {
+			return idx
+		}
+		log.Printf("顔写真マニフェストが不正です(%s): %v", source, err)
+	}
+
+	idx, _ := newPortraitIndex(legacyPortraitManifest(), dataset.SourceBuiltin)
+	return idx
+}
+
+// readPortraitManifest はローカルのマニフェストを優先し、無ければバケットから読み込む
+func readPortraitManifest(dataDir, bucket string) (*PortraitManifest, string, error) {
+	localPath := filepath.Join(dataDir, portraitManifestFile)
+	if content, err := os.ReadFile(localPath); err == nil {
+		var m PortraitManifest
+		if err := json.Unmarshal(content, &m); err != nil {
+			return nil, "", fmt.Errorf("%s の解析に失敗: %v", localPath, err)
+		}
+		return &m, localPath, nil
+	}
+
+	initS3Client()
+	if s3Client == nil {
+		return nil, "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(portraitManifestFile),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("s3://%s/%s の取得に失敗: %v", bucket, portraitManifestFile, err)
+	}
+	defer out.Body.Close()
+
+	content, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	var m PortraitManifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, "", fmt.Errorf("s3://%s/%s の解析に失敗: %v", bucket, portraitManifestFile, err)
+	}
+	return &m, "s3://" + bucket + "/" + portraitManifestFile, nil
+}
+
+// bucketName は設定で指定が無い場合の顔写真のバケット名を返す
+func bucketName() string {
+	bucket := os.Getenv("BUCKET_NAME")
+	if bucket == "" {
+		bucket = "profile-generator"
+	}
+	return bucket
+}
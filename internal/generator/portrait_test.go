@@ -0,0 +1,59 @@
+package generator
+
+import (
+	mathrand "math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortraitIndexPick(t *testing.T) {
+	idx, err := newPortraitIndex(&PortraitManifest{
+		Pools: []PortraitPool{
+			{Gender: "male", Band: "18-29", Nat: "US", Count: 3},
+			{Gender: "male", Band: "75+", Count: 2},
+			{Gender: "male", Band: "45-59", Nat: "JP", Count: 2},
+			{Gender: "female", Band: "30-44", Nat: "JP", Count: 0},
+			{Gender: "female", Count: 4},
+		},
+	}, "test")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		gender     string
+		age        int
+		nat        string
+		wantPrefix string
+	}{
+		{name: "完全一致", gender: "male", age: 20, nat: "US", wantPrefix: "male/18-29/US/"},
+		{name: "国籍不問のプール", gender: "male", age: 80, nat: "US", wantPrefix: "male/75+/any/"},
+		{name: "近い年齢区分", gender: "male", age: 65, nat: "US", wantPrefix: "male/75+/any/"},
+		{name: "国籍一致を優先", gender: "male", age: 50, nat: "JP", wantPrefix: "male/45-59/JP/"},
+		{name: "空のプールは年齢不問に戻る", gender: "female", age: 35, nat: "JP", wantPrefix: "female/all/any/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rnd := mathrand.New(mathrand.NewSource(1))
+			for i := 0; i < 20; i++ {
+				key := idx.pick(tt.gender, tt.age, tt.nat, rnd)
+				assert.True(t, strings.HasPrefix(key, tt.wantPrefix), key)
+			}
+		})
+	}
+}
+
+func TestNewPortraitIndexInvalid(t *testing.T) {
+	_, err := newPortraitIndex(&PortraitManifest{
+		Pools: []PortraitPool{{Gender: "male", Band: "20-30", Count: 1}},
+	}, "test")
+	assert.Error(t, err)
+
+	_, err = newPortraitIndex(&PortraitManifest{
+		Pools: []PortraitPool{{Gender: "male", Count: 0}},
+	}, "test")
+	assert.Error(t, err)
+}
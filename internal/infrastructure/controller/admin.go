@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/reload"
+)
+
+// Reloader は設定とデータセットの再読み込みインターフェース
+type Reloader interface {
+	Reload(trigger string) (reload.Status, error)
+	Status() reload.Status
+}
+
+// RequireAdminToken は Authorization ヘッダーの Bearer トークンを検証する。
+// トークンが未設定の場合は管理用エンドポイントを無効にする
+func RequireAdminToken(c *gin.Context, token string) {
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理用トークンが設定されていません"})
+		return
+	}
+
+	given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "認証に失敗しました"})
+		return
+	}
+
+	c.Next()
+}
+
+// ReloadData は設定とデータセットを再読み込みする
+func ReloadData(c *gin.Context, r Reloader) {
+	status, err := r.Reload(reload.TriggerAPI)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "status": status})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ReloadStatus は直近の再読み込みの結果を返す
+func ReloadStatus(c *gin.Context, r Reloader) {
+	c.JSON(http.StatusOK, r.Status())
+}
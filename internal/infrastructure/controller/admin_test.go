@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/reload"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadData(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		authorization  string
+		setUpMock      func(*MockReloader)
+		expectedStatus int
+	}{
+		{
+			name:          "再読み込みに成功",
+			token:         "secret",
+			authorization: "Bearer secret",
+			setUpMock: func(m *MockReloader) {
+				m.EXPECT().Reload(reload.TriggerAPI).Return(reload.Status{Trigger: reload.TriggerAPI, Success: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:          "再読み込みに失敗",
+			token:         "secret",
+			authorization: "Bearer secret",
+			setUpMock: func(m *MockReloader) {
+				m.EXPECT().Reload(reload.TriggerAPI).Return(reload.Status{Trigger: reload.TriggerAPI, Error: assert.AnError.Error()}, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "トークンが不正",
+			token:          "secret",
+			authorization:  "Bearer wrong",
+			setUpMock:      func(m *MockReloader) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "トークンが未設定",
+			token:          "",
+			authorization:  "Bearer ",
+			setUpMock:      func(m *MockReloader) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockReloader := NewMockReloader(t)
+			tt.setUpMock(mockReloader)
+
+			admin := r.Group("/admin")
+			admin.Use(func(c *gin.Context) {
+				RequireAdminToken(c, tt.token)
+			})
+			admin.POST("/reload", func(c *gin.Context) {
+				ReloadData(c, mockReloader)
+			})
+
+			req, _ := http.NewRequest("POST", "/admin/reload", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var status reload.Status
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+				assert.True(t, status.Success)
+			}
+		})
+	}
+}
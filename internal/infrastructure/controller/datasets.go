@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+)
+
+type datasetsResponse struct {
+	Datasets []dataset.Info `json:"datasets"`
+}
+
+// DatasetSource は使用中のデータセットを報告するインターフェース
+type DatasetSource interface {
+	Datasets() []dataset.Info
+}
+
+// ListDatasets は使用中のデータセットのサイズと出所を返す
+func ListDatasets(c *gin.Context, src DatasetSource) {
+	c.JSON(http.StatusOK, datasetsResponse{Datasets: src.Datasets()})
+}
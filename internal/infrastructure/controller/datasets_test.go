@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListDatasets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	_, r := gin.CreateTestContext(w)
+
+	mockSrc := NewMockDatasetSource(t)
+	mockSrc.EXPECT().Datasets().Return([]dataset.Info{
+		{Name: "last_names", Source: "internal/data/last.txt", Size: 306, Weighted: true},
+		{Name: "streets", Source: dataset.SourceBuiltin, Size: 5},
+	})
+
+	r.GET("/api/datasets", func(c *gin.Context) {
+		ListDatasets(c, mockSrc)
+	})
+
+	req, _ := http.NewRequest("GET", "/api/datasets", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"datasets":[{"name":"last_names","source":"internal/data/last.txt","size":306,"weighted":true},{"name":"streets","source":"builtin","size":5,"weighted":false}]}`, w.Body.String())
+}
@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// SandboxDirectory は検索と初期化もできるユーザーのディレクトリのインターフェース
+type SandboxDirectory interface {
+	UserDirectory
+	Search(q directory.Query) ([]directory.Entry, int, error)
+	Reset()
+	Regenerate(size int, seed int64) error
+	Size() int
+	Seed() int64
+}
+
+// maxDirectorySize は初期化で指定できる母集団の最大の人数
+const maxDirectorySize = 100000
+
+// defaultDirectoryResults は一覧の1ページの既定の件数
+const defaultDirectoryResults = 20
+
+type directoryResponse struct {
+	Results []directory.Entry `json:"results"`
+	Info    directoryInfo     `json:"info"`
+}
+
+type directoryInfo struct {
+	Seed    string `json:"seed"`
+	Size    int    `json:"size"`
+	Total   int    `json:"total"`
+	Results int    `json:"results"`
+	Page    int    `json:"page"`
+}
+
+// directoryEntryRequest はユーザーの追加・置き換えのリクエスト
+type directoryEntryRequest struct {
+	ExternalID string     `json:"externalId"`
+	Active     *bool      `json:"active"`
+	User       model.User `json:"user"`
+}
+
+func (r directoryEntryRequest) apply(e *directory.Entry) {
+	e.ExternalID = r.ExternalID
+	e.Active = r.Active == nil || *r.Active
+	e.User = r.User
+}
+
+// ListDirectory はディレクトリのユーザーを検索し、並べ替えてページ分けして返す
+func ListDirectory(c *gin.Context, dir SandboxDirectory, cfg *config.Config) {
+	page := queryInt(c, "page", 1)
+	if page < 1 {
+		page = 1
+	}
+	results := queryInt(c, "results", defaultDirectoryResults)
+	if results < 1 || results > cfg.MaxResults {
+		results = defaultDirectoryResults
+	}
+
+	entries, total, err := dir.Search(directory.Query{
+		Q:      c.Query("q"),
+		Name:   c.Query("name"),
+		Email:  c.Query("email"),
+		City:   c.Query("city"),
+		Sort:   c.Query("sort"),
+		Desc:   c.Query("order") == "desc",
+		Offset: (page - 1) * results,
+		Limit:  results,
+	})
+	if err != nil {
+		directoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, directoryResponse{
+		Results: entries,
+		Info: directoryInfo{
+			Seed:    strconv.FormatInt(dir.Seed(), 10),
+			Size:    dir.Size(),
+			Total:   total,
+			Results: len(entries),
+			Page:    page,
+		},
+	})
+}
+
+// GetDirectoryUser は ID のユーザーを返す
+func GetDirectoryUser(c *gin.Context, dir SandboxDirectory) {
+	e, err := dir.Get(c.Param("id"))
+	if err != nil {
+		directoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// CreateDirectoryUser はユーザーを追加する。active を省略した場合は有効にする
+func CreateDirectoryUser(c *gin.Context, dir SandboxDirectory) {
+	var req directoryEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var e directory.Entry
+	req.apply(&e)
+
+	created, err := dir.Create(e)
+	if err != nil {
+		directoryError(c, err)
+		return
+	}
+	c.Header("Location", c.Request.URL.Path+"/"+created.ID)
+	c.JSON(http.StatusCreated, created)
+}
+
+// ReplaceDirectoryUser はユーザーをリクエストの内容で置き換える
+func ReplaceDirectoryUser(c *gin.Context, dir SandboxDirectory) {
+	var req directoryEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := dir.Update(c.Param("id"), func(e *directory.Entry) error {
+		req.apply(e)
+		return nil
+	})
+	if err != nil {
+		directoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// PatchDirectoryUser はユーザーに JSON Merge Patch (RFC 7396) を適用する
+func PatchDirectoryUser(c *gin.Context, dir SandboxDirectory) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var patch map[string]any
+	if err := json.Unmarshal(body, &patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "本文は JSON のオブジェクトにしてください"})
+		return
+	}
+
+	updated, err := dir.Update(c.Param("id"), func(e *directory.Entry) error {
+		return mergePatch(e, patch)
+	})
+	if err != nil {
+		directoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteDirectoryUser はユーザーを削除する
+func DeleteDirectoryUser(c *gin.Context, dir SandboxDirectory) {
+	if err := dir.Delete(c.Param("id")); err != nil {
+		directoryError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ResetDirectory は変更をすべて破棄する。size か seed を指定した場合は母集団を生成し直す
+func ResetDirectory(c *gin.Context, dir SandboxDirectory) {
+	var req struct {
+		Size *int   `json:"size"`
+		Seed *int64 `json:"seed"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.Size == nil && req.Seed == nil {
+		dir.Reset()
+	} else {
+		size, seed := dir.Size(), dir.Seed()
+		if req.Size != nil {
+			size = *req.Size
+		}
+		if req.Seed != nil {
+			seed = *req.Seed
+		}
+		if size < 1 || size > maxDirectorySize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("size は 1 から %d の範囲で指定してください", maxDirectorySize)})
+			return
+		}
+		if err := dir.Regenerate(size, seed); err != nil {
+			directoryError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seed": strconv.FormatInt(dir.Seed(), 10), "size": dir.Size()})
+}
+
+// mergePatch はユーザーを JSON にして patch を重ね、元の形に戻す
+func mergePatch(e *directory.Entry, patch map[string]any) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	merge(doc, patch)
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var patched directory.Entry
+	if err := json.Unmarshal(b, &patched); err != nil {
+		return &patchError{err}
+	}
+	*e = patched
+	return nil
+}
+
+// merge は RFC 7396 に従って patch を doc に重ねる。null の項目は削除する
+func merge(doc, patch map[string]any) {
+	for k, v := range patch {
+		if v == nil {
+			delete(doc, k)
+			continue
+		}
+		if p, ok := v.(map[string]any); ok {
+			if d, ok := doc[k].(map[string]any); ok {
+				merge(d, p)
+				continue
+			}
+		}
+		doc[k] = v
+	}
+}
+
+// patchError はパッチを適用した結果がユーザーとして不正なことを表す
+type patchError struct {
+	err error
+}
+
+func (e *patchError) Error() string {
+	return "パッチを適用できません: " + e.err.Error()
+}
+
+// directoryError はディレクトリのエラーを HTTP のステータスにする
+func directoryError(c *gin.Context, err error) {
+	var pe *patchError
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, directory.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, directory.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, directory.ErrInvalidQuery), errors.As(err, &pe):
+		status = http.StatusBadRequest
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDirectory(t *testing.T) {
+	cfg := &config.Config{MaxResults: 50}
+	alice := directory.Entry{
+		ID:     "a1",
+		Active: true,
+		User: model.User{
+			Name:  model.Name{First: "Alice", Last: "Smith"},
+			Email: "alice@example.com",
+			Login: model.Login{Username: "alice"},
+		},
+		Meta: directory.Meta{Version: 1},
+	}
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		body             string
+		setUpMock        func(*MockSandboxDirectory)
+		expectedStatus   int
+		expectedBody     []string
+		expectedLocation string
+	}{
+		{
+			name:   "検索して並べ替え",
+			method: "GET",
+			path:   "/api/directory?q=smith&city=Austin&sort=name&order=desc&page=3&results=10",
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Search(directory.Query{Q: "smith", City: "Austin", Sort: "name", Desc: true, Offset: 20, Limit: 10}).Return([]directory.Entry{alice}, 21, nil)
+				m.EXPECT().Seed().Return(int64(42))
+				m.EXPECT().Size().Return(1000)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"info":{"seed":"42","size":1000,"total":21,"results":1,"page":3}`, `"id":"a1"`},
+		},
+		{
+			name:   "不正な並べ替えの項目",
+			method: "GET",
+			path:   "/api/directory?sort=age",
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Search(mock.Anything).Return(nil, 0, directory.ErrInvalidQuery)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "存在しないユーザー",
+			method: "GET",
+			path:   "/api/directory/missing",
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Get("missing").Return(directory.Entry{}, directory.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "ユーザーを追加",
+			method: "POST",
+			path:   "/api/directory",
+			body:   `{"user":{"name":{"first":"Bob"},"login":{"username":"bob"}}}`,
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Create(mock.MatchedBy(func(e directory.Entry) bool {
+					return e.Active && e.User.Login.Username == "bob"
+				})).RunAndReturn(func(e directory.Entry) (directory.Entry, error) {
+					e.ID = "b2"
+					return e, nil
+				})
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     []string{`"id":"b2"`},
+			expectedLocation: "/api/directory/b2",
+		},
+		{
+			name:   "ユーザー名が重複",
+			method: "POST",
+			path:   "/api/directory",
+			body:   `{"user":{"login":{"username":"alice"}}}`,
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Create(mock.Anything).Return(directory.Entry{}, directory.ErrConflict)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "部分的に更新",
+			method: "PATCH",
+			path:   "/api/directory/a1",
+			body:   `{"active":false,"user":{"email":"alice@example.org","name":{"title":"Dr"}}}`,
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Update("a1", mock.Anything).RunAndReturn(func(id string, fn func(*directory.Entry) error) (directory.Entry, error) {
+					e := alice
+					err := fn(&e)
+					return e, err
+				})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"active":false`, `"email":"alice@example.org"`, `"name":{"title":"Dr","first":"Alice","last":"Smith"}`},
+		},
+		{
+			name:   "型が合わないパッチ",
+			method: "PATCH",
+			path:   "/api/directory/a1",
+			body:   `{"user":{"dob":"1990-01-01"}}`,
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Update("a1", mock.Anything).RunAndReturn(func(id string, fn func(*directory.Entry) error) (directory.Entry, error) {
+					e := alice
+					return directory.Entry{}, fn(&e)
+				})
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "ユーザーを削除",
+			method: "DELETE",
+			path:   "/api/directory/a1",
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Delete("a1").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "変更を破棄",
+			method: "POST",
+			path:   "/api/directory/reset",
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Reset().Return()
+				m.EXPECT().Seed().Return(int64(42))
+				m.EXPECT().Size().Return(1000)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"seed":"42"`},
+		},
+		{
+			name:   "母集団を生成し直す",
+			method: "POST",
+			path:   "/api/directory/reset",
+			body:   `{"seed":7}`,
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Size().Return(1000)
+				m.EXPECT().Seed().Return(int64(42)).Once()
+				m.EXPECT().Regenerate(1000, int64(7)).Return(nil)
+				m.EXPECT().Seed().Return(int64(7)).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"seed":"7"`, `"size":1000`},
+		},
+		{
+			name:   "母集団の人数が範囲外",
+			method: "POST",
+			path:   "/api/directory/reset",
+			body:   `{"size":0}`,
+			setUpMock: func(m *MockSandboxDirectory) {
+				m.EXPECT().Size().Return(1000)
+				m.EXPECT().Seed().Return(int64(42))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockDirectory := NewMockSandboxDirectory(t)
+			tt.setUpMock(mockDirectory)
+
+			api := r.Group("/api")
+			api.GET("/directory", func(c *gin.Context) { ListDirectory(c, mockDirectory, cfg) })
+			api.POST("/directory", func(c *gin.Context) { CreateDirectoryUser(c, mockDirectory) })
+			api.POST("/directory/reset", func(c *gin.Context) { ResetDirectory(c, mockDirectory) })
+			api.GET("/directory/:id", func(c *gin.Context) { GetDirectoryUser(c, mockDirectory) })
+			api.PATCH("/directory/:id", func(c *gin.Context) { PatchDirectoryUser(c, mockDirectory) })
+			api.DELETE("/directory/:id", func(c *gin.Context) { DeleteDirectoryUser(c, mockDirectory) })
+
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, s := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), s)
+			}
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			}
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/dirty"
+	"github.com/ryuhei/randomuser-go/internal/export"
+	"github.com/ryuhei/randomuser-go/internal/generator"
 	"github.com/ryuhei/randomuser-go/internal/model"
 )
 
@@ -16,6 +22,12 @@ type userResponse struct {
 	Info    info         `json:"info"`
 }
 
+// manifestResponse は dirty で加えた破損の一覧
+type manifestResponse struct {
+	Manifest []dirty.Entry `json:"manifest"`
+	Info     info          `json:"info"`
+}
+
 type info struct {
 	Seed    string `json:"seed"`
 	Results int    `json:"results"`
@@ -24,10 +36,160 @@ type info struct {
 
 // UserGenerator はユーザー生成インターフェース
 type UserGenerator interface {
-	Generate(results int, seed int64, page int, gender string) ([]model.User, error)
+	Generate(results int, seed int64, page int, opts generator.Options) ([]model.User, error)
+	Stream(count int, seed int64, opts generator.Options, fn func(model.User) error) error
 }
 
 func GenerateUser(c *gin.Context, gen UserGenerator, cfg *config.Config) {
+	seed, page := seedAndPage(c)
+
+	resultsStr := c.DefaultQuery("results", "1")
+	results, err := strconv.Atoi(resultsStr)
+	if err != nil || results < 1 || results > cfg.MaxResults {
+		results = 1
+	}
+
+	opts, err := queryOptions(c, cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if dup := c.Query("duplicates"); dup != "" {
+		if opts.Duplicates, err = strconv.ParseFloat(dup, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duplicates は 0 から 1 の数値で指定してください"})
+			return
+		}
+		opts.Variants = queryInt(c, "variants", 0)
+	}
+
+	// manifest=true の場合は、同じ条件で生成したユーザーに加えた破損の一覧を返す
+	manifest, _ := strconv.ParseBool(c.Query("manifest"))
+
+	// json 以外の出力形式はエンコーダーで書き出す。不正な指定は生成前に弾く
+	var enc export.Encoder
+	var format string
+	if !manifest {
+		if enc, format, err = queryEncoder(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// 書き出し形式では生成したユーザーをそのままエンコーダーに渡し、全員分を保持しない。
+	// ファイルは後から開かれるため、顔写真は期限の切れない転送用の URL にする
+	if enc != nil {
+		opts.PortraitBaseURL = cfg.PortraitBaseURL
+		writeExport(c, enc, format, func(fn func(model.User) error) error {
+			return gen.Stream(results, seed, opts, fn)
+		})
+		return
+	}
+
+	output, err := gen.Generate(results, seed, page, opts)
+	if errors.Is(err, generator.ErrInvalidOptions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resInfo := info{
+		Seed:    strconv.FormatInt(seed, 10),
+		Results: results,
+		Page:    page,
+	}
+	if manifest {
+		entries := []dirty.Entry{}
+		for i, u := range output {
+			entries = append(entries, dirty.Manifest(i, u)...)
+		}
+		c.JSON(http.StatusOK, manifestResponse{Manifest: entries, Info: resInfo})
+		return
+	}
+
+	res := userResponse{
+		Results: output,
+		Info:    resInfo,
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.JSON(http.StatusOK, res)
+}
+
+// queryOptions はユーザー生成の共通の条件をクエリから読む
+func queryOptions(c *gin.Context, cfg *config.Config) (generator.Options, error) {
+	opts := generator.Options{
+		Gender:   c.DefaultQuery("gender", ""),
+		Nat:      c.DefaultQuery("nat", ""),
+		IPRanges: cfg.Internet.IPRanges,
+
+		EmailPatterns: queryList(c, "emailPatterns", cfg.Email.Patterns),
+		EmailDomains:  queryList(c, "emailDomains", cfg.Email.Domains),
+
+		Dirty:   c.Query("dirty"),
+		Profile: c.Query("profile"),
+
+		State:              c.Query("state"),
+		City:               c.Query("city"),
+		LastNameStartsWith: c.Query("lastNameStartsWith"),
+	}
+	if inc := c.Query("inc"); inc != "" {
+		opts.Include = strings.Split(inc, ",")
+	}
+	// 年齢は片方だけ指定した場合、もう片方を既定の 18 歳または 97 歳にする
+	if c.Query("minAge") != "" || c.Query("maxAge") != "" {
+		var age generator.AgeRange
+		var err error
+		if age.Min, err = queryAge(c, "minAge", 18); err != nil {
+			return opts, err
+		}
+		if age.Max, err = queryAge(c, "maxAge", 97); err != nil {
+			return opts, err
+		}
+		opts.Age = &age
+	}
+	if asOf := c.Query("asOf"); asOf != "" {
+		var err error
+		if opts.AsOf, err = time.Parse(time.DateOnly, asOf); err != nil {
+			return opts, errors.New("asOf は YYYY-MM-DD の形式で指定してください")
+		}
+	}
+	return opts, nil
+}
+
+// queryAge は年齢のクエリを読む。クエリが無い場合は def を返す
+func queryAge(c *gin.Context, key string, def int) (int, error) {
+	v := c.Query(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s は整数で指定してください: %q", key, v)
+	}
+	return n, nil
+}
+
+// queryEncoder は format のクエリに合ったエンコーダーを作る。json の場合は nil を返す
+func queryEncoder(c *gin.Context) (export.Encoder, string, error) {
+	format := c.DefaultQuery("format", export.FormatJSON)
+	if format == export.FormatJSON {
+		return nil, format, nil
+	}
+	enc, err := export.NewEncoder(c.Writer, format, export.Options{
+		Table:   c.DefaultQuery("table", ""),
+		Dialect: c.DefaultQuery("dialect", ""),
+		Layout:  c.DefaultQuery("layout", ""),
+		Index:   c.DefaultQuery("index", ""),
+		BaseDN:  c.DefaultQuery("basedn", ""),
+	})
+	return enc, format, err
+}
+
+// seedAndPage は seed と page のクエリを読む。seed が無い場合は現在時刻を使い、ページごとにずらす
+func seedAndPage(c *gin.Context) (int64, int) {
 	seed := time.Now().UnixNano()
 	if seedParam := c.DefaultQuery("seed", ""); seedParam != "" {
 		seedInt, err := strconv.ParseInt(seedParam, 10, 64)
@@ -43,31 +205,51 @@ func GenerateUser(c *gin.Context, gen UserGenerator, cfg *config.Config) {
 			page = pagenum
 		}
 	}
-	seed += int64(page)
+	return seed + int64(page), page
+}
 
-	resultsStr := c.DefaultQuery("results", "1")
-	results, err := strconv.Atoi(resultsStr)
-	if err != nil || results < 1 || results > cfg.MaxResults {
-		results = 1
+// queryList はカンマ区切りのクエリを読む。クエリが無い場合は def を返す
+func queryList(c *gin.Context, key string, def []string) []string {
+	if v := c.Query(key); v != "" {
+		return strings.Split(v, ",")
 	}
+	return def
+}
 
-	gender := c.DefaultQuery("gender", "")
+// writeExport は stream が生成するユーザーを1人ずつエンコーダーで書き出す。
+// ヘッダーは最初のユーザーを書き出す時に送るため、生成を始める前に分かる条件のエラーは JSON で返す。
+// ヘッダー送信後のエラーはステータスを変えられないため、gin のエラーとして記録する
+func writeExport(c *gin.Context, enc export.Encoder, format string, stream func(fn func(model.User) error) error) {
+	started := false
+	start := func() {
+		c.Header("Content-Type", export.ContentType(format))
+		c.Header("Content-Disposition", `attachment; filename="users`+export.Extension(format)+`"`)
+		c.Status(http.StatusOK)
+		started = true
+	}
 
-	output, err := gen.Generate(results, seed, page, gender)
-	if err != nil {
+	err := stream(func(u model.User) error {
+		if !started {
+			start()
+		}
+		return enc.Encode(u)
+	})
+	switch {
+	case err != nil && !started && errors.Is(err, generator.ErrInvalidOptions):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil && !started:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
+	case err != nil:
+		_ = c.Error(err)
+		return
 	}
 
-	res := userResponse{
-		Results: output,
-		Info: info{
-			Seed:    strconv.FormatInt(seed, 10),
-			Results: results,
-			Page:    page,
-		},
+	if !started {
+		start()
+	}
+	if err := enc.Close(); err != nil {
+		_ = c.Error(err)
 	}
-
-	c.Header("Content-Type", "application/json; charset=utf-8")
-	c.JSON(http.StatusOK, res)
 }
@@ -2,12 +2,15 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
 	"github.com/ryuhei/randomuser-go/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -31,12 +34,12 @@ func TestGenerateUser(t *testing.T) {
 		{
 			name:           "正常なリクエスト",
 			queryParams:    map[string]string{"results": "2", "gender": "male", "seed": "12345", "page": "2"},
-			mockReturnJSON: `{"results":[{"gender":"male","name":{"title":"","first":"Test","last":"User"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":""},{"gender":"male","name":{"title":"","first":"Test2","last":"User2"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":""}],"info":{"seed":"12345","results":2,"page": 2}}`,
+			mockReturnJSON: `{"results":[{"gender":"male","name":{"title":"","first":"Test","last":"User"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":"","internet":{"ipv4":"","ipv6":"","mac":"","device":"","os":"","browser":"","userAgent":"","website":"","social":{"x":"","instagram":"","github":"","linkedin":""}}},{"gender":"male","name":{"title":"","first":"Test2","last":"User2"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":"","internet":{"ipv4":"","ipv6":"","mac":"","device":"","os":"","browser":"","userAgent":"","website":"","social":{"x":"","instagram":"","github":"","linkedin":""}}}],"info":{"seed":"12345","results":2,"page": 2}}`,
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"results":[{"gender":"male","name":{"title":"","first":"Test","last":"User"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":""},{"gender":"male","name":{"title":"","first":"Test2","last":"User2"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":""}],"info":{"seed":"12347","results":2,"page":2}}`,
+			expectedBody:   `{"results":[{"gender":"male","name":{"title":"","first":"Test","last":"User"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","phoneE164":"","cellE164":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":"","internet":{"ipv4":"","ipv6":"","mac":"","device":"","os":"","browser":"","userAgent":"","website":"","social":{"x":"","instagram":"","github":"","linkedin":""}}},{"gender":"male","name":{"title":"","first":"Test2","last":"User2"},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","phoneE164":"","cellE164":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":"","internet":{"ipv4":"","ipv6":"","mac":"","device":"","os":"","browser":"","userAgent":"","website":"","social":{"x":"","instagram":"","github":"","linkedin":""}}}],"info":{"seed":"12347","results":2,"page":2}}`,
 			setUpMock: func(m *MockUserGenerator) {
-				m.EXPECT().Generate(2, mock.AnythingOfType("int64"), 2, "male").Return(
+				m.EXPECT().Generate(2, mock.AnythingOfType("int64"), 2, generator.Options{Gender: "male"}).Return(
 					[]model.User{
 						{
 							Gender: "male",
@@ -57,6 +60,42 @@ func TestGenerateUser(t *testing.T) {
 				)
 			},
 		},
+		{
+			name:           "追加の項目と基準日",
+			queryParams:    map[string]string{"inc": "finance", "asOf": "2030-01-15", "nat": "GB", "seed": "1"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"results":[{"gender":"","name":{"title":"","first":"","last":""},"location":{"street":{"number":0,"name":""},"city":"","state":"","country":"","postcode":"","coordinates":{"latitude":"","longitude":""}},"email":"","login":{"uuid":"","username":"","password":"","salt":"","md5":"","sha1":"","sha256":""},"dob":{"date":"","age":0},"registered":{"date":"","age":0},"phone":"","cell":"","phoneE164":"","cellE164":"","id":{"name":"","value":""},"picture":{"large":"","medium":"","thumbnail":""},"nat":"GB","internet":{"ipv4":"","ipv6":"","mac":"","device":"","os":"","browser":"","userAgent":"","website":"","social":{"x":"","instagram":"","github":"","linkedin":""}},"finance":{"card":{"scheme":"visa","number":"4242424242424242","expiry":"02/30","cvv":"123"},"iban":"GB82WEST12345698765432"}}],"info":{"seed":"2","results":1,"page":1}}`,
+			setUpMock: func(m *MockUserGenerator) {
+				opts := generator.Options{Nat: "GB", Include: []string{"finance"}, AsOf: time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)}
+				m.EXPECT().Generate(1, int64(2), 1, opts).Return(
+					[]model.User{
+						{
+							NAT: "GB",
+							Finance: &model.Finance{
+								Card: model.Card{Scheme: "visa", Number: "4242424242424242", Expiry: "02/30", CVV: "123"},
+								IBAN: "GB82WEST12345698765432",
+							},
+						},
+					},
+					nil,
+				)
+			},
+		},
+		{
+			name:           "破損のマニフェスト",
+			queryParams:    map[string]string{"dirty": "0.5", "manifest": "true", "results": "2", "seed": "1"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"manifest":[{"index":1,"uuid":"u2","field":"email","kind":"missing","original":"a@example.com"}],"info":{"seed":"2","results":2,"page":1}}`,
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Generate(2, int64(2), 1, generator.Options{Dirty: "0.5"}).Return(
+					[]model.User{
+						{Login: model.Login{UUID: "u1"}},
+						{Login: model.Login{UUID: "u2"}, Corruptions: []model.Corruption{{Field: "email", Kind: "missing", Original: "a@example.com"}}},
+					},
+					nil,
+				)
+			},
+		},
 		{
 			name:           "ジェネレーターエラー",
 			queryParams:    map[string]string{"results": "1"},
@@ -65,7 +104,7 @@ func TestGenerateUser(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"error":"assert.AnError general error for testing"}`,
 			setUpMock: func(m *MockUserGenerator) {
-				m.EXPECT().Generate(1, mock.AnythingOfType("int64"), 1, "").Return(
+				m.EXPECT().Generate(1, mock.AnythingOfType("int64"), 1, generator.Options{}).Return(
 					[]model.User{
 						{
 							Gender: "male",
@@ -79,6 +118,33 @@ func TestGenerateUser(t *testing.T) {
 				)
 			},
 		},
+		{
+			name:           "満たせない属性の条件",
+			queryParams:    map[string]string{"minAge": "30", "state": "Texas", "city": "Atlantis", "lastNameStartsWith": "S"},
+			mockError:      assert.AnError,
+			expectedStatus: http.StatusBadRequest,
+			setUpMock: func(m *MockUserGenerator) {
+				opts := generator.Options{Age: &generator.AgeRange{Min: 30, Max: 97}, State: "Texas", City: "Atlantis", LastNameStartsWith: "S"}
+				m.EXPECT().Generate(1, mock.AnythingOfType("int64"), 1, opts).Return(
+					nil,
+					fmt.Errorf("%w: %w", generator.ErrInvalidOptions, assert.AnError),
+				)
+			},
+		},
+		{
+			name:           "不正な生成条件",
+			queryParams:    map[string]string{"nat": "XX"},
+			mockReturnJSON: "",
+			mockError:      assert.AnError,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "",
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Generate(1, mock.AnythingOfType("int64"), 1, generator.Options{Nat: "XX"}).Return(
+					nil,
+					fmt.Errorf("%w: %w", generator.ErrInvalidOptions, assert.AnError),
+				)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,3 +183,163 @@ func TestGenerateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateUserExport(t *testing.T) {
+	cfg := &config.Config{MaxResults: 50}
+
+	tests := []struct {
+		name                string
+		query               string
+		setUpMock           func(*MockUserGenerator)
+		expectedStatus      int
+		expectedContentType string
+		expectedContains    []string
+	}{
+		{
+			name:  "SQL (MySQL)",
+			query: "format=sql&dialect=mysql&layout=flat&results=2",
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Stream(2, mock.AnythingOfType("int64"), generator.Options{}, mock.Anything).RunAndReturn(streamUsers(
+					[]model.User{
+						{Gender: "male", Name: model.Name{First: "Test", Last: "O'Brien"}},
+						{Gender: "female", Name: model.Name{First: "Test2", Last: "User2"}},
+					},
+				))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/sql; charset=utf-8",
+			expectedContains:    []string{"CREATE TABLE IF NOT EXISTS `users`", "'O''Brien'", "COMMIT;"},
+		},
+		{
+			name:  "CSV",
+			query: "format=csv",
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Stream(1, mock.AnythingOfType("int64"), generator.Options{}, mock.Anything).RunAndReturn(streamUsers(
+					[]model.User{{Gender: "male", Name: model.Name{First: "Test", Last: "User"}}},
+				))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedContains:    []string{"gender,name.title,name.first,name.last", "male,,Test,User"},
+		},
+		{
+			name:  "Elasticsearch _bulk",
+			query: "format=elasticsearch&index=people",
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Stream(1, mock.AnythingOfType("int64"), generator.Options{}, mock.Anything).RunAndReturn(streamUsers(
+					[]model.User{{Gender: "male", Login: model.Login{UUID: "1a8b9525-e20f-4a68-927f-2b2ff836f735"}}},
+				))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson; charset=utf-8",
+			expectedContains:    []string{`{"index":{"_id":"1a8b9525-e20f-4a68-927f-2b2ff836f735","_index":"people"}}`},
+		},
+		{
+			name:  "LDIF",
+			query: "format=ldif&basedn=ou%3Dstaff%2Cdc%3Dexample%2Cdc%3Dorg",
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Stream(1, mock.AnythingOfType("int64"), generator.Options{}, mock.Anything).RunAndReturn(streamUsers(
+					[]model.User{{Name: model.Name{First: "Test", Last: "User"}, Login: model.Login{Username: "testuser"}}},
+				))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/x-ldif; charset=utf-8",
+			expectedContains:    []string{"dn: uid=testuser,ou=staff,dc=example,dc=org\n", "cn: Test User\n"},
+		},
+		{
+			name:  "Parquet",
+			query: "format=parquet",
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Stream(1, mock.AnythingOfType("int64"), generator.Options{}, mock.Anything).RunAndReturn(streamUsers(
+					[]model.User{{Gender: "male", Name: model.Name{First: "Test", Last: "User"}}},
+				))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/vnd.apache.parquet",
+			expectedContains:    []string{"PAR1"},
+		},
+		{
+			name:  "書き出し前に分かる条件のエラー",
+			query: "format=csv&state=Atlantis",
+			setUpMock: func(m *MockUserGenerator) {
+				m.EXPECT().Stream(1, mock.AnythingOfType("int64"), generator.Options{State: "Atlantis"}, mock.Anything).Return(generator.ErrInvalidOptions)
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedContains: []string{`"error"`},
+		},
+		{
+			name:             "不正な方言",
+			query:            "format=sql&dialect=oracle",
+			setUpMock:        func(m *MockUserGenerator) {},
+			expectedStatus:   http.StatusBadRequest,
+			expectedContains: []string{"oracle"},
+		},
+		{
+			name:             "不正な出力形式",
+			query:            "format=xml",
+			setUpMock:        func(m *MockUserGenerator) {},
+			expectedStatus:   http.StatusBadRequest,
+			expectedContains: []string{"xml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockGen := NewMockUserGenerator(t)
+			tt.setUpMock(mockGen)
+
+			r.GET("/api", func(c *gin.Context) {
+				GenerateUser(c, mockGen, cfg)
+			})
+
+			req, _ := http.NewRequest("GET", "/api?"+tt.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			}
+			for _, s := range tt.expectedContains {
+				assert.Contains(t, w.Body.String(), s)
+			}
+		})
+	}
+}
+
+func TestQueryOptionsAge(t *testing.T) {
+	cfg := &config.Config{}
+	tests := []struct {
+		query    string
+		expected *generator.AgeRange
+	}{
+		{"", nil},
+		{"minAge=0&maxAge=0", &generator.AgeRange{Min: 0, Max: 0}},
+		{"minAge=30", &generator.AgeRange{Min: 30, Max: 97}},
+		{"maxAge=45", &generator.AgeRange{Min: 18, Max: 45}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest("GET", "/api?"+tt.query, nil)
+			opts, err := queryOptions(c, cfg)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, opts.Age)
+		})
+	}
+}
+
+// streamUsers は users を順に fn に渡す Stream の代わり
+func streamUsers(users []model.User) func(int, int64, generator.Options, func(model.User) error) error {
+	return func(_ int, _ int64, _ generator.Options, fn func(model.User) error) error {
+		for _, u := range users {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
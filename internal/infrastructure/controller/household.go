@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+type householdResponse struct {
+	Results []model.Household `json:"results"`
+	Info    info              `json:"info"`
+}
+
+// HouseholdGenerator は世帯生成インターフェース
+type HouseholdGenerator interface {
+	GenerateHouseholds(count int, seed int64, opts generator.Options) ([]model.Household, error)
+}
+
+// GenerateHouseholds は姓・住所・固定電話を共有する世帯を生成する
+func GenerateHouseholds(c *gin.Context, gen HouseholdGenerator, cfg *config.Config) {
+	seed, page := seedAndPage(c)
+
+	results, err := strconv.Atoi(c.DefaultQuery("results", "1"))
+	if err != nil || results < 1 || results > cfg.MaxResults {
+		results = 1
+	}
+
+	opts, err := queryOptions(c, cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	households, err := gen.GenerateHouseholds(results, seed, opts)
+	if errors.Is(err, generator.ErrInvalidOptions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, householdResponse{
+		Results: households,
+		Info: info{
+			Seed:    strconv.FormatInt(seed, 10),
+			Results: results,
+			Page:    page,
+		},
+	})
+}
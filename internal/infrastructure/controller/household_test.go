@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateHouseholds(t *testing.T) {
+	cfg := &config.Config{MaxResults: 50}
+
+	tests := []struct {
+		name           string
+		query          string
+		setUpMock      func(*MockHouseholdGenerator)
+		expectedStatus int
+		expectedInfo   info
+	}{
+		{
+			name:  "正常なリクエスト",
+			query: "?results=2&seed=100&page=2&nat=us",
+			setUpMock: func(m *MockHouseholdGenerator) {
+				m.EXPECT().GenerateHouseholds(2, int64(102), generator.Options{Nat: "us"}).Return([]model.Household{
+					{ID: "h1", Type: generator.HouseholdSingle, Members: []model.Member{{Role: generator.RoleHead}}},
+					{ID: "h2", Type: generator.HouseholdCouple},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   info{Seed: "102", Results: 2, Page: 2},
+		},
+		{
+			name:  "上限を超える件数",
+			query: "?results=51&seed=1",
+			setUpMock: func(m *MockHouseholdGenerator) {
+				m.EXPECT().GenerateHouseholds(1, int64(2), generator.Options{}).Return([]model.Household{{ID: "h1"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   info{Seed: "2", Results: 1, Page: 1},
+		},
+		{
+			name:  "ユーザーと共通の条件",
+			query: "?seed=1&asOf=2030-06-15&inc=finance&state=Texas&lastNameStartsWith=s",
+			setUpMock: func(m *MockHouseholdGenerator) {
+				m.EXPECT().GenerateHouseholds(1, int64(2), generator.Options{
+					Include:            []string{"finance"},
+					AsOf:               time.Date(2030, 6, 15, 0, 0, 0, 0, time.UTC),
+					State:              "Texas",
+					LastNameStartsWith: "s",
+				}).Return([]model.Household{{ID: "h1"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   info{Seed: "2", Results: 1, Page: 1},
+		},
+		{
+			name:           "不正な基準日",
+			query:          "?seed=1&asOf=2030/06/15",
+			setUpMock:      func(m *MockHouseholdGenerator) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "未対応の国籍",
+			query: "?seed=1&nat=xx",
+			setUpMock: func(m *MockHouseholdGenerator) {
+				m.EXPECT().GenerateHouseholds(1, int64(2), generator.Options{Nat: "xx"}).Return(nil, generator.ErrInvalidOptions)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockGen := NewMockHouseholdGenerator(t)
+			tt.setUpMock(mockGen)
+
+			r.GET("/api/households", func(c *gin.Context) {
+				GenerateHouseholds(c, mockGen, cfg)
+			})
+
+			req, _ := http.NewRequest("GET", "/api/households"+tt.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var res householdResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, tt.expectedInfo, res.Info)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/export"
+)
+
+// ElasticsearchMapping は format=elasticsearch の出力に対応するインデックスのマッピングを返す。
+// PUT /<index> のリクエストボディとしてそのまま使える
+func ElasticsearchMapping(c *gin.Context) {
+	c.JSON(http.StatusOK, export.ElasticsearchMapping())
+}
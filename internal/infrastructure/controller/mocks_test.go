@@ -5,10 +5,1717 @@
 package controller
 
 import (
+	"github.com/ryuhei/randomuser-go/internal/dataset"
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/generator"
 	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/ryuhei/randomuser-go/internal/oidc"
+	"github.com/ryuhei/randomuser-go/internal/reload"
 	mock "github.com/stretchr/testify/mock"
 )
 
+// NewMockDatasetSource creates a new instance of MockDatasetSource. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDatasetSource(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDatasetSource {
+	mock := &MockDatasetSource{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockDatasetSource is an autogenerated mock type for the DatasetSource type
+type MockDatasetSource struct {
+	mock.Mock
+}
+
+type MockDatasetSource_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDatasetSource) EXPECT() *MockDatasetSource_Expecter {
+	return &MockDatasetSource_Expecter{mock: &_m.Mock}
+}
+
+// Datasets provides a mock function for the type MockDatasetSource
+func (_mock *MockDatasetSource) Datasets() []dataset.Info {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Datasets")
+	}
+
+	var r0 []dataset.Info
+	if returnFunc, ok := ret.Get(0).(func() []dataset.Info); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dataset.Info)
+		}
+	}
+	return r0
+}
+
+// MockDatasetSource_Datasets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Datasets'
+type MockDatasetSource_Datasets_Call struct {
+	*mock.Call
+}
+
+// Datasets is a helper method to define mock.On call
+func (_e *MockDatasetSource_Expecter) Datasets() *MockDatasetSource_Datasets_Call {
+	return &MockDatasetSource_Datasets_Call{Call: _e.mock.On("Datasets")}
+}
+
+func (_c *MockDatasetSource_Datasets_Call) Run(run func()) *MockDatasetSource_Datasets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDatasetSource_Datasets_Call) Return(infos []dataset.Info) *MockDatasetSource_Datasets_Call {
+	_c.Call.Return(infos)
+	return _c
+}
+
+func (_c *MockDatasetSource_Datasets_Call) RunAndReturn(run func() []dataset.Info) *MockDatasetSource_Datasets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockHouseholdGenerator creates a new instance of MockHouseholdGenerator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockHouseholdGenerator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockHouseholdGenerator {
+	mock := &MockHouseholdGenerator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockHouseholdGenerator is an autogenerated mock type for the HouseholdGenerator type
+type MockHouseholdGenerator struct {
+	mock.Mock
+}
+
+type MockHouseholdGenerator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockHouseholdGenerator) EXPECT() *MockHouseholdGenerator_Expecter {
+	return &MockHouseholdGenerator_Expecter{mock: &_m.Mock}
+}
+
+// GenerateHouseholds provides a mock function for the type MockHouseholdGenerator
+func (_mock *MockHouseholdGenerator) GenerateHouseholds(count int, seed int64, opts generator.Options) ([]model.Household, error) {
+	ret := _mock.Called(count, seed, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateHouseholds")
+	}
+
+	var r0 []model.Household
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(int, int64, generator.Options) ([]model.Household, error)); ok {
+		return returnFunc(count, seed, opts)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int, int64, generator.Options) []model.Household); ok {
+		r0 = returnFunc(count, seed, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Household)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(int, int64, generator.Options) error); ok {
+		r1 = returnFunc(count, seed, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockHouseholdGenerator_GenerateHouseholds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateHouseholds'
+type MockHouseholdGenerator_GenerateHouseholds_Call struct {
+	*mock.Call
+}
+
+// GenerateHouseholds is a helper method to define mock.On call
+//   - count
+//   - seed
+//   - opts
+func (_e *MockHouseholdGenerator_Expecter) GenerateHouseholds(count interface{}, seed interface{}, opts interface{}) *MockHouseholdGenerator_GenerateHouseholds_Call {
+	return &MockHouseholdGenerator_GenerateHouseholds_Call{Call: _e.mock.On("GenerateHouseholds", count, seed, opts)}
+}
+
+func (_c *MockHouseholdGenerator_GenerateHouseholds_Call) Run(run func(count int, seed int64, opts generator.Options)) *MockHouseholdGenerator_GenerateHouseholds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int64), args[2].(generator.Options))
+	})
+	return _c
+}
+
+func (_c *MockHouseholdGenerator_GenerateHouseholds_Call) Return(households []model.Household, err error) *MockHouseholdGenerator_GenerateHouseholds_Call {
+	_c.Call.Return(households, err)
+	return _c
+}
+
+func (_c *MockHouseholdGenerator_GenerateHouseholds_Call) RunAndReturn(run func(count int, seed int64, opts generator.Options) ([]model.Household, error)) *MockHouseholdGenerator_GenerateHouseholds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockIdentityProvider creates a new instance of MockIdentityProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockIdentityProvider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockIdentityProvider {
+	mock := &MockIdentityProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockIdentityProvider is an autogenerated mock type for the IdentityProvider type
+type MockIdentityProvider struct {
+	mock.Mock
+}
+
+type MockIdentityProvider_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockIdentityProvider) EXPECT() *MockIdentityProvider_Expecter {
+	return &MockIdentityProvider_Expecter{mock: &_m.Mock}
+}
+
+// Authorize provides a mock function for the type MockIdentityProvider
+func (_mock *MockIdentityProvider) Authorize(req oidc.AuthRequest, username string, password string) (string, error) {
+	ret := _mock.Called(req, username, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authorize")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(oidc.AuthRequest, string, string) (string, error)); ok {
+		return returnFunc(req, username, password)
+	}
+	if returnFunc, ok := ret.Get(0).(func(oidc.AuthRequest, string, string) string); ok {
+		r0 = returnFunc(req, username, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(oidc.AuthRequest, string, string) error); ok {
+		r1 = returnFunc(req, username, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockIdentityProvider_Authorize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Authorize'
+type MockIdentityProvider_Authorize_Call struct {
+	*mock.Call
+}
+
+// Authorize is a helper method to define mock.On call
+//   - req
+//   - username
+//   - password
+func (_e *MockIdentityProvider_Expecter) Authorize(req interface{}, username interface{}, password interface{}) *MockIdentityProvider_Authorize_Call {
+	return &MockIdentityProvider_Authorize_Call{Call: _e.mock.On("Authorize", req, username, password)}
+}
+
+func (_c *MockIdentityProvider_Authorize_Call) Run(run func(req oidc.AuthRequest, username string, password string)) *MockIdentityProvider_Authorize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(oidc.AuthRequest), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockIdentityProvider_Authorize_Call) Return(s string, err error) *MockIdentityProvider_Authorize_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockIdentityProvider_Authorize_Call) RunAndReturn(run func(req oidc.AuthRequest, username string, password string) (string, error)) *MockIdentityProvider_Authorize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Check provides a mock function for the type MockIdentityProvider
+func (_mock *MockIdentityProvider) Check(req oidc.AuthRequest) error {
+	ret := _mock.Called(req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(oidc.AuthRequest) error); ok {
+		r0 = returnFunc(req)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockIdentityProvider_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type MockIdentityProvider_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - req
+func (_e *MockIdentityProvider_Expecter) Check(req interface{}) *MockIdentityProvider_Check_Call {
+	return &MockIdentityProvider_Check_Call{Call: _e.mock.On("Check", req)}
+}
+
+func (_c *MockIdentityProvider_Check_Call) Run(run func(req oidc.AuthRequest)) *MockIdentityProvider_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(oidc.AuthRequest))
+	})
+	return _c
+}
+
+func (_c *MockIdentityProvider_Check_Call) Return(err error) *MockIdentityProvider_Check_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockIdentityProvider_Check_Call) RunAndReturn(run func(req oidc.AuthRequest) error) *MockIdentityProvider_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CheckClient provides a mock function for the type MockIdentityProvider
+func (_mock *MockIdentityProvider) CheckClient(clientID string, redirectURI string) error {
+	ret := _mock.Called(clientID, redirectURI)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckClient")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = returnFunc(clientID, redirectURI)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockIdentityProvider_CheckClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckClient'
+type MockIdentityProvider_CheckClient_Call struct {
+	*mock.Call
+}
+
+// CheckClient is a helper method to define mock.On call
+//   - clientID
+//   - redirectURI
+func (_e *MockIdentityProvider_Expecter) CheckClient(clientID interface{}, redirectURI interface{}) *MockIdentityProvider_CheckClient_Call {
+	return &MockIdentityProvider_CheckClient_Call{Call: _e.mock.On("CheckClient", clientID, redirectURI)}
+}
+
+func (_c *MockIdentityProvider_CheckClient_Call) Run(run func(clientID string, redirectURI string)) *MockIdentityProvider_CheckClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockIdentityProvider_CheckClient_Call) Return(err error) *MockIdentityProvider_CheckClient_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockIdentityProvider_CheckClient_Call) RunAndReturn(run func(clientID string, redirectURI string) error) *MockIdentityProvider_CheckClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// JWKS provides a mock function for the type MockIdentityProvider
+func (_mock *MockIdentityProvider) JWKS() oidc.JWKS {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for JWKS")
+	}
+
+	var r0 oidc.JWKS
+	if returnFunc, ok := ret.Get(0).(func() oidc.JWKS); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(oidc.JWKS)
+	}
+	return r0
+}
+
+// MockIdentityProvider_JWKS_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'JWKS'
+type MockIdentityProvider_JWKS_Call struct {
+	*mock.Call
+}
+
+// JWKS is a helper method to define mock.On call
+func (_e *MockIdentityProvider_Expecter) JWKS() *MockIdentityProvider_JWKS_Call {
+	return &MockIdentityProvider_JWKS_Call{Call: _e.mock.On("JWKS")}
+}
+
+func (_c *MockIdentityProvider_JWKS_Call) Run(run func()) *MockIdentityProvider_JWKS_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockIdentityProvider_JWKS_Call) Return(jWKS oidc.JWKS) *MockIdentityProvider_JWKS_Call {
+	_c.Call.Return(jWKS)
+	return _c
+}
+
+func (_c *MockIdentityProvider_JWKS_Call) RunAndReturn(run func() oidc.JWKS) *MockIdentityProvider_JWKS_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Token provides a mock function for the type MockIdentityProvider
+func (_mock *MockIdentityProvider) Token(req oidc.TokenRequest, issuer string) (oidc.TokenResponse, error) {
+	ret := _mock.Called(req, issuer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Token")
+	}
+
+	var r0 oidc.TokenResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(oidc.TokenRequest, string) (oidc.TokenResponse, error)); ok {
+		return returnFunc(req, issuer)
+	}
+	if returnFunc, ok := ret.Get(0).(func(oidc.TokenRequest, string) oidc.TokenResponse); ok {
+		r0 = returnFunc(req, issuer)
+	} else {
+		r0 = ret.Get(0).(oidc.TokenResponse)
+	}
+	if returnFunc, ok := ret.Get(1).(func(oidc.TokenRequest, string) error); ok {
+		r1 = returnFunc(req, issuer)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockIdentityProvider_Token_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Token'
+type MockIdentityProvider_Token_Call struct {
+	*mock.Call
+}
+
+// Token is a helper method to define mock.On call
+//   - req
+//   - issuer
+func (_e *MockIdentityProvider_Expecter) Token(req interface{}, issuer interface{}) *MockIdentityProvider_Token_Call {
+	return &MockIdentityProvider_Token_Call{Call: _e.mock.On("Token", req, issuer)}
+}
+
+func (_c *MockIdentityProvider_Token_Call) Run(run func(req oidc.TokenRequest, issuer string)) *MockIdentityProvider_Token_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(oidc.TokenRequest), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockIdentityProvider_Token_Call) Return(tokenResponse oidc.TokenResponse, err error) *MockIdentityProvider_Token_Call {
+	_c.Call.Return(tokenResponse, err)
+	return _c
+}
+
+func (_c *MockIdentityProvider_Token_Call) RunAndReturn(run func(req oidc.TokenRequest, issuer string) (oidc.TokenResponse, error)) *MockIdentityProvider_Token_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UserInfo provides a mock function for the type MockIdentityProvider
+func (_mock *MockIdentityProvider) UserInfo(accessToken string, issuer string) (map[string]any, error) {
+	ret := _mock.Called(accessToken, issuer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserInfo")
+	}
+
+	var r0 map[string]any
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string) (map[string]any, error)); ok {
+		return returnFunc(accessToken, issuer)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string) map[string]any); ok {
+		r0 = returnFunc(accessToken, issuer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]any)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = returnFunc(accessToken, issuer)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockIdentityProvider_UserInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UserInfo'
+type MockIdentityProvider_UserInfo_Call struct {
+	*mock.Call
+}
+
+// UserInfo is a helper method to define mock.On call
+//   - accessToken
+//   - issuer
+func (_e *MockIdentityProvider_Expecter) UserInfo(accessToken interface{}, issuer interface{}) *MockIdentityProvider_UserInfo_Call {
+	return &MockIdentityProvider_UserInfo_Call{Call: _e.mock.On("UserInfo", accessToken, issuer)}
+}
+
+func (_c *MockIdentityProvider_UserInfo_Call) Run(run func(accessToken string, issuer string)) *MockIdentityProvider_UserInfo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockIdentityProvider_UserInfo_Call) Return(stringToV map[string]any, err error) *MockIdentityProvider_UserInfo_Call {
+	_c.Call.Return(stringToV, err)
+	return _c
+}
+
+func (_c *MockIdentityProvider_UserInfo_Call) RunAndReturn(run func(accessToken string, issuer string) (map[string]any, error)) *MockIdentityProvider_UserInfo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockOrganizationGenerator creates a new instance of MockOrganizationGenerator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockOrganizationGenerator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockOrganizationGenerator {
+	mock := &MockOrganizationGenerator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockOrganizationGenerator is an autogenerated mock type for the OrganizationGenerator type
+type MockOrganizationGenerator struct {
+	mock.Mock
+}
+
+type MockOrganizationGenerator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockOrganizationGenerator) EXPECT() *MockOrganizationGenerator_Expecter {
+	return &MockOrganizationGenerator_Expecter{mock: &_m.Mock}
+}
+
+// GenerateOrganization provides a mock function for the type MockOrganizationGenerator
+func (_mock *MockOrganizationGenerator) GenerateOrganization(size int, depth int, seed int64, opts generator.Options) (model.Organization, error) {
+	ret := _mock.Called(size, depth, seed, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateOrganization")
+	}
+
+	var r0 model.Organization
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(int, int, int64, generator.Options) (model.Organization, error)); ok {
+		return returnFunc(size, depth, seed, opts)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int, int, int64, generator.Options) model.Organization); ok {
+		r0 = returnFunc(size, depth, seed, opts)
+	} else {
+		r0 = ret.Get(0).(model.Organization)
+	}
+	if returnFunc, ok := ret.Get(1).(func(int, int, int64, generator.Options) error); ok {
+		r1 = returnFunc(size, depth, seed, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockOrganizationGenerator_GenerateOrganization_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateOrganization'
+type MockOrganizationGenerator_GenerateOrganization_Call struct {
+	*mock.Call
+}
+
+// GenerateOrganization is a helper method to define mock.On call
+//   - size
+//   - depth
+//   - seed
+//   - opts
+func (_e *MockOrganizationGenerator_Expecter) GenerateOrganization(size interface{}, depth interface{}, seed interface{}, opts interface{}) *MockOrganizationGenerator_GenerateOrganization_Call {
+	return &MockOrganizationGenerator_GenerateOrganization_Call{Call: _e.mock.On("GenerateOrganization", size, depth, seed, opts)}
+}
+
+func (_c *MockOrganizationGenerator_GenerateOrganization_Call) Run(run func(size int, depth int, seed int64, opts generator.Options)) *MockOrganizationGenerator_GenerateOrganization_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int), args[2].(int64), args[3].(generator.Options))
+	})
+	return _c
+}
+
+func (_c *MockOrganizationGenerator_GenerateOrganization_Call) Return(organization model.Organization, err error) *MockOrganizationGenerator_GenerateOrganization_Call {
+	_c.Call.Return(organization, err)
+	return _c
+}
+
+func (_c *MockOrganizationGenerator_GenerateOrganization_Call) RunAndReturn(run func(size int, depth int, seed int64, opts generator.Options) (model.Organization, error)) *MockOrganizationGenerator_GenerateOrganization_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockPopulationGenerator creates a new instance of MockPopulationGenerator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockPopulationGenerator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockPopulationGenerator {
+	mock := &MockPopulationGenerator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockPopulationGenerator is an autogenerated mock type for the PopulationGenerator type
+type MockPopulationGenerator struct {
+	mock.Mock
+}
+
+type MockPopulationGenerator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockPopulationGenerator) EXPECT() *MockPopulationGenerator_Expecter {
+	return &MockPopulationGenerator_Expecter{mock: &_m.Mock}
+}
+
+// GeneratePopulation provides a mock function for the type MockPopulationGenerator
+func (_mock *MockPopulationGenerator) GeneratePopulation(p generator.Population, seed int64, opts generator.Options) ([]model.User, error) {
+	ret := _mock.Called(p, seed, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GeneratePopulation")
+	}
+
+	var r0 []model.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(generator.Population, int64, generator.Options) ([]model.User, error)); ok {
+		return returnFunc(p, seed, opts)
+	}
+	if returnFunc, ok := ret.Get(0).(func(generator.Population, int64, generator.Options) []model.User); ok {
+		r0 = returnFunc(p, seed, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(generator.Population, int64, generator.Options) error); ok {
+		r1 = returnFunc(p, seed, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPopulationGenerator_GeneratePopulation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GeneratePopulation'
+type MockPopulationGenerator_GeneratePopulation_Call struct {
+	*mock.Call
+}
+
+// GeneratePopulation is a helper method to define mock.On call
+//   - p
+//   - seed
+//   - opts
+func (_e *MockPopulationGenerator_Expecter) GeneratePopulation(p interface{}, seed interface{}, opts interface{}) *MockPopulationGenerator_GeneratePopulation_Call {
+	return &MockPopulationGenerator_GeneratePopulation_Call{Call: _e.mock.On("GeneratePopulation", p, seed, opts)}
+}
+
+func (_c *MockPopulationGenerator_GeneratePopulation_Call) Run(run func(p generator.Population, seed int64, opts generator.Options)) *MockPopulationGenerator_GeneratePopulation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(generator.Population), args[1].(int64), args[2].(generator.Options))
+	})
+	return _c
+}
+
+func (_c *MockPopulationGenerator_GeneratePopulation_Call) Return(_a0 []model.User, _a1 error) *MockPopulationGenerator_GeneratePopulation_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPopulationGenerator_GeneratePopulation_Call) RunAndReturn(run func(p generator.Population, seed int64, opts generator.Options) ([]model.User, error)) *MockPopulationGenerator_GeneratePopulation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamPopulation provides a mock function for the type MockPopulationGenerator
+func (_mock *MockPopulationGenerator) StreamPopulation(p generator.Population, seed int64, opts generator.Options, fn func(model.User) error) error {
+	ret := _mock.Called(p, seed, opts, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamPopulation")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(generator.Population, int64, generator.Options, func(model.User) error) error); ok {
+		r0 = returnFunc(p, seed, opts, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPopulationGenerator_StreamPopulation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamPopulation'
+type MockPopulationGenerator_StreamPopulation_Call struct {
+	*mock.Call
+}
+
+// StreamPopulation is a helper method to define mock.On call
+//   - p
+//   - seed
+//   - opts
+//   - fn
+func (_e *MockPopulationGenerator_Expecter) StreamPopulation(p interface{}, seed interface{}, opts interface{}, fn interface{}) *MockPopulationGenerator_StreamPopulation_Call {
+	return &MockPopulationGenerator_StreamPopulation_Call{Call: _e.mock.On("StreamPopulation", p, seed, opts, fn)}
+}
+
+func (_c *MockPopulationGenerator_StreamPopulation_Call) Run(run func(p generator.Population, seed int64, opts generator.Options, fn func(model.User) error)) *MockPopulationGenerator_StreamPopulation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(generator.Population), args[1].(int64), args[2].(generator.Options), args[3].(func(model.User) error))
+	})
+	return _c
+}
+
+func (_c *MockPopulationGenerator_StreamPopulation_Call) Return(_a0 error) *MockPopulationGenerator_StreamPopulation_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockPopulationGenerator_StreamPopulation_Call) RunAndReturn(run func(p generator.Population, seed int64, opts generator.Options, fn func(model.User) error) error) *MockPopulationGenerator_StreamPopulation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockPortraitSigner creates a new instance of MockPortraitSigner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockPortraitSigner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockPortraitSigner {
+	mock := &MockPortraitSigner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockPortraitSigner is an autogenerated mock type for the PortraitSigner type
+type MockPortraitSigner struct {
+	mock.Mock
+}
+
+type MockPortraitSigner_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockPortraitSigner) EXPECT() *MockPortraitSigner_Expecter {
+	return &MockPortraitSigner_Expecter{mock: &_m.Mock}
+}
+
+// SignPortrait provides a mock function for the type MockPortraitSigner
+func (_mock *MockPortraitSigner) SignPortrait(key string) (string, error) {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SignPortrait")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return returnFunc(key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) string); ok {
+		r0 = returnFunc(key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPortraitSigner_SignPortrait_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignPortrait'
+type MockPortraitSigner_SignPortrait_Call struct {
+	*mock.Call
+}
+
+// SignPortrait is a helper method to define mock.On call
+//   - key
+func (_e *MockPortraitSigner_Expecter) SignPortrait(key interface{}) *MockPortraitSigner_SignPortrait_Call {
+	return &MockPortraitSigner_SignPortrait_Call{Call: _e.mock.On("SignPortrait", key)}
+}
+
+func (_c *MockPortraitSigner_SignPortrait_Call) Run(run func(key string)) *MockPortraitSigner_SignPortrait_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockPortraitSigner_SignPortrait_Call) Return(_a0 string, _a1 error) *MockPortraitSigner_SignPortrait_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPortraitSigner_SignPortrait_Call) RunAndReturn(run func(key string) (string, error)) *MockPortraitSigner_SignPortrait_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockReloader creates a new instance of MockReloader. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockReloader(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockReloader {
+	mock := &MockReloader{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockReloader is an autogenerated mock type for the Reloader type
+type MockReloader struct {
+	mock.Mock
+}
+
+type MockReloader_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockReloader) EXPECT() *MockReloader_Expecter {
+	return &MockReloader_Expecter{mock: &_m.Mock}
+}
+
+// Reload provides a mock function for the type MockReloader
+func (_mock *MockReloader) Reload(trigger string) (reload.Status, error) {
+	ret := _mock.Called(trigger)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reload")
+	}
+
+	var r0 reload.Status
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (reload.Status, error)); ok {
+		return returnFunc(trigger)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) reload.Status); ok {
+		r0 = returnFunc(trigger)
+	} else {
+		r0 = ret.Get(0).(reload.Status)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(trigger)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockReloader_Reload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reload'
+type MockReloader_Reload_Call struct {
+	*mock.Call
+}
+
+// Reload is a helper method to define mock.On call
+//   - trigger
+func (_e *MockReloader_Expecter) Reload(trigger interface{}) *MockReloader_Reload_Call {
+	return &MockReloader_Reload_Call{Call: _e.mock.On("Reload", trigger)}
+}
+
+func (_c *MockReloader_Reload_Call) Run(run func(trigger string)) *MockReloader_Reload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockReloader_Reload_Call) Return(status reload.Status, err error) *MockReloader_Reload_Call {
+	_c.Call.Return(status, err)
+	return _c
+}
+
+func (_c *MockReloader_Reload_Call) RunAndReturn(run func(trigger string) (reload.Status, error)) *MockReloader_Reload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Status provides a mock function for the type MockReloader
+func (_mock *MockReloader) Status() reload.Status {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Status")
+	}
+
+	var r0 reload.Status
+	if returnFunc, ok := ret.Get(0).(func() reload.Status); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(reload.Status)
+	}
+	return r0
+}
+
+// MockReloader_Status_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Status'
+type MockReloader_Status_Call struct {
+	*mock.Call
+}
+
+// Status is a helper method to define mock.On call
+func (_e *MockReloader_Expecter) Status() *MockReloader_Status_Call {
+	return &MockReloader_Status_Call{Call: _e.mock.On("Status")}
+}
+
+func (_c *MockReloader_Status_Call) Run(run func()) *MockReloader_Status_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockReloader_Status_Call) Return(status reload.Status) *MockReloader_Status_Call {
+	_c.Call.Return(status)
+	return _c
+}
+
+func (_c *MockReloader_Status_Call) RunAndReturn(run func() reload.Status) *MockReloader_Status_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockSandboxDirectory creates a new instance of MockSandboxDirectory. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSandboxDirectory(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSandboxDirectory {
+	mock := &MockSandboxDirectory{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSandboxDirectory is an autogenerated mock type for the SandboxDirectory type
+type MockSandboxDirectory struct {
+	mock.Mock
+}
+
+type MockSandboxDirectory_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSandboxDirectory) EXPECT() *MockSandboxDirectory_Expecter {
+	return &MockSandboxDirectory_Expecter{mock: &_m.Mock}
+}
+
+// Create provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Create(e directory.Entry) (directory.Entry, error) {
+	ret := _mock.Called(e)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 directory.Entry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(directory.Entry) (directory.Entry, error)); ok {
+		return returnFunc(e)
+	}
+	if returnFunc, ok := ret.Get(0).(func(directory.Entry) directory.Entry); ok {
+		r0 = returnFunc(e)
+	} else {
+		r0 = ret.Get(0).(directory.Entry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(directory.Entry) error); ok {
+		r1 = returnFunc(e)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSandboxDirectory_Create_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Create'
+type MockSandboxDirectory_Create_Call struct {
+	*mock.Call
+}
+
+// Create is a helper method to define mock.On call
+//   - e
+func (_e *MockSandboxDirectory_Expecter) Create(e interface{}) *MockSandboxDirectory_Create_Call {
+	return &MockSandboxDirectory_Create_Call{Call: _e.mock.On("Create", e)}
+}
+
+func (_c *MockSandboxDirectory_Create_Call) Run(run func(e directory.Entry)) *MockSandboxDirectory_Create_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(directory.Entry))
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Create_Call) Return(entry directory.Entry, err error) *MockSandboxDirectory_Create_Call {
+	_c.Call.Return(entry, err)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Create_Call) RunAndReturn(run func(e directory.Entry) (directory.Entry, error)) *MockSandboxDirectory_Create_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Delete(id string) error {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSandboxDirectory_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockSandboxDirectory_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - id
+func (_e *MockSandboxDirectory_Expecter) Delete(id interface{}) *MockSandboxDirectory_Delete_Call {
+	return &MockSandboxDirectory_Delete_Call{Call: _e.mock.On("Delete", id)}
+}
+
+func (_c *MockSandboxDirectory_Delete_Call) Run(run func(id string)) *MockSandboxDirectory_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Delete_Call) Return(err error) *MockSandboxDirectory_Delete_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Delete_Call) RunAndReturn(run func(id string) error) *MockSandboxDirectory_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Get(id string) (directory.Entry, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 directory.Entry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (directory.Entry, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) directory.Entry); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Get(0).(directory.Entry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSandboxDirectory_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockSandboxDirectory_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - id
+func (_e *MockSandboxDirectory_Expecter) Get(id interface{}) *MockSandboxDirectory_Get_Call {
+	return &MockSandboxDirectory_Get_Call{Call: _e.mock.On("Get", id)}
+}
+
+func (_c *MockSandboxDirectory_Get_Call) Run(run func(id string)) *MockSandboxDirectory_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Get_Call) Return(entry directory.Entry, err error) *MockSandboxDirectory_Get_Call {
+	_c.Call.Return(entry, err)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Get_Call) RunAndReturn(run func(id string) (directory.Entry, error)) *MockSandboxDirectory_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) List() []directory.Entry {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []directory.Entry
+	if returnFunc, ok := ret.Get(0).(func() []directory.Entry); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]directory.Entry)
+		}
+	}
+	return r0
+}
+
+// MockSandboxDirectory_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockSandboxDirectory_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+func (_e *MockSandboxDirectory_Expecter) List() *MockSandboxDirectory_List_Call {
+	return &MockSandboxDirectory_List_Call{Call: _e.mock.On("List")}
+}
+
+func (_c *MockSandboxDirectory_List_Call) Run(run func()) *MockSandboxDirectory_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_List_Call) Return(entrys []directory.Entry) *MockSandboxDirectory_List_Call {
+	_c.Call.Return(entrys)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_List_Call) RunAndReturn(run func() []directory.Entry) *MockSandboxDirectory_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Regenerate provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Regenerate(size int, seed int64) error {
+	ret := _mock.Called(size, seed)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Regenerate")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(int, int64) error); ok {
+		r0 = returnFunc(size, seed)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSandboxDirectory_Regenerate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Regenerate'
+type MockSandboxDirectory_Regenerate_Call struct {
+	*mock.Call
+}
+
+// Regenerate is a helper method to define mock.On call
+//   - size
+//   - seed
+func (_e *MockSandboxDirectory_Expecter) Regenerate(size interface{}, seed interface{}) *MockSandboxDirectory_Regenerate_Call {
+	return &MockSandboxDirectory_Regenerate_Call{Call: _e.mock.On("Regenerate", size, seed)}
+}
+
+func (_c *MockSandboxDirectory_Regenerate_Call) Run(run func(size int, seed int64)) *MockSandboxDirectory_Regenerate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Regenerate_Call) Return(err error) *MockSandboxDirectory_Regenerate_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Regenerate_Call) RunAndReturn(run func(size int, seed int64) error) *MockSandboxDirectory_Regenerate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Reset provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Reset() {
+	_mock.Called()
+	return
+}
+
+// MockSandboxDirectory_Reset_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reset'
+type MockSandboxDirectory_Reset_Call struct {
+	*mock.Call
+}
+
+// Reset is a helper method to define mock.On call
+func (_e *MockSandboxDirectory_Expecter) Reset() *MockSandboxDirectory_Reset_Call {
+	return &MockSandboxDirectory_Reset_Call{Call: _e.mock.On("Reset")}
+}
+
+func (_c *MockSandboxDirectory_Reset_Call) Run(run func()) *MockSandboxDirectory_Reset_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Reset_Call) Return() *MockSandboxDirectory_Reset_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Reset_Call) RunAndReturn(run func()) *MockSandboxDirectory_Reset_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Search provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Search(q directory.Query) ([]directory.Entry, int, error) {
+	ret := _mock.Called(q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []directory.Entry
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(directory.Query) ([]directory.Entry, int, error)); ok {
+		return returnFunc(q)
+	}
+	if returnFunc, ok := ret.Get(0).(func(directory.Query) []directory.Entry); ok {
+		r0 = returnFunc(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]directory.Entry)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(directory.Query) int); ok {
+		r1 = returnFunc(q)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(directory.Query) error); ok {
+		r2 = returnFunc(q)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockSandboxDirectory_Search_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Search'
+type MockSandboxDirectory_Search_Call struct {
+	*mock.Call
+}
+
+// Search is a helper method to define mock.On call
+//   - q
+func (_e *MockSandboxDirectory_Expecter) Search(q interface{}) *MockSandboxDirectory_Search_Call {
+	return &MockSandboxDirectory_Search_Call{Call: _e.mock.On("Search", q)}
+}
+
+func (_c *MockSandboxDirectory_Search_Call) Run(run func(q directory.Query)) *MockSandboxDirectory_Search_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(directory.Query))
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Search_Call) Return(entrys []directory.Entry, n int, err error) *MockSandboxDirectory_Search_Call {
+	_c.Call.Return(entrys, n, err)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Search_Call) RunAndReturn(run func(q directory.Query) ([]directory.Entry, int, error)) *MockSandboxDirectory_Search_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Seed provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Seed() int64 {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Seed")
+	}
+
+	var r0 int64
+	if returnFunc, ok := ret.Get(0).(func() int64); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	return r0
+}
+
+// MockSandboxDirectory_Seed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Seed'
+type MockSandboxDirectory_Seed_Call struct {
+	*mock.Call
+}
+
+// Seed is a helper method to define mock.On call
+func (_e *MockSandboxDirectory_Expecter) Seed() *MockSandboxDirectory_Seed_Call {
+	return &MockSandboxDirectory_Seed_Call{Call: _e.mock.On("Seed")}
+}
+
+func (_c *MockSandboxDirectory_Seed_Call) Run(run func()) *MockSandboxDirectory_Seed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Seed_Call) Return(n int64) *MockSandboxDirectory_Seed_Call {
+	_c.Call.Return(n)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Seed_Call) RunAndReturn(run func() int64) *MockSandboxDirectory_Seed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Size provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Size() int {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Size")
+	}
+
+	var r0 int
+	if returnFunc, ok := ret.Get(0).(func() int); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	return r0
+}
+
+// MockSandboxDirectory_Size_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Size'
+type MockSandboxDirectory_Size_Call struct {
+	*mock.Call
+}
+
+// Size is a helper method to define mock.On call
+func (_e *MockSandboxDirectory_Expecter) Size() *MockSandboxDirectory_Size_Call {
+	return &MockSandboxDirectory_Size_Call{Call: _e.mock.On("Size")}
+}
+
+func (_c *MockSandboxDirectory_Size_Call) Run(run func()) *MockSandboxDirectory_Size_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Size_Call) Return(n int) *MockSandboxDirectory_Size_Call {
+	_c.Call.Return(n)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Size_Call) RunAndReturn(run func() int) *MockSandboxDirectory_Size_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Update provides a mock function for the type MockSandboxDirectory
+func (_mock *MockSandboxDirectory) Update(id string, fn func(*directory.Entry) error) (directory.Entry, error) {
+	ret := _mock.Called(id, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 directory.Entry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, func(*directory.Entry) error) (directory.Entry, error)); ok {
+		return returnFunc(id, fn)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, func(*directory.Entry) error) directory.Entry); ok {
+		r0 = returnFunc(id, fn)
+	} else {
+		r0 = ret.Get(0).(directory.Entry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, func(*directory.Entry) error) error); ok {
+		r1 = returnFunc(id, fn)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSandboxDirectory_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type MockSandboxDirectory_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - id
+//   - fn
+func (_e *MockSandboxDirectory_Expecter) Update(id interface{}, fn interface{}) *MockSandboxDirectory_Update_Call {
+	return &MockSandboxDirectory_Update_Call{Call: _e.mock.On("Update", id, fn)}
+}
+
+func (_c *MockSandboxDirectory_Update_Call) Run(run func(id string, fn func(*directory.Entry) error)) *MockSandboxDirectory_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func(*directory.Entry) error))
+	})
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Update_Call) Return(entry directory.Entry, err error) *MockSandboxDirectory_Update_Call {
+	_c.Call.Return(entry, err)
+	return _c
+}
+
+func (_c *MockSandboxDirectory_Update_Call) RunAndReturn(run func(id string, fn func(*directory.Entry) error) (directory.Entry, error)) *MockSandboxDirectory_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockUserDirectory creates a new instance of MockUserDirectory. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockUserDirectory(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockUserDirectory {
+	mock := &MockUserDirectory{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockUserDirectory is an autogenerated mock type for the UserDirectory type
+type MockUserDirectory struct {
+	mock.Mock
+}
+
+type MockUserDirectory_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockUserDirectory) EXPECT() *MockUserDirectory_Expecter {
+	return &MockUserDirectory_Expecter{mock: &_m.Mock}
+}
+
+// Create provides a mock function for the type MockUserDirectory
+func (_mock *MockUserDirectory) Create(e directory.Entry) (directory.Entry, error) {
+	ret := _mock.Called(e)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 directory.Entry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(directory.Entry) (directory.Entry, error)); ok {
+		return returnFunc(e)
+	}
+	if returnFunc, ok := ret.Get(0).(func(directory.Entry) directory.Entry); ok {
+		r0 = returnFunc(e)
+	} else {
+		r0 = ret.Get(0).(directory.Entry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(directory.Entry) error); ok {
+		r1 = returnFunc(e)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserDirectory_Create_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Create'
+type MockUserDirectory_Create_Call struct {
+	*mock.Call
+}
+
+// Create is a helper method to define mock.On call
+//   - e
+func (_e *MockUserDirectory_Expecter) Create(e interface{}) *MockUserDirectory_Create_Call {
+	return &MockUserDirectory_Create_Call{Call: _e.mock.On("Create", e)}
+}
+
+func (_c *MockUserDirectory_Create_Call) Run(run func(e directory.Entry)) *MockUserDirectory_Create_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(directory.Entry))
+	})
+	return _c
+}
+
+func (_c *MockUserDirectory_Create_Call) Return(entry directory.Entry, err error) *MockUserDirectory_Create_Call {
+	_c.Call.Return(entry, err)
+	return _c
+}
+
+func (_c *MockUserDirectory_Create_Call) RunAndReturn(run func(e directory.Entry) (directory.Entry, error)) *MockUserDirectory_Create_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function for the type MockUserDirectory
+func (_mock *MockUserDirectory) Delete(id string) error {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserDirectory_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockUserDirectory_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - id
+func (_e *MockUserDirectory_Expecter) Delete(id interface{}) *MockUserDirectory_Delete_Call {
+	return &MockUserDirectory_Delete_Call{Call: _e.mock.On("Delete", id)}
+}
+
+func (_c *MockUserDirectory_Delete_Call) Run(run func(id string)) *MockUserDirectory_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserDirectory_Delete_Call) Return(err error) *MockUserDirectory_Delete_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockUserDirectory_Delete_Call) RunAndReturn(run func(id string) error) *MockUserDirectory_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function for the type MockUserDirectory
+func (_mock *MockUserDirectory) Get(id string) (directory.Entry, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 directory.Entry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (directory.Entry, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) directory.Entry); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Get(0).(directory.Entry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserDirectory_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockUserDirectory_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - id
+func (_e *MockUserDirectory_Expecter) Get(id interface{}) *MockUserDirectory_Get_Call {
+	return &MockUserDirectory_Get_Call{Call: _e.mock.On("Get", id)}
+}
+
+func (_c *MockUserDirectory_Get_Call) Run(run func(id string)) *MockUserDirectory_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserDirectory_Get_Call) Return(entry directory.Entry, err error) *MockUserDirectory_Get_Call {
+	_c.Call.Return(entry, err)
+	return _c
+}
+
+func (_c *MockUserDirectory_Get_Call) RunAndReturn(run func(id string) (directory.Entry, error)) *MockUserDirectory_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function for the type MockUserDirectory
+func (_mock *MockUserDirectory) List() []directory.Entry {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []directory.Entry
+	if returnFunc, ok := ret.Get(0).(func() []directory.Entry); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]directory.Entry)
+		}
+	}
+	return r0
+}
+
+// MockUserDirectory_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockUserDirectory_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+func (_e *MockUserDirectory_Expecter) List() *MockUserDirectory_List_Call {
+	return &MockUserDirectory_List_Call{Call: _e.mock.On("List")}
+}
+
+func (_c *MockUserDirectory_List_Call) Run(run func()) *MockUserDirectory_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockUserDirectory_List_Call) Return(entrys []directory.Entry) *MockUserDirectory_List_Call {
+	_c.Call.Return(entrys)
+	return _c
+}
+
+func (_c *MockUserDirectory_List_Call) RunAndReturn(run func() []directory.Entry) *MockUserDirectory_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Update provides a mock function for the type MockUserDirectory
+func (_mock *MockUserDirectory) Update(id string, fn func(*directory.Entry) error) (directory.Entry, error) {
+	ret := _mock.Called(id, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 directory.Entry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, func(*directory.Entry) error) (directory.Entry, error)); ok {
+		return returnFunc(id, fn)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, func(*directory.Entry) error) directory.Entry); ok {
+		r0 = returnFunc(id, fn)
+	} else {
+		r0 = ret.Get(0).(directory.Entry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, func(*directory.Entry) error) error); ok {
+		r1 = returnFunc(id, fn)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserDirectory_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type MockUserDirectory_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - id
+//   - fn
+func (_e *MockUserDirectory_Expecter) Update(id interface{}, fn interface{}) *MockUserDirectory_Update_Call {
+	return &MockUserDirectory_Update_Call{Call: _e.mock.On("Update", id, fn)}
+}
+
+func (_c *MockUserDirectory_Update_Call) Run(run func(id string, fn func(*directory.Entry) error)) *MockUserDirectory_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func(*directory.Entry) error))
+	})
+	return _c
+}
+
+func (_c *MockUserDirectory_Update_Call) Return(entry directory.Entry, err error) *MockUserDirectory_Update_Call {
+	_c.Call.Return(entry, err)
+	return _c
+}
+
+func (_c *MockUserDirectory_Update_Call) RunAndReturn(run func(id string, fn func(*directory.Entry) error) (directory.Entry, error)) *MockUserDirectory_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockUserGenerator creates a new instance of MockUserGenerator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockUserGenerator(t interface {
@@ -37,8 +1744,8 @@ func (_m *MockUserGenerator) EXPECT() *MockUserGenerator_Expecter {
 }
 
 // Generate provides a mock function for the type MockUserGenerator
-func (_mock *MockUserGenerator) Generate(results int, seed int64, page int, gender string) ([]model.User, error) {
-	ret := _mock.Called(results, seed, page, gender)
+func (_mock *MockUserGenerator) Generate(results int, seed int64, page int, opts generator.Options) ([]model.User, error) {
+	ret := _mock.Called(results, seed, page, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Generate")
@@ -46,18 +1753,18 @@ func (_mock *MockUserGenerator) Generate(results int, seed int64, page int, gend
 
 	var r0 []model.User
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(int, int64, int, string) ([]model.User, error)); ok {
-		return returnFunc(results, seed, page, gender)
+	if returnFunc, ok := ret.Get(0).(func(int, int64, int, generator.Options) ([]model.User, error)); ok {
+		return returnFunc(results, seed, page, opts)
 	}
-	if returnFunc, ok := ret.Get(0).(func(int, int64, int, string) []model.User); ok {
-		r0 = returnFunc(results, seed, page, gender)
+	if returnFunc, ok := ret.Get(0).(func(int, int64, int, generator.Options) []model.User); ok {
+		r0 = returnFunc(results, seed, page, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]model.User)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(int, int64, int, string) error); ok {
-		r1 = returnFunc(results, seed, page, gender)
+	if returnFunc, ok := ret.Get(1).(func(int, int64, int, generator.Options) error); ok {
+		r1 = returnFunc(results, seed, page, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -73,14 +1780,14 @@ type MockUserGenerator_Generate_Call struct {
 //   - results
 //   - seed
 //   - page
-//   - gender
-func (_e *MockUserGenerator_Expecter) Generate(results interface{}, seed interface{}, page interface{}, gender interface{}) *MockUserGenerator_Generate_Call {
-	return &MockUserGenerator_Generate_Call{Call: _e.mock.On("Generate", results, seed, page, gender)}
+//   - opts
+func (_e *MockUserGenerator_Expecter) Generate(results interface{}, seed interface{}, page interface{}, opts interface{}) *MockUserGenerator_Generate_Call {
+	return &MockUserGenerator_Generate_Call{Call: _e.mock.On("Generate", results, seed, page, opts)}
 }
 
-func (_c *MockUserGenerator_Generate_Call) Run(run func(results int, seed int64, page int, gender string)) *MockUserGenerator_Generate_Call {
+func (_c *MockUserGenerator_Generate_Call) Run(run func(results int, seed int64, page int, opts generator.Options)) *MockUserGenerator_Generate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(int), args[1].(int64), args[2].(int), args[3].(string))
+		run(args[0].(int), args[1].(int64), args[2].(int), args[3].(generator.Options))
 	})
 	return _c
 }
@@ -90,7 +1797,55 @@ func (_c *MockUserGenerator_Generate_Call) Return(users []model.User, err error)
 	return _c
 }
 
-func (_c *MockUserGenerator_Generate_Call) RunAndReturn(run func(results int, seed int64, page int, gender string) ([]model.User, error)) *MockUserGenerator_Generate_Call {
+func (_c *MockUserGenerator_Generate_Call) RunAndReturn(run func(results int, seed int64, page int, opts generator.Options) ([]model.User, error)) *MockUserGenerator_Generate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stream provides a mock function for the type MockUserGenerator
+func (_mock *MockUserGenerator) Stream(count int, seed int64, opts generator.Options, fn func(model.User) error) error {
+	ret := _mock.Called(count, seed, opts, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stream")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(int, int64, generator.Options, func(model.User) error) error); ok {
+		r0 = returnFunc(count, seed, opts, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserGenerator_Stream_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stream'
+type MockUserGenerator_Stream_Call struct {
+	*mock.Call
+}
+
+// Stream is a helper method to define mock.On call
+//   - count
+//   - seed
+//   - opts
+//   - fn
+func (_e *MockUserGenerator_Expecter) Stream(count interface{}, seed interface{}, opts interface{}, fn interface{}) *MockUserGenerator_Stream_Call {
+	return &MockUserGenerator_Stream_Call{Call: _e.mock.On("Stream", count, seed, opts, fn)}
+}
+
+func (_c *MockUserGenerator_Stream_Call) Run(run func(count int, seed int64, opts generator.Options, fn func(model.User) error)) *MockUserGenerator_Stream_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int64), args[2].(generator.Options), args[3].(func(model.User) error))
+	})
+	return _c
+}
+
+func (_c *MockUserGenerator_Stream_Call) Return(_a0 error) *MockUserGenerator_Stream_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserGenerator_Stream_Call) RunAndReturn(run func(count int, seed int64, opts generator.Options, fn func(model.User) error) error) *MockUserGenerator_Stream_Call {
 	_c.Call.Return(run)
 	return _c
 }
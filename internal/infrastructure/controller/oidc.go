@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/oidc"
+)
+
+// IdentityProvider は生成されたユーザーでログインできる OpenID Connect プロバイダーのインターフェース
+type IdentityProvider interface {
+	JWKS() oidc.JWKS
+	CheckClient(clientID, redirectURI string) error
+	Check(req oidc.AuthRequest) error
+	Authorize(req oidc.AuthRequest, username, password string) (string, error)
+	Token(req oidc.TokenRequest, issuer string) (oidc.TokenResponse, error)
+	UserInfo(accessToken, issuer string) (map[string]any, error)
+}
+
+// loginPage は認可エンドポイントのログインフォーム
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head><meta charset="utf-8"><title>ログイン</title></head>
+<body>
+<h1>ログイン</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post">
+{{range $name, $value := .Hidden}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}<label>ユーザー名 <input name="username" value="{{.Username}}" autocomplete="username" required></label>
+<label>パスワード <input name="password" type="password" autocomplete="current-password" required></label>
+<button type="submit">ログイン</button>
+</form>
+</body>
+</html>
+`))
+
+// OIDCDiscovery はディスカバリードキュメントを返す
+func OIDCDiscovery(c *gin.Context, issuer string) {
+	c.JSON(http.StatusOK, oidc.Discovery(oidcIssuer(c, issuer)))
+}
+
+// OIDCJWKS は署名の検証に使う公開鍵を返す
+func OIDCJWKS(c *gin.Context, p IdentityProvider) {
+	c.JSON(http.StatusOK, p.JWKS())
+}
+
+// OIDCAuthorize は認可リクエストを確かめてログインフォームを表示する
+func OIDCAuthorize(c *gin.Context, p IdentityProvider) {
+	req, ok := bindAuthRequest(c, p)
+	if !ok {
+		return
+	}
+	renderLogin(c, http.StatusOK, req, req.LoginHint, "")
+}
+
+// OIDCLogin はログインフォームのユーザー名とパスワードを確かめ、認可コードを付けてリダイレクトする
+func OIDCLogin(c *gin.Context, p IdentityProvider) {
+	req, ok := bindAuthRequest(c, p)
+	if !ok {
+		return
+	}
+	username := c.PostForm("username")
+	code, err := p.Authorize(req, username, c.PostForm("password"))
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidCredentials) {
+			renderLogin(c, http.StatusUnauthorized, req, username, err.Error())
+			return
+		}
+		redirectAuthError(c, req, err)
+		return
+	}
+	redirectWithParams(c, req.RedirectURI, url.Values{"code": {code}, "state": {req.State}})
+}
+
+// OIDCToken は認可コードまたはパスワードと引き換えにトークンを発行する
+func OIDCToken(c *gin.Context, p IdentityProvider, issuer string) {
+	var req oidc.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		oidcError(c, &oidc.Error{Status: http.StatusBadRequest, Code: "invalid_request", Description: err.Error()})
+		return
+	}
+	// client_secret_basic
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	res, err := p.Token(req, oidcIssuer(c, issuer))
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, res)
+}
+
+// OIDCUserInfo はアクセストークンのユーザーのクレームを返す
+func OIDCUserInfo(c *gin.Context, p IdentityProvider, issuer string) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		token = c.PostForm("access_token")
+	}
+	claims, err := p.UserInfo(token, oidcIssuer(c, issuer))
+	if err != nil {
+		var oe *oidc.Error
+		if errors.As(err, &oe) && oe.Status == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `Bearer error="`+oe.Code+`"`)
+		}
+		oidcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, claims)
+}
+
+// bindAuthRequest は認可リクエストを読み取って確かめる。
+// クライアントが不正な場合はエラーを表示し、それ以外の不正はリダイレクト先にエラーを返す
+func bindAuthRequest(c *gin.Context, p IdentityProvider) (oidc.AuthRequest, bool) {
+	var req oidc.AuthRequest
+	if err := c.ShouldBind(&req); err != nil {
+		oidcError(c, &oidc.Error{Status: http.StatusBadRequest, Code: "invalid_request", Description: err.Error()})
+		return req, false
+	}
+	if err := p.CheckClient(req.ClientID, req.RedirectURI); err != nil {
+		oidcError(c, err)
+		return req, false
+	}
+	if err := p.Check(req); err != nil {
+		redirectAuthError(c, req, err)
+		return req, false
+	}
+	return req, true
+}
+
+func renderLogin(c *gin.Context, status int, req oidc.AuthRequest, username, message string) {
+	hidden := map[string]string{
+		"response_type":         req.ResponseType,
+		"client_id":             req.ClientID,
+		"redirect_uri":          req.RedirectURI,
+		"scope":                 req.Scope,
+		"state":                 req.State,
+		"nonce":                 req.Nonce,
+		"code_challenge":        req.CodeChallenge,
+		"code_challenge_method": req.CodeChallengeMethod,
+	}
+	for name, value := range hidden {
+		if value == "" {
+			delete(hidden, name)
+		}
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := loginPage.Execute(c.Writer, gin.H{"Hidden": hidden, "Username": username, "Error": message}); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// redirectAuthError は認可リクエストのエラーをリダイレクト先に返す (RFC 6749 4.1.2.1)
+func redirectAuthError(c *gin.Context, req oidc.AuthRequest, err error) {
+	var oe *oidc.Error
+	if !errors.As(err, &oe) {
+		oe = &oidc.Error{Code: "server_error", Description: err.Error()}
+	}
+	redirectWithParams(c, req.RedirectURI, url.Values{
+		"error":             {oe.Code},
+		"error_description": {oe.Description},
+		"state":             {req.State},
+	})
+}
+
+func redirectWithParams(c *gin.Context, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		oidcError(c, &oidc.Error{Status: http.StatusBadRequest, Code: "invalid_request", Description: "redirect_uri が不正です"})
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		if v[0] != "" {
+			q[k] = v
+		}
+	}
+	u.RawQuery = q.Encode()
+	c.Redirect(http.StatusFound, u.String())
+}
+
+// oidcError はエラーを OAuth 2.0 のエラーレスポンスにする
+func oidcError(c *gin.Context, err error) {
+	var oe *oidc.Error
+	if !errors.As(err, &oe) {
+		oe = &oidc.Error{Status: http.StatusInternalServerError, Code: "server_error", Description: err.Error()}
+	}
+	c.AbortWithStatusJSON(oe.Status, gin.H{"error": oe.Code, "error_description": oe.Description})
+}
+
+// oidcIssuer は issuer を返す。設定されていない場合はリクエストのホストの /oidc にする
+func oidcIssuer(c *gin.Context, issuer string) string {
+	if issuer != "" {
+		return strings.TrimSuffix(issuer, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + "/oidc"
+}
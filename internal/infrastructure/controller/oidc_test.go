@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOIDC(t *testing.T) {
+	authQuery := "response_type=code&client_id=app&redirect_uri=" + url.QueryEscape("http://localhost:3000/cb") + "&scope=openid&state=xyz"
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		body             string
+		header           map[string]string
+		setUpMock        func(*MockIdentityProvider)
+		expectedStatus   int
+		expectedBody     []string
+		expectedLocation string
+	}{
+		{
+			name:           "ディスカバリードキュメント",
+			method:         "GET",
+			path:           "/oidc/.well-known/openid-configuration",
+			setUpMock:      func(m *MockIdentityProvider) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"issuer":"http://example.com/oidc"`, `"jwks_uri":"http://example.com/oidc/jwks"`},
+		},
+		{
+			name:   "ログインフォーム",
+			method: "GET",
+			path:   "/oidc/authorize?" + authQuery + "&login_hint=alice",
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().CheckClient("app", "http://localhost:3000/cb").Return(nil)
+				m.EXPECT().Check(mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`name="state" value="xyz"`, `name="username" value="alice"`},
+		},
+		{
+			name:   "未登録のクライアント",
+			method: "GET",
+			path:   "/oidc/authorize?" + authQuery,
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().CheckClient("app", "http://localhost:3000/cb").Return(&oidc.Error{Status: http.StatusBadRequest, Code: "invalid_client"})
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   []string{`"error":"invalid_client"`},
+		},
+		{
+			name:   "不正な認可リクエストはリダイレクト先に返す",
+			method: "GET",
+			path:   "/oidc/authorize?" + authQuery,
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().CheckClient("app", "http://localhost:3000/cb").Return(nil)
+				m.EXPECT().Check(mock.Anything).Return(&oidc.Error{Status: http.StatusBadRequest, Code: "invalid_scope", Description: "x"})
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "http://localhost:3000/cb?error=invalid_scope&error_description=x&state=xyz",
+		},
+		{
+			name:   "ログインに成功",
+			method: "POST",
+			path:   "/oidc/authorize",
+			body:   authQuery + "&username=alice&password=secret",
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().CheckClient("app", "http://localhost:3000/cb").Return(nil)
+				m.EXPECT().Check(mock.Anything).Return(nil)
+				m.EXPECT().Authorize(mock.MatchedBy(func(req oidc.AuthRequest) bool { return req.State == "xyz" }), "alice", "secret").Return("abc", nil)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "http://localhost:3000/cb?code=abc&state=xyz",
+		},
+		{
+			name:   "パスワードが不正",
+			method: "POST",
+			path:   "/oidc/authorize",
+			body:   authQuery + "&username=alice&password=wrong",
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().CheckClient("app", "http://localhost:3000/cb").Return(nil)
+				m.EXPECT().Check(mock.Anything).Return(nil)
+				m.EXPECT().Authorize(mock.Anything, "alice", "wrong").Return("", oidc.ErrInvalidCredentials)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   []string{`role="alert"`},
+		},
+		{
+			name:   "Basic 認証のクライアントでトークンを発行",
+			method: "POST",
+			path:   "/oidc/token",
+			body:   "grant_type=authorization_code&code=abc&redirect_uri=" + url.QueryEscape("http://localhost:3000/cb"),
+			header: map[string]string{"Authorization": "Basic YXBwOnMzY3JldA=="},
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().Token(oidc.TokenRequest{
+					GrantType:    "authorization_code",
+					Code:         "abc",
+					RedirectURI:  "http://localhost:3000/cb",
+					ClientID:     "app",
+					ClientSecret: "s3cret",
+				}, "http://example.com/oidc").Return(oidc.TokenResponse{AccessToken: "at", TokenType: "Bearer", ExpiresIn: 3600, IDToken: "id"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"access_token":"at"`, `"id_token":"id"`},
+		},
+		{
+			name:   "トークンの発行に失敗",
+			method: "POST",
+			path:   "/oidc/token",
+			body:   "grant_type=password&username=alice&password=wrong",
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().Token(mock.Anything, "http://example.com/oidc").Return(oidc.TokenResponse{}, &oidc.Error{Status: http.StatusBadRequest, Code: "invalid_grant"})
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   []string{`"error":"invalid_grant"`},
+		},
+		{
+			name:   "ユーザー情報",
+			method: "GET",
+			path:   "/oidc/userinfo",
+			header: map[string]string{"Authorization": "Bearer at"},
+			setUpMock: func(m *MockIdentityProvider) {
+				m.EXPECT().UserInfo("at", "http://example.com/oidc").Return(map[string]any{"sub": "a1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"sub":"a1"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockProvider := NewMockIdentityProvider(t)
+			tt.setUpMock(mockProvider)
+
+			group := r.Group("/oidc")
+			group.GET("/.well-known/openid-configuration", func(c *gin.Context) { OIDCDiscovery(c, "") })
+			group.GET("/authorize", func(c *gin.Context) { OIDCAuthorize(c, mockProvider) })
+			group.POST("/authorize", func(c *gin.Context) { OIDCLogin(c, mockProvider) })
+			group.POST("/token", func(c *gin.Context) { OIDCToken(c, mockProvider, "") })
+			group.GET("/userinfo", func(c *gin.Context) { OIDCUserInfo(c, mockProvider, "") })
+
+			req, _ := http.NewRequest(tt.method, "http://example.com"+tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, s := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), s)
+			}
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// 組織図の既定の従業員数と階層の深さ
+const (
+	defaultOrgSize  = 100
+	defaultOrgDepth = 5
+)
+
+// OrganizationGenerator は組織図生成インターフェース
+type OrganizationGenerator interface {
+	GenerateOrganization(size, depth int, seed int64, opts generator.Options) (model.Organization, error)
+}
+
+type orgResponse struct {
+	Name      string       `json:"name"`
+	Domain    string       `json:"domain"`
+	Employees []model.User `json:"employees,omitempty"`
+	// Root は view=tree の場合の社長から始まる組織図
+	Root *orgNode `json:"root,omitempty"`
+	Info orgInfo  `json:"info"`
+}
+
+type orgInfo struct {
+	Seed  string `json:"seed"`
+	Size  int    `json:"size"`
+	Depth int    `json:"depth"`
+	Page  int    `json:"page"`
+}
+
+// orgNode は従業員と直属の部下
+type orgNode struct {
+	model.User
+	Reports []*orgNode `json:"reports,omitempty"`
+}
+
+// MarshalJSON は従業員の項目の後に reports を加える。
+// 埋め込んだ model.User の MarshalJSON がそのままでは使われ、reports が書き出されないため
+func (n orgNode) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(n.User)
+	if err != nil || len(n.Reports) == 0 {
+		return b, err
+	}
+	reports, err := json.Marshal(n.Reports)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b[:len(b)-1], `,"reports":`...)
+	return append(append(b, reports...), '}'), nil
+}
+
+// GenerateOrganization は会社の組織図を生成する。view=tree の場合は入れ子の木で、それ以外は一覧で返す
+func GenerateOrganization(c *gin.Context, gen OrganizationGenerator, cfg *config.Config) {
+	seed, page := seedAndPage(c)
+
+	size := queryInt(c, "size", defaultOrgSize)
+	if size < 1 || size > cfg.MaxResults {
+		size = min(defaultOrgSize, cfg.MaxResults)
+	}
+	depth := queryInt(c, "depth", defaultOrgDepth)
+
+	view := c.DefaultQuery("view", "flat")
+	if view != "flat" && view != "tree" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view は flat か tree を指定してください"})
+		return
+	}
+
+	opts, err := queryOptions(c, cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := gen.GenerateOrganization(size, depth, seed, opts)
+	if errors.Is(err, generator.ErrInvalidOptions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := orgResponse{
+		Name:   org.Name,
+		Domain: org.Domain,
+		Info: orgInfo{
+			Seed:  strconv.FormatInt(seed, 10),
+			Size:  len(org.Employees),
+			Depth: depth,
+			Page:  page,
+		},
+	}
+	if view == "tree" {
+		res.Root = orgTree(org.Employees)
+	} else {
+		res.Employees = org.Employees
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// orgTree は上司の EmployeeID をたどって従業員を木にする。上司のいない最初の従業員を根にする
+func orgTree(employees []model.User) *orgNode {
+	nodes := make(map[string]*orgNode, len(employees))
+	var root *orgNode
+	for _, u := range employees {
+		n := &orgNode{User: u}
+		if u.Employment == nil {
+			continue
+		}
+		nodes[u.Employment.EmployeeID] = n
+		if m, ok := nodes[u.Employment.ManagerID]; ok {
+			m.Reports = append(m.Reports, n)
+		} else if root == nil {
+			root = n
+		}
+	}
+	return root
+}
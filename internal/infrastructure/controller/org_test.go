@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateOrganization(t *testing.T) {
+	cfg := &config.Config{MaxResults: 500}
+	org := model.Organization{
+		Name:   "Smith Inc.",
+		Domain: "smith.example",
+		Employees: []model.User{
+			{Employment: &model.Employment{EmployeeID: "E00001"}},
+			{Employment: &model.Employment{EmployeeID: "E00002", ManagerID: "E00001"}},
+			{Employment: &model.Employment{EmployeeID: "E00003", ManagerID: "E00002"}},
+			{Employment: &model.Employment{EmployeeID: "E00004", ManagerID: "E00001"}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		setUpMock      func(*MockOrganizationGenerator)
+		expectedStatus int
+		expectedInfo   orgInfo
+		tree           bool
+	}{
+		{
+			name:  "一覧",
+			query: "?size=4&depth=3&seed=10",
+			setUpMock: func(m *MockOrganizationGenerator) {
+				m.EXPECT().GenerateOrganization(4, 3, int64(11), generator.Options{}).Return(org, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   orgInfo{Seed: "11", Size: 4, Depth: 3, Page: 1},
+		},
+		{
+			name:  "木",
+			query: "?seed=10&view=tree&nat=gb",
+			setUpMock: func(m *MockOrganizationGenerator) {
+				m.EXPECT().GenerateOrganization(defaultOrgSize, defaultOrgDepth, int64(11), generator.Options{Nat: "gb"}).Return(org, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   orgInfo{Seed: "11", Size: 4, Depth: defaultOrgDepth, Page: 1},
+			tree:           true,
+		},
+		{
+			name:  "基準日",
+			query: "?size=4&depth=3&seed=10&asOf=2030-06-15&city=Houston",
+			setUpMock: func(m *MockOrganizationGenerator) {
+				m.EXPECT().GenerateOrganization(4, 3, int64(11), generator.Options{
+					AsOf: time.Date(2030, 6, 15, 0, 0, 0, 0, time.UTC),
+					City: "Houston",
+				}).Return(org, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   orgInfo{Seed: "11", Size: 4, Depth: 3, Page: 1},
+		},
+		{
+			name:           "不正な基準日",
+			query:          "?asOf=15-06-2030",
+			setUpMock:      func(m *MockOrganizationGenerator) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "未対応の表示形式",
+			query:          "?view=chart",
+			setUpMock:      func(m *MockOrganizationGenerator) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "不正な階層の深さ",
+			query: "?seed=1&depth=1",
+			setUpMock: func(m *MockOrganizationGenerator) {
+				m.EXPECT().GenerateOrganization(defaultOrgSize, 1, int64(2), generator.Options{}).Return(model.Organization{}, generator.ErrInvalidOptions)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockGen := NewMockOrganizationGenerator(t)
+			tt.setUpMock(mockGen)
+
+			r.GET("/api/orgs", func(c *gin.Context) {
+				GenerateOrganization(c, mockGen, cfg)
+			})
+
+			req, _ := http.NewRequest("GET", "/api/orgs"+tt.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var res orgResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedInfo, res.Info)
+			if !tt.tree {
+				assert.Len(t, res.Employees, 4)
+				assert.Nil(t, res.Root)
+				return
+			}
+			assert.Empty(t, res.Employees)
+			require.NotNil(t, res.Root)
+			assert.Equal(t, "E00001", res.Root.Employment.EmployeeID)
+			require.Len(t, res.Root.Reports, 2)
+			assert.Equal(t, "E00003", res.Root.Reports[0].Reports[0].Employment.EmployeeID)
+		})
+	}
+}
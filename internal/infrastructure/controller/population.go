@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// PopulationGenerator は母集団の指定からのユーザー生成インターフェース
+type PopulationGenerator interface {
+	GeneratePopulation(p generator.Population, seed int64, opts generator.Options) ([]model.User, error)
+	StreamPopulation(p generator.Population, seed int64, opts generator.Options, fn func(model.User) error) error
+}
+
+// GeneratePopulation は JSON または YAML の本文で指定した母集団のユーザーを生成する。
+// 集団ごとの人数は重みどおりになり、クエリの条件は全集団に共通の条件になる
+func GeneratePopulation(c *gin.Context, gen PopulationGenerator, cfg *config.Config) {
+	seed, page := seedAndPage(c)
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	spec, err := generator.ParsePopulation(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if spec.Size > cfg.MaxResults {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("母集団の人数は %d 以下で指定してください: %d", cfg.MaxResults, spec.Size)})
+		return
+	}
+
+	opts, err := queryOptions(c, cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	enc, format, err := queryEncoder(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 書き出したファイルの顔写真は期限の切れない転送用の URL にする
+	if enc != nil {
+		opts.PortraitBaseURL = cfg.PortraitBaseURL
+		writeExport(c, enc, format, func(fn func(model.User) error) error {
+			return gen.StreamPopulation(spec, seed, opts, fn)
+		})
+		return
+	}
+
+	output, err := gen.GeneratePopulation(spec, seed, opts)
+	if errors.Is(err, generator.ErrInvalidOptions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, userResponse{
+		Results: output,
+		Info: info{
+			Seed:    strconv.FormatInt(seed, 10),
+			Results: len(output),
+			Page:    page,
+		},
+	})
+}
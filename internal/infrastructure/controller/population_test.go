@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGeneratePopulation(t *testing.T) {
+	cfg := &config.Config{MaxResults: 50}
+	age := func(n int) *int { return &n }
+	spec := generator.Population{
+		Size: 3,
+		Cohorts: []generator.Cohort{
+			{Name: "us-young", Weight: 60, Nat: "US", MinAge: age(18), MaxAge: age(34)},
+			{Name: "jp", Weight: 40, Nat: "JP", MinAge: age(35), MaxAge: age(60)},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		query          string
+		setUpMock      func(*MockPopulationGenerator)
+		expectedStatus int
+		expectedInfo   info
+	}{
+		{
+			name:  "JSON の指定",
+			body:  `{"size":3,"cohorts":[{"name":"us-young","weight":60,"nat":"US","minAge":18,"maxAge":34},{"name":"jp","weight":40,"nat":"JP","minAge":35,"maxAge":60}]}`,
+			query: "?seed=10",
+			setUpMock: func(m *MockPopulationGenerator) {
+				m.EXPECT().GeneratePopulation(spec, int64(11), generator.Options{}).Return([]model.User{
+					{Cohort: "us-young"}, {Cohort: "jp"}, {Cohort: "us-young"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   info{Seed: "11", Results: 3, Page: 1},
+		},
+		{
+			name: "YAML の指定",
+			body: strings.Join([]string{
+				"size: 3",
+				"cohorts:",
+				"  - {name: us-young, weight: 60, nat: US, minAge: 18, maxAge: 34}",
+				"  - {name: jp, weight: 40, nat: JP, minAge: 35, maxAge: 60}",
+			}, "\n"),
+			query: "?seed=10&profile=edge",
+			setUpMock: func(m *MockPopulationGenerator) {
+				m.EXPECT().GeneratePopulation(spec, int64(11), generator.Options{Profile: generator.ProfileEdge}).Return([]model.User{{}, {}, {}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedInfo:   info{Seed: "11", Results: 3, Page: 1},
+		},
+		{
+			name:           "未知の項目",
+			body:           `{"size":3,"cohorts":[{"weight":1,"age":30}]}`,
+			setUpMock:      func(m *MockPopulationGenerator) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "上限を超える人数",
+			body:           `{"size":51,"cohorts":[{"weight":1}]}`,
+			setUpMock:      func(m *MockPopulationGenerator) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "不正な集団の条件",
+			body:  `{"size":1,"cohorts":[{"weight":1,"nat":"XX"}]}`,
+			query: "?seed=1",
+			setUpMock: func(m *MockPopulationGenerator) {
+				m.EXPECT().GeneratePopulation(generator.Population{Size: 1, Cohorts: []generator.Cohort{{Weight: 1, Nat: "XX"}}}, int64(2), generator.Options{}).Return(nil, generator.ErrInvalidOptions)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockGen := NewMockPopulationGenerator(t)
+			tt.setUpMock(mockGen)
+
+			r.POST("/api/population", func(c *gin.Context) {
+				GeneratePopulation(c, mockGen, cfg)
+			})
+
+			req, _ := http.NewRequest("POST", "/api/population"+tt.query, strings.NewReader(tt.body))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var res userResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, tt.expectedInfo, res.Info)
+			}
+		})
+	}
+}
+
+func TestGeneratePopulationExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	_, r := gin.CreateTestContext(w)
+
+	// 書き出し形式では母集団を生成しながらエンコーダーに渡す
+	mockGen := NewMockPopulationGenerator(t)
+	spec := generator.Population{Size: 2, Cohorts: []generator.Cohort{{Name: "all", Weight: 1}}}
+	mockGen.EXPECT().StreamPopulation(spec, int64(2), generator.Options{}, mock.Anything).RunAndReturn(
+		func(_ generator.Population, _ int64, _ generator.Options, fn func(model.User) error) error {
+			for _, first := range []string{"Alice", "Bob"} {
+				if err := fn(model.User{Name: model.Name{First: first}, Cohort: "all"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	r.POST("/api/population", func(c *gin.Context) {
+		GeneratePopulation(c, mockGen, &config.Config{MaxResults: 50})
+	})
+	req, _ := http.NewRequest("POST", "/api/population?seed=1&format=csv", strings.NewReader(`{"size":2,"cohorts":[{"name":"all","weight":1}]}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), ",Alice,")
+	assert.Contains(t, w.Body.String(), ",Bob,")
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+)
+
+// PortraitSigner は顔写真の署名付き URL を作るインターフェース
+type PortraitSigner interface {
+	SignPortrait(key string) (string, error)
+}
+
+// RedirectPortrait は顔写真のキーをその場で作った署名付き URL に転送する。
+// ディレクトリや書き出したファイルにはこのエンドポイントの URL を残し、署名の期限切れで顔写真が見えなくならないようにする
+func RedirectPortrait(c *gin.Context, signer PortraitSigner) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	url, err := signer.SignPortrait(key)
+	if errors.Is(err, generator.ErrUnknownPortrait) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 署名付き URL は期限があるため、転送先をキャッシュさせない
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectPortrait(t *testing.T) {
+	tests := []struct {
+		name             string
+		path             string
+		setUpMock        func(*MockPortraitSigner)
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name: "署名付き URL に転送",
+			path: "/portraits/male/30-44/US/portrait%20(3).png",
+			setUpMock: func(m *MockPortraitSigner) {
+				m.EXPECT().SignPortrait("male/30-44/US/portrait (3).png").Return("https://bucket.example.com/signed", nil)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://bucket.example.com/signed",
+		},
+		{
+			name: "索引に無いキー",
+			path: "/portraits/secret.txt",
+			setUpMock: func(m *MockPortraitSigner) {
+				m.EXPECT().SignPortrait("secret.txt").Return("", generator.ErrUnknownPortrait)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "署名に失敗",
+			path: "/portraits/female/portrait%20(1).png",
+			setUpMock: func(m *MockPortraitSigner) {
+				m.EXPECT().SignPortrait("female/portrait (1).png").Return("", assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockSigner := NewMockPortraitSigner(t)
+			tt.setUpMock(mockSigner)
+
+			r.GET("/portraits/*key", func(c *gin.Context) {
+				RedirectPortrait(c, mockSigner)
+			})
+
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+		})
+	}
+}
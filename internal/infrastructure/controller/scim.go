@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/scim"
+)
+
+// UserDirectory はシードから決まる母集団に変更を重ねたユーザーのディレクトリのインターフェース
+type UserDirectory interface {
+	List() []directory.Entry
+	Get(id string) (directory.Entry, error)
+	Create(e directory.Entry) (directory.Entry, error)
+	Update(id string, fn func(*directory.Entry) error) (directory.Entry, error)
+	Delete(id string) error
+}
+
+// ScimListUsers は filter に一致するユーザーを startIndex と count でページ分けして返す
+func ScimListUsers(c *gin.Context, dir UserDirectory) {
+	var filter scim.Filter
+	if s := c.Query("filter"); s != "" {
+		f, err := scim.ParseFilter(s)
+		if err != nil {
+			scimError(c, err)
+			return
+		}
+		filter = f
+	}
+
+	startIndex := queryInt(c, "startIndex", 1)
+	count := queryInt(c, "count", scim.DefaultCount)
+
+	res, err := scim.List(dir.List(), filter, startIndex, count, scimBaseURL(c))
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, res)
+}
+
+// ScimGetUser は ID のユーザーを返す。If-None-Match が現在の ETag と一致する場合は 304 を返す
+func ScimGetUser(c *gin.Context, dir UserDirectory) {
+	e, err := dir.Get(c.Param("id"))
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, scim.Version(e)) {
+		c.Header("ETag", scim.Version(e))
+		c.Status(http.StatusNotModified)
+		return
+	}
+	scimUser(c, http.StatusOK, e)
+}
+
+// ScimCreateUser はユーザーを追加する
+func ScimCreateUser(c *gin.Context, dir UserDirectory) {
+	r, ok := bindScimUser(c)
+	if !ok {
+		return
+	}
+	e := directory.Entry{Active: true}
+	scim.Apply(r, &e)
+
+	created, err := dir.Create(e)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	c.Header("Location", scim.FromEntry(created, scimBaseURL(c)).Meta.Location)
+	scimUser(c, http.StatusCreated, created)
+}
+
+// ScimReplaceUser はユーザーの属性をリクエストの内容で置き換える
+func ScimReplaceUser(c *gin.Context, dir UserDirectory) {
+	r, ok := bindScimUser(c)
+	if !ok {
+		return
+	}
+	updated, err := dir.Update(c.Param("id"), func(e *directory.Entry) error {
+		if err := checkIfMatch(c, *e); err != nil {
+			return err
+		}
+		scim.Apply(r, e)
+		return nil
+	})
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimUser(c, http.StatusOK, updated)
+}
+
+// ScimPatchUser はユーザーに PATCH の操作を適用する
+func ScimPatchUser(c *gin.Context, dir UserDirectory) {
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, &scim.Error{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: err.Error()})
+		return
+	}
+	updated, err := dir.Update(c.Param("id"), func(e *directory.Entry) error {
+		if err := checkIfMatch(c, *e); err != nil {
+			return err
+		}
+		return scim.Patch(e, req.Operations)
+	})
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimUser(c, http.StatusOK, updated)
+}
+
+// ScimDeleteUser はユーザーを削除する
+func ScimDeleteUser(c *gin.Context, dir UserDirectory) {
+	if c.GetHeader("If-Match") != "" {
+		e, err := dir.Get(c.Param("id"))
+		if err == nil {
+			err = checkIfMatch(c, e)
+		}
+		if err != nil {
+			scimError(c, err)
+			return
+		}
+	}
+	if err := dir.Delete(c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ScimServiceProviderConfig は対応している機能を返す
+func ScimServiceProviderConfig(c *gin.Context) {
+	supported := func(ok bool) gin.H { return gin.H{"supported": ok} }
+	scimJSON(c, http.StatusOK, gin.H{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          supported(true),
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": scim.MaxCount},
+		"changePassword": supported(true),
+		"sort":           supported(false),
+		"etag":           supported(true),
+
+		// 認証はしないため、認証方式は空
+		"authenticationSchemes": []gin.H{},
+		"meta":                  gin.H{"resourceType": "ServiceProviderConfig", "location": scimBaseURL(c) + "/ServiceProviderConfig"},
+	})
+}
+
+// ScimResourceTypes は対応しているリソースの種類を返す
+func ScimResourceTypes(c *gin.Context) {
+	scimJSON(c, http.StatusOK, gin.H{
+		"schemas":      []string{scim.SchemaListResponse},
+		"totalResults": 1,
+		"Resources": []gin.H{{
+			"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   scim.SchemaUser,
+			"schemaExtensions": []gin.H{
+				{"schema": scim.SchemaEnterpriseUser, "required": false},
+			},
+			"meta": gin.H{"resourceType": "ResourceType", "location": scimBaseURL(c) + "/ResourceTypes/User"},
+		}},
+	})
+}
+
+// checkIfMatch は If-Match が指定されていて現在の ETag と一致しない場合にエラーを返す
+func checkIfMatch(c *gin.Context, e directory.Entry) error {
+	if im := c.GetHeader("If-Match"); im != "" && !etagMatches(im, scim.Version(e)) {
+		return &scim.Error{Status: http.StatusPreconditionFailed, Detail: "ETag が一致しません: " + im}
+	}
+	return nil
+}
+
+// etagMatches は If-Match または If-None-Match の ETag の一覧に etag が含まれるかを返す。"*" はすべてに一致する
+func etagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		if v = strings.TrimSpace(v); v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func bindScimUser(c *gin.Context) (scim.User, bool) {
+	var r scim.User
+	if err := c.ShouldBindJSON(&r); err != nil {
+		scimError(c, &scim.Error{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: err.Error()})
+		return r, false
+	}
+	if strings.TrimSpace(r.UserName) == "" {
+		scimError(c, &scim.Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: "userName は必須です"})
+		return r, false
+	}
+	return r, true
+}
+
+func scimUser(c *gin.Context, status int, e directory.Entry) {
+	c.Header("ETag", scim.Version(e))
+	scimJSON(c, status, scim.FromEntry(e, scimBaseURL(c)))
+}
+
+func scimJSON(c *gin.Context, status int, body any) {
+	c.Header("Content-Type", scim.ContentType)
+	c.JSON(status, body)
+}
+
+// scimError はエラーを SCIM のエラーレスポンスにする
+func scimError(c *gin.Context, err error) {
+	var se *scim.Error
+	switch {
+	case errors.As(err, &se):
+	case errors.Is(err, directory.ErrNotFound):
+		se = &scim.Error{Status: http.StatusNotFound, Detail: err.Error()}
+	case errors.Is(err, directory.ErrConflict):
+		se = &scim.Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: err.Error()}
+	default:
+		se = &scim.Error{Status: http.StatusInternalServerError, Detail: err.Error()}
+	}
+	c.Abort()
+	scimJSON(c, se.Status, se.Response())
+}
+
+// scimBaseURL は SCIM のエンドポイントの URL を返す。/Users より前の部分になる
+func scimBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	path := c.Request.URL.Path
+	if i := strings.Index(path, "/Users"); i >= 0 {
+		path = path[:i]
+	} else if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[:i]
+	}
+	return scheme + "://" + c.Request.Host + path
+}
+
+func queryInt(c *gin.Context, key string, def int) int {
+	n, err := strconv.Atoi(c.Query(key))
+	if err != nil {
+		return def
+	}
+	return n
+}
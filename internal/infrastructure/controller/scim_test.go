@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScimUsers(t *testing.T) {
+	alice := directory.Entry{
+		ID:     "a1",
+		Active: true,
+		User:   model.User{Login: model.Login{Username: "alice"}, Email: "alice@example.com"},
+		Meta:   directory.Meta{Version: 1},
+	}
+	bob := directory.Entry{
+		ID:     "b2",
+		Active: true,
+		User:   model.User{Login: model.Login{Username: "bob"}},
+		Meta:   directory.Meta{Version: 2},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		header         http.Header
+		setUpMock      func(*MockUserDirectory)
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:   "フィルターで一覧",
+			method: "GET",
+			path:   `/scim/v2/Users?filter=userName+eq+"BOB"`,
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().List().Return([]directory.Entry{alice, bob})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"totalResults":1`, `"userName":"bob"`, `"location":"http://example.com/scim/v2/Users/b2"`},
+		},
+		{
+			name:           "不正なフィルター",
+			method:         "GET",
+			path:           `/scim/v2/Users?filter=userName+eq`,
+			setUpMock:      func(m *MockUserDirectory) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   []string{`"scimType":"invalidFilter"`, `"status":"400"`},
+		},
+		{
+			name:   "存在しないユーザー",
+			method: "GET",
+			path:   "/scim/v2/Users/missing",
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Get("missing").Return(directory.Entry{}, directory.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   []string{`"status":"404"`},
+		},
+		{
+			name:   "ユーザーを追加",
+			method: "POST",
+			path:   "/scim/v2/Users",
+			body:   `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"carol","emails":[{"value":"carol@example.com","primary":true}]}`,
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Create(mock.MatchedBy(func(e directory.Entry) bool {
+					return e.Active && e.User.Login.Username == "carol" && e.User.Email == "carol@example.com"
+				})).RunAndReturn(func(e directory.Entry) (directory.Entry, error) {
+					e.ID = "c3"
+					e.Meta.Version = 1
+					return e, nil
+				})
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   []string{`"id":"c3"`, `"userName":"carol"`},
+		},
+		{
+			name:   "userName が重複",
+			method: "POST",
+			path:   "/scim/v2/Users",
+			body:   `{"userName":"Alice"}`,
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Create(mock.Anything).Return(directory.Entry{}, directory.ErrConflict)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   []string{`"scimType":"uniqueness"`},
+		},
+		{
+			name:           "userName が無い",
+			method:         "POST",
+			path:           "/scim/v2/Users",
+			body:           `{"name":{"givenName":"Dave"}}`,
+			setUpMock:      func(m *MockUserDirectory) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   []string{`"scimType":"invalidValue"`},
+		},
+		{
+			name:   "PATCH で無効化",
+			method: "PATCH",
+			path:   "/scim/v2/Users/a1",
+			body:   `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"Replace","path":"active","value":"False"}]}`,
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Update("a1", mock.Anything).RunAndReturn(func(id string, fn func(*directory.Entry) error) (directory.Entry, error) {
+					e := alice
+					if err := fn(&e); err != nil {
+						return directory.Entry{}, err
+					}
+					e.Meta.Version++
+					return e, nil
+				})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"active":false`, `"version":"W/\"2\""`},
+		},
+		{
+			name:   "ETag が一致すれば 304",
+			method: "GET",
+			path:   "/scim/v2/Users/a1",
+			header: http.Header{"If-None-Match": {`W/"1"`}},
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Get("a1").Return(alice, nil)
+			},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:   "ETag が一致しなければ PATCH しない",
+			method: "PATCH",
+			path:   "/scim/v2/Users/a1",
+			body:   `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"replace","path":"active","value":false}]}`,
+			header: http.Header{"If-Match": {`W/"0"`}},
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Update("a1", mock.Anything).RunAndReturn(func(id string, fn func(*directory.Entry) error) (directory.Entry, error) {
+					e := alice
+					return directory.Entry{}, fn(&e)
+				})
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   []string{`"status":"412"`},
+		},
+		{
+			name:   "ETag が一致しなければ削除しない",
+			method: "DELETE",
+			path:   "/scim/v2/Users/b2",
+			header: http.Header{"If-Match": {`W/"1"`}},
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Get("b2").Return(bob, nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "ETag が一致すれば削除",
+			method: "DELETE",
+			path:   "/scim/v2/Users/b2",
+			header: http.Header{"If-Match": {`W/"1", W/"2"`}},
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Get("b2").Return(bob, nil)
+				m.EXPECT().Delete("b2").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "対応している機能",
+			method:         "GET",
+			path:           "/scim/v2/ServiceProviderConfig",
+			setUpMock:      func(m *MockUserDirectory) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"etag":{"supported":true}`, `"authenticationSchemes":[]`},
+		},
+		{
+			name:   "ユーザーを削除",
+			method: "DELETE",
+			path:   "/scim/v2/Users/b2",
+			setUpMock: func(m *MockUserDirectory) {
+				m.EXPECT().Delete("b2").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			mockDirectory := NewMockUserDirectory(t)
+			tt.setUpMock(mockDirectory)
+
+			group := r.Group("/scim/v2")
+			group.GET("/Users", func(c *gin.Context) { ScimListUsers(c, mockDirectory) })
+			group.POST("/Users", func(c *gin.Context) { ScimCreateUser(c, mockDirectory) })
+			group.GET("/Users/:id", func(c *gin.Context) { ScimGetUser(c, mockDirectory) })
+			group.PATCH("/Users/:id", func(c *gin.Context) { ScimPatchUser(c, mockDirectory) })
+			group.DELETE("/Users/:id", func(c *gin.Context) { ScimDeleteUser(c, mockDirectory) })
+			group.GET("/ServiceProviderConfig", ScimServiceProviderConfig)
+
+			req, _ := http.NewRequest(tt.method, "http://example.com"+tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, s := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), s)
+			}
+			if w.Code != http.StatusNoContent && w.Code != http.StatusNotModified {
+				assert.Equal(t, "application/scim+json; charset=utf-8", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
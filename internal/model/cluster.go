@@ -0,0 +1,11 @@
+package model
+
+// Cluster は近似重複の生成で、同じ人物を表すレコードのまとまり
+type Cluster struct {
+	// ID はまとまりの識別子。元のユーザーの Login.UUID
+	ID string `json:"id"`
+	// Variant は元のユーザーが 0、変形したレコードが 1 からの番号
+	Variant int `json:"variant"`
+	// Perturbations は元のユーザーから加えた変更 (typo, nickname など)
+	Perturbations []string `json:"perturbations,omitempty"`
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Corruption は dirty モードでユーザーに加えた1件の破損
+type Corruption struct {
+	// Field は破損させた項目の json 名を "." で連結したもの (例: dob.age)
+	Field string `json:"field"`
+	// Kind は破損の種類 (missing, wrong_type, malformed など)
+	Kind string `json:"kind"`
+	// Original は破損させる前の値
+	Original any `json:"original"`
+	// Value は JSON に書き出す破損後の値。nil の場合は項目を書き出さない
+	Value any `json:"value,omitempty"`
+}
+
+// jsonUser は MarshalJSON を持たない User
+type jsonUser User
+
+// MarshalJSON は破損がある場合、Corruptions の値で項目を差し替える。
+// 型の異なる値や項目の欠落は構造体では表せないため、JSON に書き出す時に反映する
+func (u User) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(jsonUser(u))
+	if err != nil || len(u.Corruptions) == 0 {
+		return b, err
+	}
+	for _, c := range u.Corruptions {
+		if b, err = patchJSON(b, strings.Split(c.Field, "."), c.Value); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// patchJSON は JSON のオブジェクト b の path の値を value に差し替える。value が nil の場合は削除する。
+// キーの順序は保つ
+func patchJSON(b []byte, path []string, value any) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	first := true
+	write := func(key string, raw []byte) {
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+		k, _ := json.Marshal(key)
+		out.Write(k)
+		out.WriteByte(':')
+		out.Write(raw)
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := t.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		switch {
+		case key != path[0]:
+			write(key, raw)
+		case len(path) > 1:
+			patched, err := patchJSON(raw, path[1:], value)
+			if err != nil {
+				return nil, err
+			}
+			write(key, patched)
+		case value != nil:
+			v, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			write(key, v)
+		}
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
@@ -0,0 +1,18 @@
+package model
+
+// Finance はテスト用の決済情報
+type Finance struct {
+	Card Card `json:"card"`
+	// IBAN は国籍が IBAN を使う国の場合だけ設定する
+	IBAN string `json:"iban,omitempty"`
+}
+
+// Card はテスト用の決済カード
+type Card struct {
+	// Scheme はカードのブランド (visa, mastercard, amex など)
+	Scheme string `json:"scheme"`
+	Number string `json:"number"`
+	// Expiry は有効期限 (MM/YY)
+	Expiry string `json:"expiry"`
+	CVV    string `json:"cvv"`
+}
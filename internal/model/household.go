@@ -0,0 +1,22 @@
+package model
+
+// Household は同じ住所に住む世帯
+type Household struct {
+	ID      string   `json:"id"`
+	Type    string   `json:"type"`
+	Members []Member `json:"members"`
+}
+
+// Member は世帯の一員
+type Member struct {
+	// Role は世帯主から見た続柄。head / spouse / child / parent のいずれか
+	Role          string         `json:"role"`
+	User          User           `json:"user"`
+	Relationships []Relationship `json:"relationships"`
+}
+
+// Relationship は他の世帯員との関係。UUID は相手の Login.UUID
+type Relationship struct {
+	Type string `json:"type"`
+	UUID string `json:"uuid"`
+}
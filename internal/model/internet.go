@@ -0,0 +1,23 @@
+package model
+
+// Internet はネットワークと端末の情報
+type Internet struct {
+	IPv4 string `json:"ipv4"`
+	IPv6 string `json:"ipv6"`
+	MAC  string `json:"mac"`
+	// Device は端末の種類 (desktop, mobile, tablet)。UserAgent と対応する
+	Device    string `json:"device"`
+	OS        string `json:"os"`
+	Browser   string `json:"browser"`
+	UserAgent string `json:"userAgent"`
+	Website   string `json:"website"`
+	Social    Social `json:"social"`
+}
+
+// Social は SNS のアカウント。Login.Username から作る
+type Social struct {
+	X         string `json:"x"`
+	Instagram string `json:"instagram"`
+	GitHub    string `json:"github"`
+	LinkedIn  string `json:"linkedin"`
+}
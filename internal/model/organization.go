@@ -0,0 +1,21 @@
+package model
+
+// Organization は会社と従業員
+type Organization struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	// Employees は社長を先頭に、上司が部下より前になる順に並べる
+	Employees []User `json:"employees"`
+}
+
+// Employment は従業員としての情報
+type Employment struct {
+	Employer   string `json:"employer"`
+	Department string `json:"department"`
+	Title      string `json:"title"`
+	// Email は会社のドメインの仕事用メールアドレス
+	Email      string `json:"email"`
+	EmployeeID string `json:"employeeId"`
+	// ManagerID は上司の EmployeeID。社長は空
+	ManagerID string `json:"managerId"`
+}
@@ -1,18 +1,34 @@
 package model
 
 type User struct {
-	Gender     string   `json:"gender"`
-	Name       Name     `json:"name"`
-	Location   Location `json:"location"`
-	Email      string   `json:"email"`
-	Login      Login    `json:"login"`
-	Dob        Dob      `json:"dob"`
+	Gender     string     `json:"gender"`
+	Name       Name       `json:"name"`
+	Location   Location   `json:"location"`
+	Email      string     `json:"email"`
+	Login      Login      `json:"login"`
+	Dob        Dob        `json:"dob"`
 	Registered Registered `json:"registered"`
-	Phone      string   `json:"phone"`
-	Cell       string   `json:"cell"`
-	ID         ID       `json:"id"`
-	Picture    Picture  `json:"picture"`
-	NAT        string   `json:"nat"`
+	Phone      string     `json:"phone"`
+	Cell       string     `json:"cell"`
+	// PhoneE164 と CellE164 は Phone と Cell の E.164 形式 (例: +12125550123)
+	PhoneE164 string   `json:"phoneE164"`
+	CellE164  string   `json:"cellE164"`
+	ID        ID       `json:"id"`
+	Picture   Picture  `json:"picture"`
+	NAT       string   `json:"nat"`
+	Internet  Internet `json:"internet"`
+	// Employment は組織図を生成した場合だけ設定する
+	Employment *Employment `json:"employment,omitempty"`
+	// Finance は inc=finance を指定した場合だけ設定する
+	Finance *Finance `json:"finance,omitempty"`
+	// Cluster は近似重複を生成した場合だけ設定する
+	Cluster *Cluster `json:"cluster,omitempty"`
+	// EdgeCases は profile=edge で設定した境界値の種類
+	EdgeCases []string `json:"edgeCases,omitempty"`
+	// Cohort は母集団の指定で生成した場合の集団の名前
+	Cohort string `json:"cohort,omitempty"`
+	// Corruptions は dirty モードで加えた破損。JSON の本体には書き出さず、マニフェストとして別に出力する
+	Corruptions []Corruption `json:"-"`
 }
 
 type Name struct {
@@ -70,3 +86,8 @@ type Picture struct {
 	Medium    string `json:"medium"`
 	Thumbnail string `json:"thumbnail"`
 }
+
+// Portrait は顔写真の URL を返す。顔写真は Thumbnail にあり、Large と Medium は性別ごとの仮の画像
+func (p Picture) Portrait() string {
+	return p.Thumbnail
+}
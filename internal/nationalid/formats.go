@@ -0,0 +1,223 @@
+package nationalid
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSN (米国)
+
+// generateSSN は AAA-GG-SSSS 形式の社会保障番号を作る。
+// エリア番号は 000・666・900 番台を、グループ番号は 00 を、シリアル番号は 0000 を避ける
+func generateSSN(_ string, _ time.Time, rnd *mathrand.Rand) string {
+	area := 1 + rnd.Intn(898)
+	if area >= 666 {
+		area++
+	}
+	return fmt.Sprintf("%03d-%02d-%04d", area, 1+rnd.Intn(99), 1+rnd.Intn(9999))
+}
+
+// ValidSSN は米国の社会保障番号 (AAA-GG-SSSS) として有効かを返す
+func ValidSSN(s string) bool {
+	parts := strings.Split(s, "-")
+	if len(parts) == 1 && len(s) == 9 {
+		parts = []string{s[:3], s[3:5], s[5:]}
+	}
+	if len(parts) != 3 || len(parts[0]) != 3 || len(parts[1]) != 2 || len(parts[2]) != 4 {
+		return false
+	}
+	area, err1 := strconv.Atoi(parts[0])
+	group, err2 := strconv.Atoi(parts[1])
+	serial, err3 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return false
+	}
+	return area > 0 && area != 666 && area < 900 && group > 0 && serial > 0
+}
+
+// NINO (英国)
+
+const (
+	ninoFirst    = "ABCEGHJKLMNOPRSTWXYZ"
+	ninoSecond   = "ABCEGHJKLMNPRSTWXYZ"
+	ninoSuffixes = "ABCD"
+)
+
+// ninoReserved は割り当てられない接頭辞
+var ninoReserved = map[string]bool{"BG": true, "GB": true, "KN": true, "NK": true, "NT": true, "TN": true, "ZZ": true}
+
+// generateNINO は "AB 12 34 56 C" 形式の国民保険番号を作る
+func generateNINO(_ string, _ time.Time, rnd *mathrand.Rand) string {
+	prefix := ""
+	for prefix == "" || ninoReserved[prefix] {
+		prefix = string(ninoFirst[rnd.Intn(len(ninoFirst))]) + string(ninoSecond[rnd.Intn(len(ninoSecond))])
+	}
+	return fmt.Sprintf("%s %02d %02d %02d %c", prefix, rnd.Intn(100), rnd.Intn(100), rnd.Intn(100), ninoSuffixes[rnd.Intn(len(ninoSuffixes))])
+}
+
+// ValidNINO は英国の国民保険番号として有効かを返す。空白の有無は問わない
+func ValidNINO(s string) bool {
+	s = strings.ReplaceAll(strings.ToUpper(s), " ", "")
+	if len(s) != 9 || ninoReserved[s[:2]] {
+		return false
+	}
+	if !strings.ContainsRune(ninoFirst, rune(s[0])) || !strings.ContainsRune(ninoSecond, rune(s[1])) ||
+		!strings.ContainsRune(ninoSuffixes, rune(s[8])) {
+		return false
+	}
+	_, ok := digits(s[2:8], 6)
+	return ok
+}
+
+// INSEE (フランス)
+
+// generateINSEE は "1 85 05 78 006 084 36" 形式の社会保障番号を作る。
+// 性別・出生年・出生月は本人のものを使い、出生地はコルシカ (2A・2B) を除く本土の県にする
+func generateINSEE(gender string, dob time.Time, rnd *mathrand.Rand) string {
+	sex := 1
+	if gender == "female" {
+		sex = 2
+	}
+	dept := 1 + rnd.Intn(94)
+	if dept >= 20 {
+		dept++
+	}
+	body := fmt.Sprintf("%d%02d%02d%02d%03d%03d", sex, dob.Year()%100, int(dob.Month()), dept, 1+rnd.Intn(990), 1+rnd.Intn(999))
+	n, _ := strconv.ParseInt(body, 10, 64)
+	key := 97 - n%97
+	return fmt.Sprintf("%s %s %s %s %s %s %02d", body[0:1], body[1:3], body[3:5], body[5:7], body[7:10], body[10:13], key)
+}
+
+// ValidINSEE は INSEE の社会保障番号 (13 桁と 2 桁のキー) として有効かを返す。
+// コルシカの 2A・2B はキーの計算で 19・18 に置き換える
+func ValidINSEE(s string) bool {
+	s = strings.ReplaceAll(strings.ToUpper(s), " ", "")
+	if len(s) != 15 {
+		return false
+	}
+	body := s[:13]
+	switch body[5:7] {
+	case "2A":
+		body = body[:5] + "19" + body[7:]
+	case "2B":
+		body = body[:5] + "18" + body[7:]
+	}
+	d, ok := digits(body+s[13:], 15)
+	if !ok || (d[0] != 1 && d[0] != 2) {
+		return false
+	}
+	month := d[3]*10 + d[4]
+	if month < 1 || (month > 12 && month < 20) || (month > 42 && month != 99) {
+		return false
+	}
+	n, _ := strconv.ParseInt(body, 10, 64)
+	return 97-n%97 == int64(d[13]*10+d[14])
+}
+
+// BSN (オランダ)
+
+// generateBSN は 9 桁の市民サービス番号を作る
+func generateBSN(_ string, _ time.Time, rnd *mathrand.Rand) string {
+	for {
+		d := append(randomDigits(rnd, 8), 0)
+		d[0] = 1 + rnd.Intn(9)
+		sum := 0
+		for i := 0; i < 8; i++ {
+			sum += (9 - i) * d[i]
+		}
+		if check := sum % 11; check < 10 {
+			d[8] = check
+			return join(d)
+		}
+	}
+}
+
+// ValidBSN はオランダの市民サービス番号として有効かを返す。
+// 各桁に 9, 8, ..., 2, -1 を掛けた和が 11 で割り切れる (11 テスト)
+func ValidBSN(s string) bool {
+	d, ok := digits(s, 9)
+	if !ok {
+		return false
+	}
+	sum := -d[8]
+	for i := 0; i < 8; i++ {
+		sum += (9 - i) * d[i]
+	}
+	return sum != 0 && sum%11 == 0
+}
+
+// マイナンバー (日本)
+
+// generateMyNumber は "1234 5678 9018" 形式の個人番号を作る
+func generateMyNumber(_ string, _ time.Time, rnd *mathrand.Rand) string {
+	d := append(randomDigits(rnd, 11), 0)
+	d[11] = myNumberCheckDigit(d[:11])
+	s := join(d)
+	return s[0:4] + " " + s[4:8] + " " + s[8:12]
+}
+
+// myNumberCheckDigit は個人番号の上位 11 桁から検査用数字を計算する
+func myNumberCheckDigit(d []int) int {
+	sum := 0
+	for n := 1; n <= 11; n++ {
+		q := n + 1
+		if n > 6 {
+			q = n - 5
+		}
+		sum += d[11-n] * q
+	}
+	if r := sum % 11; r > 1 {
+		return 11 - r
+	}
+	return 0
+}
+
+// ValidMyNumber は日本の個人番号 (12 桁) として有効かを返す
+func ValidMyNumber(s string) bool {
+	d, ok := digits(s, 12)
+	return ok && myNumberCheckDigit(d[:11]) == d[11]
+}
+
+// CPF (ブラジル)
+
+// generateCPF は "123.456.789-09" 形式の納税者番号を作る
+func generateCPF(_ string, _ time.Time, rnd *mathrand.Rand) string {
+	d := randomDigits(rnd, 9)
+	for allSame(d) {
+		d = randomDigits(rnd, 9)
+	}
+	d = append(d, cpfCheckDigit(d))
+	d = append(d, cpfCheckDigit(d))
+	s := join(d)
+	return s[0:3] + "." + s[3:6] + "." + s[6:9] + "-" + s[9:11]
+}
+
+// cpfCheckDigit は d に続く検査用数字を計算する。重みは末尾の桁から 2, 3, ... になる
+func cpfCheckDigit(d []int) int {
+	sum := 0
+	for i, n := range d {
+		sum += n * (len(d) + 1 - i)
+	}
+	return sum * 10 % 11 % 10
+}
+
+// ValidCPF はブラジルの納税者番号として有効かを返す。すべて同じ数字の番号は無効
+func ValidCPF(s string) bool {
+	d, ok := digits(s, 11)
+	if !ok || allSame(d) {
+		return false
+	}
+	return cpfCheckDigit(d[:9]) == d[9] && cpfCheckDigit(d[:10]) == d[10]
+}
+
+func allSame(d []int) bool {
+	for _, n := range d {
+		if n != d[0] {
+			return false
+		}
+	}
+	return true
+}
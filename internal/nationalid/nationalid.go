@@ -0,0 +1,91 @@
+// Package nationalid は国籍ごとの国民識別番号を生成・検証する。
+// 生成する番号はチェックディジットや番号体系の規則を満たすが、実在の人物には割り当てられていない
+package nationalid
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// 識別番号の種類。model.ID の Name に入る
+const (
+	SSN      = "SSN"
+	NINO     = "NINO"
+	INSEE    = "INSEE"
+	BSN      = "BSN"
+	MyNumber = "MY NUMBER"
+	CPF      = "CPF"
+)
+
+// kind は識別番号の種類ごとの生成と検証
+type kind struct {
+	name     string
+	generate func(gender string, dob time.Time, rnd *mathrand.Rand) string
+	valid    func(s string) bool
+}
+
+// kinds は国籍コードと識別番号の対応
+var kinds = map[string]kind{
+	"US": {SSN, generateSSN, ValidSSN},
+	"GB": {NINO, generateNINO, ValidNINO},
+	"FR": {INSEE, generateINSEE, ValidINSEE},
+	"NL": {BSN, generateBSN, ValidBSN},
+	"JP": {MyNumber, generateMyNumber, ValidMyNumber},
+	"BR": {CPF, generateCPF, ValidCPF},
+}
+
+// Generate は国籍 nat の識別番号を生成する。INSEE のように性別と生年月を含む番号は gender と dob から作る。
+// 対応していない国籍の場合は8桁の汎用の ID にする
+func Generate(nat, gender string, dob time.Time, rnd *mathrand.Rand) model.ID {
+	k, ok := kinds[strings.ToUpper(nat)]
+	if !ok {
+		return model.ID{Name: "ID", Value: fmt.Sprintf("%08d", rnd.Int63n(100000000))}
+	}
+	return model.ID{Name: k.name, Value: k.generate(gender, dob, rnd)}
+}
+
+// Valid は識別番号が種類の規則を満たすかを返す。未知の種類の場合は false
+func Valid(id model.ID) bool {
+	for _, k := range kinds {
+		if k.name == id.Name {
+			return k.valid(id.Value)
+		}
+	}
+	return false
+}
+
+// digits は区切りの空白・ハイフン・ピリオドを除いた s が n 桁の数字であれば、各桁を返す
+func digits(s string, n int) ([]int, bool) {
+	s = strings.NewReplacer(" ", "", "-", "", ".", "").Replace(s)
+	if len(s) != n {
+		return nil, false
+	}
+	d := make([]int, n)
+	for i, c := range s {
+		if c < '0' || c > '9' {
+			return nil, false
+		}
+		d[i] = int(c - '0')
+	}
+	return d, true
+}
+
+func randomDigits(rnd *mathrand.Rand, n int) []int {
+	d := make([]int, n)
+	for i := range d {
+		d[i] = rnd.Intn(10)
+	}
+	return d
+}
+
+func join(d []int) string {
+	var b strings.Builder
+	for _, n := range d {
+		b.WriteByte(byte('0' + n))
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package nationalid
+
+import (
+	mathrand "math/rand"
+	"testing"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name    string
+		valid   func(string) bool
+		ok      []string
+		invalid []string
+	}{
+		{SSN, ValidSSN, []string{"123-45-6789", "899-01-0001", "123456789"}, []string{"000-12-3456", "666-12-3456", "900-12-3456", "123-00-4567", "123-45-0000", "12-345-6789"}},
+		{NINO, ValidNINO, []string{"AB 12 34 56 C", "ab123456d"}, []string{"GB 12 34 56 A", "DA 12 34 56 A", "AO 12 34 56 A", "AB 12 34 56 E", "AB 12 34 5 C"}},
+		{INSEE, ValidINSEE, []string{"1 85 05 78 006 084 91", "185057800608491", "2 69 05 2A 123 456 88"}, []string{"1 85 05 78 006 084 90", "3 85 05 78 006 084 91", "1 85 13 78 006 084 91"}},
+		{BSN, ValidBSN, []string{"111222333", "123456782"}, []string{"111222334", "000000000", "12345678"}},
+		{MyNumber, ValidMyNumber, []string{"123456789018", "1234 5678 9018"}, []string{"123456789012", "12345678901"}},
+		{CPF, ValidCPF, []string{"529.982.247-25", "52998224725"}, []string{"529.982.247-26", "111.111.111-11", "529.982.247"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range tt.ok {
+				assert.True(t, tt.valid(s), s)
+			}
+			for _, s := range tt.invalid {
+				assert.False(t, tt.valid(s), s)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	rnd := mathrand.New(mathrand.NewSource(1))
+	dob := time.Date(1985, time.May, 10, 0, 0, 0, 0, time.UTC)
+	for nat, k := range kinds {
+		for i := 0; i < 1000; i++ {
+			id := Generate(nat, "female", dob, rnd)
+			assert.Equal(t, k.name, id.Name)
+			if !assert.True(t, Valid(id), "%s %s", nat, id.Value) {
+				break
+			}
+		}
+	}
+
+	id := Generate("FR", "female", dob, rnd)
+	assert.Regexp(t, `^2 85 05 `, id.Value, "性別と生年月を含む")
+
+	id = Generate("XX", "male", dob, rnd)
+	assert.Equal(t, "ID", id.Name)
+	assert.Len(t, id.Value, 8)
+	assert.False(t, Valid(id))
+	assert.False(t, Valid(model.ID{Name: SSN, Value: "666-12-3456"}))
+}
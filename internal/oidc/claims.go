@@ -0,0 +1,128 @@
+package oidc
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/directory"
+)
+
+// Metadata はディスカバリードキュメント (OpenID Connect Discovery 1.0)
+type Metadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// Discovery は issuer のディスカバリードキュメントを返す。各エンドポイントは issuer の下に置く
+func Discovery(issuer string) Metadata {
+	issuer = strings.TrimSuffix(issuer, "/")
+	return Metadata{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/authorize",
+		TokenEndpoint:                     issuer + "/token",
+		UserinfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/jwks",
+		ScopesSupported:                   []string{"openid", "profile", "email", "phone", "address"},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "password"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256", "plain"},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+			"name", "given_name", "family_name", "preferred_username", "gender", "birthdate",
+			"picture", "locale", "updated_at", "email", "email_verified", "phone_number", "address",
+		},
+	}
+}
+
+// locales は国籍から locale クレームを決める
+var locales = map[string]string{
+	"AU": "en-AU",
+	"BR": "pt-BR",
+	"CA": "en-CA",
+	"DE": "de-DE",
+	"ES": "es-ES",
+	"FR": "fr-FR",
+	"GB": "en-GB",
+	"JP": "ja-JP",
+	"NL": "nl-NL",
+	"US": "en-US",
+}
+
+// Claims はスコープに応じたユーザーのクレームを返す (OpenID Connect Core 1.0 5.4)
+func Claims(e directory.Entry, scopes []string) map[string]any {
+	u := e.User
+	claims := map[string]any{"sub": e.ID}
+	for _, scope := range scopes {
+		switch scope {
+		case "profile":
+			setClaim(claims, "name", strings.TrimSpace(u.Name.First+" "+u.Name.Last))
+			setClaim(claims, "given_name", u.Name.First)
+			setClaim(claims, "family_name", u.Name.Last)
+			setClaim(claims, "preferred_username", u.Login.Username)
+			setClaim(claims, "gender", u.Gender)
+			setClaim(claims, "picture", u.Picture.Portrait())
+			setClaim(claims, "locale", locale(u.NAT))
+			if dob, err := time.Parse(time.RFC3339, u.Dob.Date); err == nil {
+				claims["birthdate"] = dob.Format(time.DateOnly)
+			}
+			if !e.Meta.LastModified.IsZero() {
+				claims["updated_at"] = e.Meta.LastModified.Unix()
+			}
+		case "email":
+			if u.Email != "" {
+				claims["email"] = u.Email
+				claims["email_verified"] = true
+			}
+		case "phone":
+			// phone_number は E.164 を推奨している
+			setClaim(claims, "phone_number", cmp.Or(u.PhoneE164, u.Phone))
+		case "address":
+			loc := u.Location
+			street := loc.Street.Name
+			if loc.Street.Number != 0 {
+				street = strconv.Itoa(loc.Street.Number) + " " + loc.Street.Name
+			}
+			if street != "" || loc.City != "" {
+				claims["address"] = map[string]any{
+					"formatted":      strings.Join([]string{street, loc.City + ", " + loc.State + " " + loc.Postcode, loc.Country}, "\n"),
+					"street_address": street,
+					"locality":       loc.City,
+					"region":         loc.State,
+					"postal_code":    loc.Postcode,
+					"country":        loc.Country,
+				}
+			}
+		}
+	}
+	return claims
+}
+
+// locale は国籍コードから BCP 47 の言語タグを返す。対応していない国籍は en-US にする
+func locale(nat string) string {
+	if l, ok := locales[strings.ToUpper(nat)]; ok {
+		return l
+	}
+	return "en-US"
+}
+
+func setClaim(claims map[string]any, name, value string) {
+	if value != "" {
+		claims[name] = value
+	}
+}
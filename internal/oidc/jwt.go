@@ -0,0 +1,110 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// typeAccessToken はアクセストークンの JWT の typ (RFC 9068)。ID トークンと区別するために使う
+const typeAccessToken = "at+jwt"
+
+var errInvalidToken = errors.New("トークンが不正です")
+
+// JWK は RSA の公開鍵 (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS は jwks_uri で公開する鍵の一覧
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// newJWK は公開鍵を JWK にする。kid は公開鍵の SHA-256 の先頭から作る
+func newJWK(pub *rsa.PublicKey) JWK {
+	n := pub.N.Bytes()
+	e := big.NewInt(int64(pub.E)).Bytes()
+	sum := sha256.Sum256(n)
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: b64(sum[:12]),
+		N:   b64(n),
+		E:   b64(e),
+	}
+}
+
+// sign は claims を RS256 で署名した JWT を返す
+func sign(key *rsa.PrivateKey, kid, typ string, claims map[string]any) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "RS256", Kid: kid, Typ: typ})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + b64(sig), nil
+}
+
+// verify は JWT の署名を確かめ、ヘッダーの typ とクレームを返す
+func verify(pub *rsa.PublicKey, token string) (string, map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", nil, errInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, errInvalidToken
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		return "", nil, errInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "RS256" {
+		return "", nil, errInvalidToken
+	}
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", nil, errInvalidToken
+	}
+	return header.Typ, claims, nil
+}
+
+func decodeSegment(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,393 @@
+// Package oidc は生成されたユーザーでログインできる OpenID Connect のプロバイダーを実装する。
+// ユーザーはディレクトリの母集団から引くため、同じシードであれば同じユーザーでログインできる。
+// フロントエンドの e2e テストなどで使う模擬の IdP であり、本番の認証に使うことは想定していない
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/directory"
+)
+
+const (
+	// CodeTTL は認可コードの有効期間
+	CodeTTL = time.Minute
+	// TokenTTL は ID トークンとアクセストークンの有効期間
+	TokenTTL = time.Hour
+)
+
+// ErrInvalidCredentials はユーザー名またはパスワードが正しくないことを表す
+var ErrInvalidCredentials = errors.New("ユーザー名またはパスワードが正しくありません")
+
+// Error は OAuth 2.0 のエラーレスポンス (RFC 6749 5.2)
+type Error struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newError(status int, code, format string, args ...any) error {
+	return &Error{Status: status, Code: code, Description: fmt.Sprintf(format, args...)}
+}
+
+// Users はログインに使うユーザーを引くインターフェース
+type Users interface {
+	Get(id string) (directory.Entry, error)
+	Lookup(username string) (directory.Entry, error)
+}
+
+// Client は登録済みのクライアント
+type Client struct {
+	ID     string
+	Secret string
+	// RedirectURIs は許可するリダイレクト先。空の場合はどこでも許可する
+	RedirectURIs []string
+}
+
+// AuthRequest は認可エンドポイントへのリクエスト
+type AuthRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	LoginHint           string `form:"login_hint"`
+}
+
+// TokenRequest はトークンエンドポイントへのリクエスト
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+	Username     string `form:"username"`
+	Password     string `form:"password"`
+	Scope        string `form:"scope"`
+}
+
+// TokenResponse はトークンエンドポイントのレスポンス
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope"`
+}
+
+// authCode は発行した認可コードに紐づく情報
+type authCode struct {
+	clientID            string
+	redirectURI         string
+	userID              string
+	scope               string
+	nonce               string
+	codeChallenge       string
+	codeChallengeMethod string
+	authTime            time.Time
+	expires             time.Time
+}
+
+// Provider は OpenID Connect のプロバイダー。署名鍵は起動のたびに作り直す
+type Provider struct {
+	users   Users
+	clients map[string]Client
+	key     *rsa.PrivateKey
+	jwk     JWK
+
+	mu    sync.Mutex
+	codes map[string]authCode
+	now   func() time.Time
+}
+
+// New はプロバイダーを作成する。clients が空の場合はどのクライアントも受け付ける
+func New(users Users, clients []Client) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("署名鍵の生成に失敗: %w", err)
+	}
+	p := &Provider{
+		users:   users,
+		clients: make(map[string]Client),
+		key:     key,
+		jwk:     newJWK(&key.PublicKey),
+		codes:   make(map[string]authCode),
+		now:     time.Now,
+	}
+	for _, c := range clients {
+		p.clients[c.ID] = c
+	}
+	return p, nil
+}
+
+// JWKS は署名の検証に使う公開鍵を返す
+func (p *Provider) JWKS() JWKS {
+	return JWKS{Keys: []JWK{p.jwk}}
+}
+
+// CheckClient はクライアントとリダイレクト先を確かめる。
+// ここで失敗した場合はリダイレクト先が信頼できないため、エラーはリダイレクトせずに表示する
+func (p *Provider) CheckClient(clientID, redirectURI string) error {
+	if clientID == "" {
+		return newError(http.StatusBadRequest, "invalid_request", "client_id が必要です")
+	}
+	if redirectURI == "" {
+		return newError(http.StatusBadRequest, "invalid_request", "redirect_uri が必要です")
+	}
+	if len(p.clients) == 0 {
+		return nil
+	}
+	c, ok := p.clients[clientID]
+	if !ok {
+		return newError(http.StatusBadRequest, "invalid_client", "未登録のクライアントです: %s", clientID)
+	}
+	if len(c.RedirectURIs) > 0 && !slices.Contains(c.RedirectURIs, redirectURI) {
+		return newError(http.StatusBadRequest, "invalid_request", "登録されていない redirect_uri です: %s", redirectURI)
+	}
+	return nil
+}
+
+// Check は認可リクエストを確かめる。クライアントは CheckClient で確かめておく
+func (p *Provider) Check(req AuthRequest) error {
+	if req.ResponseType != "code" {
+		return newError(http.StatusBadRequest, "unsupported_response_type", "response_type は code のみ対応しています")
+	}
+	if !slices.Contains(strings.Fields(req.Scope), "openid") {
+		return newError(http.StatusBadRequest, "invalid_scope", "scope に openid が必要です")
+	}
+	switch req.CodeChallengeMethod {
+	case "", "plain", "S256":
+	default:
+		return newError(http.StatusBadRequest, "invalid_request", "未対応の code_challenge_method です: %s", req.CodeChallengeMethod)
+	}
+	return nil
+}
+
+// Authorize はユーザー名とパスワードを確かめ、認可コードを発行する
+func (p *Provider) Authorize(req AuthRequest, username, password string) (string, error) {
+	if err := p.CheckClient(req.ClientID, req.RedirectURI); err != nil {
+		return "", err
+	}
+	if err := p.Check(req); err != nil {
+		return "", err
+	}
+	e, err := p.authenticate(username, password)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	method := req.CodeChallengeMethod
+	if method == "" && req.CodeChallenge != "" {
+		method = "plain"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for k, c := range p.codes {
+		if now.After(c.expires) {
+			delete(p.codes, k)
+		}
+	}
+	p.codes[code] = authCode{
+		clientID:            req.ClientID,
+		redirectURI:         req.RedirectURI,
+		userID:              e.ID,
+		scope:               req.Scope,
+		nonce:               req.Nonce,
+		codeChallenge:       req.CodeChallenge,
+		codeChallengeMethod: method,
+		authTime:            now,
+		expires:             now.Add(CodeTTL),
+	}
+	return code, nil
+}
+
+// Token は認可コードまたはパスワードと引き換えにトークンを発行する
+func (p *Provider) Token(req TokenRequest, issuer string) (TokenResponse, error) {
+	if err := p.authenticateClient(req); err != nil {
+		return TokenResponse{}, err
+	}
+
+	switch req.GrantType {
+	case "authorization_code":
+		c, err := p.redeem(req)
+		if err != nil {
+			return TokenResponse{}, err
+		}
+		e, err := p.users.Get(c.userID)
+		if err != nil || !e.Active {
+			return TokenResponse{}, newError(http.StatusBadRequest, "invalid_grant", "ユーザーが無効です")
+		}
+		return p.issue(e, c.clientID, c.scope, c.nonce, c.authTime, issuer)
+	case "password":
+		e, err := p.authenticate(req.Username, req.Password)
+		if err != nil {
+			return TokenResponse{}, newError(http.StatusBadRequest, "invalid_grant", "%v", err)
+		}
+		scope := req.Scope
+		if scope == "" {
+			scope = "openid profile email"
+		}
+		return p.issue(e, req.ClientID, scope, "", p.now(), issuer)
+	default:
+		return TokenResponse{}, newError(http.StatusBadRequest, "unsupported_grant_type", "未対応の grant_type です: %s", req.GrantType)
+	}
+}
+
+// UserInfo はアクセストークンのユーザーのクレームを返す
+func (p *Provider) UserInfo(accessToken, issuer string) (map[string]any, error) {
+	typ, claims, err := verify(&p.key.PublicKey, accessToken)
+	if err != nil || typ != typeAccessToken {
+		return nil, newError(http.StatusUnauthorized, "invalid_token", "アクセストークンが不正です")
+	}
+	if iss, _ := claims["iss"].(string); iss != strings.TrimSuffix(issuer, "/") {
+		return nil, newError(http.StatusUnauthorized, "invalid_token", "アクセストークンの発行者が異なります")
+	}
+	if exp, _ := claims["exp"].(float64); p.now().Unix() >= int64(exp) {
+		return nil, newError(http.StatusUnauthorized, "invalid_token", "アクセストークンの有効期限が切れています")
+	}
+
+	sub, _ := claims["sub"].(string)
+	e, err := p.users.Get(sub)
+	if err != nil || !e.Active {
+		return nil, newError(http.StatusUnauthorized, "invalid_token", "ユーザーが無効です")
+	}
+	scope, _ := claims["scope"].(string)
+	return Claims(e, strings.Fields(scope)), nil
+}
+
+// authenticate はユーザー名とパスワードが一致する有効なユーザーを返す
+func (p *Provider) authenticate(username, password string) (directory.Entry, error) {
+	e, err := p.users.Lookup(username)
+	if err != nil {
+		if errors.Is(err, directory.ErrNotFound) {
+			return directory.Entry{}, ErrInvalidCredentials
+		}
+		return directory.Entry{}, err
+	}
+	if !e.Active || subtle.ConstantTimeCompare([]byte(e.User.Login.Password), []byte(password)) != 1 {
+		return directory.Entry{}, ErrInvalidCredentials
+	}
+	return e, nil
+}
+
+// authenticateClient は登録済みのクライアントであればシークレットを確かめる
+func (p *Provider) authenticateClient(req TokenRequest) error {
+	if len(p.clients) == 0 {
+		return nil
+	}
+	c, ok := p.clients[req.ClientID]
+	if !ok {
+		return newError(http.StatusUnauthorized, "invalid_client", "未登録のクライアントです: %s", req.ClientID)
+	}
+	if c.Secret != "" && subtle.ConstantTimeCompare([]byte(c.Secret), []byte(req.ClientSecret)) != 1 {
+		return newError(http.StatusUnauthorized, "invalid_client", "クライアントの認証に失敗しました")
+	}
+	return nil
+}
+
+// redeem は認可コードを1回だけ使えるように取り出し、リクエストと照合する
+func (p *Provider) redeem(req TokenRequest) (authCode, error) {
+	p.mu.Lock()
+	c, ok := p.codes[req.Code]
+	delete(p.codes, req.Code)
+	p.mu.Unlock()
+
+	switch {
+	case !ok || p.now().After(c.expires):
+		return c, newError(http.StatusBadRequest, "invalid_grant", "認可コードが不正か、有効期限が切れています")
+	case req.ClientID != "" && req.ClientID != c.clientID:
+		return c, newError(http.StatusBadRequest, "invalid_grant", "認可コードを発行したクライアントと異なります")
+	case req.RedirectURI != c.redirectURI:
+		return c, newError(http.StatusBadRequest, "invalid_grant", "redirect_uri が認可リクエストと異なります")
+	case !verifyChallenge(c.codeChallenge, c.codeChallengeMethod, req.CodeVerifier):
+		return c, newError(http.StatusBadRequest, "invalid_grant", "code_verifier が一致しません")
+	}
+	return c, nil
+}
+
+// verifyChallenge は PKCE (RFC 7636) の code_verifier を確かめる
+func verifyChallenge(challenge, method, verifier string) bool {
+	if challenge == "" {
+		return true
+	}
+	if method == "S256" {
+		sum := sha256.Sum256([]byte(verifier))
+		verifier = b64(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(verifier)) == 1
+}
+
+// issue は ID トークンとアクセストークンを発行する
+func (p *Provider) issue(e directory.Entry, clientID, scope, nonce string, authTime time.Time, issuer string) (TokenResponse, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	now := p.now()
+	scopes := strings.Fields(scope)
+
+	access := map[string]any{
+		"iss":       issuer,
+		"sub":       e.ID,
+		"aud":       clientID,
+		"client_id": clientID,
+		"scope":     scope,
+		"iat":       now.Unix(),
+		"exp":       now.Add(TokenTTL).Unix(),
+	}
+	accessToken, err := sign(p.key, p.jwk.Kid, typeAccessToken, access)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	res := TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(TokenTTL.Seconds()),
+		Scope:       scope,
+	}
+
+	if slices.Contains(scopes, "openid") {
+		claims := Claims(e, scopes)
+		claims["iss"] = issuer
+		claims["aud"] = clientID
+		claims["iat"] = now.Unix()
+		claims["exp"] = now.Add(TokenTTL).Unix()
+		claims["auth_time"] = authTime.Unix()
+		if nonce != "" {
+			claims["nonce"] = nonce
+		}
+		if res.IDToken, err = sign(p.key, p.jwk.Kid, "JWT", claims); err != nil {
+			return TokenResponse{}, err
+		}
+	}
+	return res, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b64(b), nil
+}
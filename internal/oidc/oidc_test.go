@@ -0,0 +1,210 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const issuer = "http://localhost:8080/oidc"
+
+// fakeUsers は ID とユーザー名でユーザーを引く
+type fakeUsers []directory.Entry
+
+func (f fakeUsers) Get(id string) (directory.Entry, error) {
+	for _, e := range f {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return directory.Entry{}, directory.ErrNotFound
+}
+
+func (f fakeUsers) Lookup(username string) (directory.Entry, error) {
+	for _, e := range f {
+		if strings.EqualFold(e.User.Login.Username, username) {
+			return e, nil
+		}
+	}
+	return directory.Entry{}, directory.ErrNotFound
+}
+
+var testUsers = fakeUsers{
+	{
+		ID:     "d8f02e4d-4e1c-4213-a7b5-5d4fe145dff4",
+		Active: true,
+		User: model.User{
+			Gender:  "female",
+			Name:    model.Name{Title: "Ms", First: "Susan", Last: "Sanders"},
+			Email:   "susan.sanders@example.com",
+			Login:   model.Login{Username: "susansanders32", Password: "secret"},
+			Dob:     model.Dob{Date: "1977-12-25T10:00:00Z"},
+			Picture: model.Picture{Large: "https://example.com/large.png", Thumbnail: "https://example.com/portrait.png"},
+			NAT:     "GB",
+		},
+	},
+	{
+		ID:   "0f6a3c1e-9b2d-4e7f-8a10-5c3d2b1a0e9f",
+		User: model.User{Login: model.Login{Username: "inactive", Password: "secret"}},
+		Meta: directory.Meta{Version: 2},
+	},
+}
+
+func newTestProvider(t *testing.T, clients ...Client) *Provider {
+	t.Helper()
+	p, err := New(testUsers, clients)
+	require.NoError(t, err)
+	return p
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	p := newTestProvider(t)
+	verifier := "dBjftJeZ4CVP-mJ92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	req := AuthRequest{
+		ResponseType:        "code",
+		ClientID:            "app",
+		RedirectURI:         "http://localhost:3000/callback",
+		Scope:               "openid profile email",
+		Nonce:               "n-0S6_WzA2Mj",
+		CodeChallenge:       b64(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+
+	_, err := p.Authorize(req, "susansanders32", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = p.Authorize(req, "inactive", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	code, err := p.Authorize(req, "SusanSanders32", "secret")
+	require.NoError(t, err)
+
+	token := TokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: req.RedirectURI, ClientID: "app", CodeVerifier: "wrong"}
+	_, err = p.Token(token, issuer)
+	assertError(t, err, "invalid_grant")
+
+	// 失敗した場合も認可コードは使えなくなる
+	token.CodeVerifier = verifier
+	_, err = p.Token(token, issuer)
+	assertError(t, err, "invalid_grant")
+
+	code, err = p.Authorize(req, "susansanders32", "secret")
+	require.NoError(t, err)
+	token.Code = code
+	res, err := p.Token(token, issuer)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", res.TokenType)
+	assert.Equal(t, 3600, res.ExpiresIn)
+
+	typ, claims, err := verify(&p.key.PublicKey, res.IDToken)
+	require.NoError(t, err)
+	assert.Equal(t, "JWT", typ)
+	assert.Equal(t, issuer, claims["iss"])
+	assert.Equal(t, "app", claims["aud"])
+	assert.Equal(t, testUsers[0].ID, claims["sub"])
+	assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+	assert.Equal(t, "Susan Sanders", claims["name"])
+	assert.Equal(t, "1977-12-25", claims["birthdate"])
+	assert.Equal(t, "en-GB", claims["locale"])
+	assert.Equal(t, "susan.sanders@example.com", claims["email"])
+	// 仮の画像ではなく顔写真の URL
+	assert.Equal(t, "https://example.com/portrait.png", claims["picture"])
+
+	_, err = p.Token(token, issuer)
+	assertError(t, err, "invalid_grant")
+
+	info, err := p.UserInfo(res.AccessToken, issuer)
+	require.NoError(t, err)
+	assert.Equal(t, "susansanders32", info["preferred_username"])
+	assert.NotContains(t, info, "nonce")
+
+	// ID トークンはアクセストークンとして使えない
+	_, err = p.UserInfo(res.IDToken, issuer)
+	assertError(t, err, "invalid_token")
+	_, err = p.UserInfo(res.AccessToken, "http://other/oidc")
+	assertError(t, err, "invalid_token")
+
+	p.now = func() time.Time { return time.Now().Add(2 * TokenTTL) }
+	_, err = p.UserInfo(res.AccessToken, issuer)
+	assertError(t, err, "invalid_token")
+}
+
+func TestPasswordGrant(t *testing.T) {
+	p := newTestProvider(t)
+
+	res, err := p.Token(TokenRequest{GrantType: "password", Username: "susansanders32", Password: "secret", Scope: "openid phone"}, issuer)
+	require.NoError(t, err)
+	_, claims, err := verify(&p.key.PublicKey, res.IDToken)
+	require.NoError(t, err)
+	assert.NotContains(t, claims, "email")
+
+	res, err = p.Token(TokenRequest{GrantType: "password", Username: "susansanders32", Password: "secret", Scope: "profile"}, issuer)
+	require.NoError(t, err)
+	assert.Empty(t, res.IDToken)
+
+	_, err = p.Token(TokenRequest{GrantType: "password", Username: "nobody", Password: "secret"}, issuer)
+	assertError(t, err, "invalid_grant")
+	_, err = p.Token(TokenRequest{GrantType: "client_credentials"}, issuer)
+	assertError(t, err, "unsupported_grant_type")
+}
+
+func TestClients(t *testing.T) {
+	p := newTestProvider(t, Client{ID: "app", Secret: "s3cret", RedirectURIs: []string{"http://localhost:3000/callback"}})
+
+	assert.NoError(t, p.CheckClient("app", "http://localhost:3000/callback"))
+	assertError(t, p.CheckClient("app", "http://evil.example.com/"), "invalid_request")
+	assertError(t, p.CheckClient("other", "http://localhost:3000/callback"), "invalid_client")
+
+	req := TokenRequest{GrantType: "password", ClientID: "app", Username: "susansanders32", Password: "secret"}
+	_, err := p.Token(req, issuer)
+	assertError(t, err, "invalid_client")
+	req.ClientSecret = "s3cret"
+	_, err = p.Token(req, issuer)
+	assert.NoError(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	p := newTestProvider(t)
+	valid := AuthRequest{ResponseType: "code", ClientID: "app", RedirectURI: "http://localhost/", Scope: "openid"}
+	assert.NoError(t, p.Check(valid))
+
+	req := valid
+	req.ResponseType = "token"
+	assertError(t, p.Check(req), "unsupported_response_type")
+
+	req = valid
+	req.Scope = "profile"
+	assertError(t, p.Check(req), "invalid_scope")
+
+	req = valid
+	req.CodeChallengeMethod = "S512"
+	assertError(t, p.Check(req), "invalid_request")
+}
+
+func TestJWKS(t *testing.T) {
+	p := newTestProvider(t)
+	keys := p.JWKS().Keys
+	require.Len(t, keys, 1)
+	assert.Equal(t, "RS256", keys[0].Alg)
+	assert.Equal(t, "AQAB", keys[0].E)
+
+	token, err := sign(p.key, keys[0].Kid, "JWT", map[string]any{"sub": "x"})
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+	_, _, err = verify(&p.key.PublicKey, parts[0]+"."+b64([]byte(`{"sub":"y"}`))+"."+parts[2])
+	assert.Error(t, err)
+}
+
+func assertError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oe *Error
+	if assert.ErrorAs(t, err, &oe) {
+		assert.Equal(t, code, oe.Code)
+	}
+}
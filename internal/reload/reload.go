@@ -0,0 +1,114 @@
+package reload
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+)
+
+// 再読み込みのきっかけ
+const (
+	TriggerStartup = "startup"
+	TriggerSignal  = "signal"
+	TriggerAPI     = "api"
+)
+
+// Status は直近の再読み込みの結果
+type Status struct {
+	Trigger    string    `json:"trigger"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	// DataLoadedAt は現在使用中のデータセットを読み込んだ時刻
+	DataLoadedAt time.Time `json:"dataLoadedAt"`
+	Reloads      int       `json:"reloads"`
+}
+
+// Reloader は設定とデータセットを再読み込みして差し替える
+type Reloader struct {
+	gen *generator.Generator
+	cfg atomic.Pointer[config.Config]
+
+	// mu は再読み込みを直列化し、status を保護する
+	mu      sync.Mutex
+	status  Status
+	reloads int
+}
+
+// New は読み込み済みの設定とジェネレーターから Reloader を作成する
+func New(gen *generator.Generator, cfg *config.Config) *Reloader {
+	r := &Reloader{gen: gen}
+	r.cfg.Store(cfg)
+	r.status = Status{
+		Trigger:      TriggerStartup,
+		StartedAt:    gen.LoadedAt(),
+		Success:      true,
+		DataLoadedAt: gen.LoadedAt(),
+	}
+	return r
+}
+
+// Config は現在の設定を返す
+func (r *Reloader) Config() *config.Config {
+	return r.cfg.Load()
+}
+
+// Reload は設定とデータセットを読み込み直す。
+// いずれかの読み込みに失敗した場合は何も差し替えず、現在の設定とデータセットを使い続ける
+func (r *Reloader) Reload(trigger string) (Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	started := time.Now()
+	err := r.reload()
+
+	r.reloads++
+	r.status = Status{
+		Trigger:      trigger,
+		StartedAt:    started,
+		DurationMs:   time.Since(started).Milliseconds(),
+		Success:      err == nil,
+		DataLoadedAt: r.gen.LoadedAt(),
+		Reloads:      r.reloads,
+	}
+	if err != nil {
+		r.status.Error = err.Error()
+		log.Printf("再読み込みに失敗(%s): %v", trigger, err)
+	} else {
+		log.Printf("再読み込みが完了しました(%s): %dms", trigger, r.status.DurationMs)
+	}
+	return r.status, err
+}
+
+func (r *Reloader) reload() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("設定の読み込みに失敗: %v", err)
+	}
+
+	old := r.cfg.Load()
+	if cfg.Port != old.Port {
+		log.Printf("ポートの変更(%d -> %d)は再起動するまで反映されません", old.Port, cfg.Port)
+	}
+
+	// 顔写真は新しい設定のバケットから読み込み、バケット名もスナップショットと一緒に差し替える
+	if err := r.gen.LoadGenerators(cfg.BucketName); err != nil {
+		return err
+	}
+
+	r.cfg.Store(cfg)
+	return nil
+}
+
+// Status は直近の再読み込みの結果を返す
+func (r *Reloader) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
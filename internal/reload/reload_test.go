@@ -0,0 +1,96 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ryuhei/randomuser-go/internal/config"
+	"github.com/ryuhei/randomuser-go/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setUpWorkDir は internal/data の写しと config.json を置いたディレクトリをワーキングディレクトリにする
+func setUpWorkDir(t *testing.T, configJSON string) string {
+	t.Helper()
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "internal", "data")
+	require.NoError(t, os.CopyFS(dataDir, os.DirFS("../data")))
+	// バケットを見に行かないよう、顔写真のマニフェストはローカルに置く
+	manifest := `{"layout":"{gender}/portrait ({n}).png","pools":[{"gender":"male","count":1},{"gender":"female","count":1}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "portraits.json"), []byte(manifest), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(configJSON), 0o644))
+	t.Chdir(dir)
+	return dir
+}
+
+func TestReload(t *testing.T) {
+	t.Setenv("BUCKET_NAME", "env-bucket")
+	setUpWorkDir(t, `{"port":8080,"maxResults":10,"bucketName":"new-bucket"}`)
+
+	gen := &generator.Generator{}
+	require.NoError(t, gen.LoadGenerators("old-bucket"))
+	loadedAt := gen.LoadedAt()
+	r := New(gen, &config.Config{Port: 8080, MaxResults: 5, BucketName: "old-bucket"})
+
+	st, err := r.Reload(TriggerAPI)
+	require.NoError(t, err)
+	assert.True(t, st.Success)
+	assert.Equal(t, TriggerAPI, st.Trigger)
+	assert.Equal(t, 1, st.Reloads)
+	assert.False(t, gen.LoadedAt().Before(loadedAt))
+	assert.Equal(t, gen.LoadedAt(), st.DataLoadedAt)
+
+	assert.Equal(t, "new-bucket", r.Config().BucketName)
+	assert.Equal(t, 10, r.Config().MaxResults)
+	// バケット名はスナップショットに持たせ、環境変数は書き換えない
+	assert.Equal(t, "env-bucket", os.Getenv("BUCKET_NAME"))
+}
+
+func TestReloadFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		setUp func(t *testing.T, dir string)
+	}{
+		{
+			name: "不正な設定",
+			setUp: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"port":`), 0o644))
+			},
+		},
+		{
+			name: "不正なデータセット",
+			setUp: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "internal", "data", "last.txt"), []byte{0xff, '\n'}, 0o644))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BUCKET_NAME", "env-bucket")
+			dir := setUpWorkDir(t, `{"port":8080,"maxResults":10,"bucketName":"new-bucket"}`)
+
+			gen := &generator.Generator{}
+			require.NoError(t, gen.LoadGenerators("old-bucket"))
+			loadedAt := gen.LoadedAt()
+			old := &config.Config{Port: 8080, MaxResults: 5, BucketName: "old-bucket"}
+			r := New(gen, old)
+
+			tt.setUp(t, dir)
+			st, err := r.Reload(TriggerSignal)
+			require.Error(t, err)
+			assert.False(t, st.Success)
+			assert.Equal(t, err.Error(), st.Error)
+			assert.Equal(t, 1, st.Reloads)
+
+			// 読み込みに失敗した場合は設定もデータセットも差し替えない
+			assert.Same(t, old, r.Config())
+			assert.Equal(t, loadedAt, gen.LoadedAt())
+			assert.Equal(t, loadedAt, st.DataLoadedAt)
+			assert.Equal(t, "env-bucket", os.Getenv("BUCKET_NAME"))
+			assert.Equal(t, st, r.Status())
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter は filter パラメーターを解析した条件式 (RFC 7644 3.4.2.2)
+type Filter interface {
+	// Match はリソース (JSON と同じ形のマップ) が条件を満たすかどうかを返す
+	Match(resource map[string]any) bool
+}
+
+// ParseFilter は filter パラメーターを解析する。不正な式は scimType が invalidFilter のエラーになる
+func ParseFilter(s string) (Filter, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, invalidFilter("%q の後に余分な字句があります", p.peek().text)
+	}
+	return f, nil
+}
+
+func invalidFilter(format string, args ...any) error {
+	return &Error{Status: 400, ScimType: "invalidFilter", Detail: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize は式を語・文字列・括弧に分ける
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")"})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{kind: tokenLBracket, text: "["})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{kind: tokenRBracket, text: "]"})
+			i++
+		case r == '"':
+			// JSON の文字列としてエスケープを解釈する
+			j := i + 1
+			for ; j < len(rs) && rs[j] != '"'; j++ {
+				if rs[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(rs) {
+				return nil, invalidFilter("文字列が閉じられていません")
+			}
+			text, err := strconv.Unquote(string(rs[i : j+1]))
+			if err != nil {
+				return nil, invalidFilter("不正な文字列です: %s", string(rs[i:j+1]))
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text})
+			i = j + 1
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune(`()[]"`, rs[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(rs[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() (token, error) {
+	if p.done() {
+		return token{}, invalidFilter("式が途中で終わっています")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+// isKeyword は次の字句が大文字小文字を区別せずに keyword と一致するかどうかを返す
+func (p *filterParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return !p.done() && t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// parseOr は and より優先度の低い or をまとめる
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.isKeyword("not") {
+		p.pos++
+		if p.peek().kind != tokenLParen || p.done() {
+			return nil, invalidFilter("not の後には ( が必要です")
+		}
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{f}, nil
+	}
+
+	if !p.done() && p.peek().kind == tokenLParen {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil || t.kind != tokenRParen {
+			return nil, invalidFilter(") が必要です")
+		}
+		return f, nil
+	}
+
+	return p.parseAttrExp()
+}
+
+// parseAttrExp は "attrPath op value"、"attrPath pr"、"attrPath[filter]" を解析する
+func (p *filterParser) parseAttrExp() (Filter, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind != tokenWord {
+		return nil, invalidFilter("属性名が必要です: %q", t.text)
+	}
+	path := parseAttrPath(t.text)
+
+	// emails[type eq "work"] のような値のフィルター
+	if !p.done() && p.peek().kind == tokenLBracket {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil || t.kind != tokenRBracket {
+			return nil, invalidFilter("] が必要です")
+		}
+		return valuePathFilter{path: path, filter: inner}, nil
+	}
+
+	opToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(opToken.text)
+	if opToken.kind != tokenWord || !validOps[op] {
+		return nil, invalidFilter("不正な演算子です: %q", opToken.text)
+	}
+	if op == "pr" {
+		return presentFilter{path: path}, nil
+	}
+
+	v, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	switch {
+	case v.kind == tokenString:
+		value = v.text
+	case v.kind == tokenWord && v.text == "true":
+		value = true
+	case v.kind == tokenWord && v.text == "false":
+		value = false
+	case v.kind == tokenWord && v.text == "null":
+		value = nil
+	case v.kind == tokenWord:
+		f, err := strconv.ParseFloat(v.text, 64)
+		if err != nil {
+			return nil, invalidFilter("不正な値です: %q", v.text)
+		}
+		value = f
+	default:
+		return nil, invalidFilter("値が必要です: %q", v.text)
+	}
+	return compareFilter{path: path, op: op, value: value}, nil
+}
+
+var validOps = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "lt": true, "ge": true, "le": true, "pr": true,
+}
+
+// attrPath は属性のパス。拡張スキーマの属性は URN をキーにしたオブジェクトの中にある
+type attrPath struct {
+	urn   string
+	names []string
+}
+
+// parseAttrPath は "name.givenName" や "urn:...:User:employeeNumber" を解析する
+func parseAttrPath(s string) attrPath {
+	var urn string
+	if strings.HasPrefix(strings.ToLower(s), "urn:") {
+		if i := strings.LastIndex(s, ":"); i >= 0 {
+			urn, s = s[:i], s[i+1:]
+		}
+		if strings.EqualFold(urn, SchemaUser) {
+			urn = ""
+		}
+	}
+	return attrPath{urn: urn, names: strings.Split(s, ".")}
+}
+
+// values はリソースからパスの値をすべて取り出す。複数値属性は要素ごとに展開する
+func (a attrPath) values(resource map[string]any) []any {
+	var node any = resource
+	if a.urn != "" {
+		node = lookup(resource, a.urn)
+	}
+	nodes := []any{node}
+	for _, name := range a.names {
+		var next []any
+		for _, n := range nodes {
+			next = append(next, flatten(lookupAny(n, name))...)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func lookupAny(node any, name string) any {
+	m, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return lookup(m, name)
+}
+
+// lookup は属性名を大文字小文字を区別せずに引く
+func lookup(m map[string]any, name string) any {
+	if v, ok := m[name]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func flatten(v any) []any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		var out []any
+		for _, e := range x {
+			out = append(out, flatten(e)...)
+		}
+		return out
+	}
+	return []any{v}
+}
+
+type andFilter struct{ left, right Filter }
+
+func (f andFilter) Match(r map[string]any) bool { return f.left.Match(r) && f.right.Match(r) }
+
+type orFilter struct{ left, right Filter }
+
+func (f orFilter) Match(r map[string]any) bool { return f.left.Match(r) || f.right.Match(r) }
+
+type notFilter struct{ filter Filter }
+
+func (f notFilter) Match(r map[string]any) bool { return !f.filter.Match(r) }
+
+type presentFilter struct{ path attrPath }
+
+func (f presentFilter) Match(r map[string]any) bool {
+	for _, v := range f.path.values(r) {
+		if s, ok := v.(string); !ok || s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// valuePathFilter は複数値属性の要素のいずれかが条件を満たすかどうかを調べる
+type valuePathFilter struct {
+	path   attrPath
+	filter Filter
+}
+
+func (f valuePathFilter) Match(r map[string]any) bool {
+	for _, v := range f.path.values(r) {
+		if m, ok := v.(map[string]any); ok && f.filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+type compareFilter struct {
+	path  attrPath
+	op    string
+	value any
+}
+
+func (f compareFilter) Match(r map[string]any) bool {
+	values := f.path.values(r)
+	if f.op == "ne" {
+		for _, v := range values {
+			if compare(v, "eq", f.value) {
+				return false
+			}
+		}
+		return true
+	}
+	if f.op == "eq" && f.value == nil {
+		return len(values) == 0
+	}
+	for _, v := range values {
+		if compare(v, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare は属性の値と比較する。文字列は大文字小文字を区別しない
+func compare(v any, op string, want any) bool {
+	switch w := want.(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		s, w = strings.ToLower(s), strings.ToLower(w)
+		switch op {
+		case "eq":
+			return s == w
+		case "co":
+			return strings.Contains(s, w)
+		case "sw":
+			return strings.HasPrefix(s, w)
+		case "ew":
+			return strings.HasSuffix(s, w)
+		case "gt":
+			return s > w
+		case "lt":
+			return s < w
+		case "ge":
+			return s >= w
+		case "le":
+			return s <= w
+		}
+	case float64:
+		n, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return n == w
+		case "gt":
+			return n > w
+		case "lt":
+			return n < w
+		case "ge":
+			return n >= w
+		case "le":
+			return n <= w
+		}
+	case bool:
+		b, ok := v.(bool)
+		return ok && op == "eq" && b == w
+	}
+	return false
+}
@@ -0,0 +1,323 @@
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ryuhei/randomuser-go/internal/directory"
+)
+
+// Error は SCIM のエラーレスポンス (RFC 7644 3.12)
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// Response はエラーレスポンスの本文を返す
+func (e *Error) Response() map[string]any {
+	body := map[string]any{
+		"schemas": []string{SchemaError},
+		"status":  strconv.Itoa(e.Status),
+		"detail":  e.Detail,
+	}
+	if e.ScimType != "" {
+		body["scimType"] = e.ScimType
+	}
+	return body
+}
+
+func invalidValue(format string, args ...any) error {
+	return &Error{Status: 400, ScimType: "invalidValue", Detail: fmt.Sprintf(format, args...)}
+}
+
+func invalidPath(format string, args ...any) error {
+	return &Error{Status: 400, ScimType: "invalidPath", Detail: fmt.Sprintf(format, args...)}
+}
+
+// PatchRequest は PATCH のリクエスト (RFC 7644 3.5.2)
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation は PATCH の1つの操作
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// booleanAttrs は文字列の "True" / "False" も受け付ける属性。Azure AD はこの形で送ってくる
+var booleanAttrs = map[string]bool{"active": true, "primary": true}
+
+// readOnlyAttrs はクライアントが変更できない属性
+var readOnlyAttrs = map[string]bool{"id": true, "meta": true, "schemas": true}
+
+// Patch はユーザーに PATCH の操作を順に適用する。
+// いずれかの操作が失敗した場合はエラーを返し、e は変更しない
+func Patch(e *directory.Entry, ops []PatchOperation) error {
+	resource, err := toMap(FromEntry(*e, ""))
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		value := normalizeValue(op.Value)
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			if op.Path == "" {
+				m, ok := value.(map[string]any)
+				if !ok {
+					return invalidValue("path を省略する場合、value はオブジェクトにしてください")
+				}
+				for k, v := range m {
+					if err := setAttr(resource, k, v, strings.EqualFold(op.Op, "add")); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := setAttr(resource, op.Path, value, strings.EqualFold(op.Op, "add")); err != nil {
+				return err
+			}
+		case "remove":
+			if op.Path == "" {
+				return &Error{Status: 400, ScimType: "noTarget", Detail: "remove には path が必要です"}
+			}
+			if err := removeAttr(resource, op.Path); err != nil {
+				return err
+			}
+		default:
+			return invalidValue("未対応の操作です: %q", op.Op)
+		}
+	}
+
+	r, err := fromMap(resource)
+	if err != nil {
+		return invalidValue("属性の値が不正です: %v", err)
+	}
+	updated := *e
+	Apply(r, &updated)
+	*e = updated
+	return nil
+}
+
+// normalizeValue は真偽値を表す文字列を bool にする
+func normalizeValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, e := range x {
+			if booleanAttrs[strings.ToLower(k)] {
+				x[k] = toBool(e)
+			} else {
+				x[k] = normalizeValue(e)
+			}
+		}
+	case []any:
+		for i, e := range x {
+			x[i] = normalizeValue(e)
+		}
+	}
+	return v
+}
+
+func toBool(v any) any {
+	if s, ok := v.(string); ok {
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// patchPath は PATCH の path。emails[type eq "work"].value のように値のフィルターを含む
+type patchPath struct {
+	attr   attrPath
+	filter Filter
+	sub    string
+}
+
+func parsePatchPath(s string) (patchPath, error) {
+	var p patchPath
+	attr := s
+	if i := strings.Index(s, "["); i >= 0 {
+		j := strings.LastIndex(s, "]")
+		if j < i {
+			return p, invalidPath("] が必要です: %q", s)
+		}
+		f, err := ParseFilter(s[i+1 : j])
+		if err != nil {
+			return p, invalidPath("path のフィルターが不正です: %v", err)
+		}
+		p.filter = f
+		attr = s[:i]
+		p.sub = strings.TrimPrefix(s[j+1:], ".")
+	}
+	p.attr = parseAttrPath(attr)
+	if len(p.attr.names) == 0 || p.attr.names[0] == "" {
+		return p, invalidPath("属性名が必要です: %q", s)
+	}
+	if p.attr.urn == "" && readOnlyAttrs[strings.ToLower(p.attr.names[0])] {
+		return p, &Error{Status: 400, ScimType: "mutability", Detail: fmt.Sprintf("%s は変更できません", p.attr.names[0])}
+	}
+	if len(p.attr.names) > 2 || (p.filter != nil && len(p.attr.names) > 1) {
+		return p, invalidPath("未対応の path です: %q", s)
+	}
+	return p, nil
+}
+
+// container はパスの値を持つオブジェクトと、その中のキーを返す。create が true なら途中のオブジェクトを作る
+func (p patchPath) container(resource map[string]any, create bool) (map[string]any, string) {
+	m := resource
+	if p.attr.urn != "" {
+		ext, ok := lookup(resource, p.attr.urn).(map[string]any)
+		if !ok {
+			if !create {
+				return nil, ""
+			}
+			ext = map[string]any{}
+			resource[p.attr.urn] = ext
+		}
+		m = ext
+	}
+	names := p.attr.names
+	for _, name := range names[:len(names)-1] {
+		key := keyOf(m, name)
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			if !create {
+				return nil, ""
+			}
+			next = map[string]any{}
+			m[key] = next
+		}
+		m = next
+	}
+	return m, keyOf(m, names[len(names)-1])
+}
+
+// keyOf は大文字小文字を区別せずに既存のキーを探す。無ければ name をそのまま返す
+func keyOf(m map[string]any, name string) string {
+	if _, ok := m[name]; ok {
+		return name
+	}
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return name
+}
+
+// setAttr は add / replace を1つ適用する。add の場合、複数値属性には要素を追加する
+func setAttr(resource map[string]any, path string, value any, add bool) error {
+	p, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	m, key := p.container(resource, true)
+
+	leaf := p.sub
+	if leaf == "" {
+		leaf = p.attr.names[len(p.attr.names)-1]
+	}
+	if booleanAttrs[strings.ToLower(leaf)] {
+		value = toBool(value)
+	}
+
+	if p.filter != nil {
+		items, _ := m[key].([]any)
+		matched := false
+		for i, item := range items {
+			obj, ok := item.(map[string]any)
+			if !ok || !p.filter.Match(obj) {
+				continue
+			}
+			matched = true
+			if p.sub == "" {
+				items[i] = value
+			} else {
+				obj[keyOf(obj, p.sub)] = value
+			}
+		}
+		// 一致する要素が無い場合、Azure AD の emails[type eq "work"].value のような指定では要素を作る
+		if !matched {
+			obj := elementFromFilter(p.filter)
+			if obj == nil {
+				return &Error{Status: 400, ScimType: "noTarget", Detail: fmt.Sprintf("%q に一致する要素がありません", path)}
+			}
+			if p.sub != "" {
+				obj[p.sub] = value
+			} else if v, ok := value.(map[string]any); ok {
+				for k, e := range v {
+					obj[k] = e
+				}
+			}
+			items = append(items, obj)
+		}
+		m[key] = items
+		return nil
+	}
+
+	if add {
+		if existing, ok := m[key].([]any); ok {
+			if values, ok := value.([]any); ok {
+				m[key] = append(existing, values...)
+				return nil
+			}
+		}
+	}
+	m[key] = value
+	return nil
+}
+
+// elementFromFilter は type eq "work" のような単純な条件から新しい要素を作る
+func elementFromFilter(f Filter) map[string]any {
+	c, ok := f.(compareFilter)
+	if !ok || c.op != "eq" || c.path.urn != "" || len(c.path.names) != 1 {
+		return nil
+	}
+	return map[string]any{c.path.names[0]: c.value}
+}
+
+// removeAttr は remove を1つ適用する
+func removeAttr(resource map[string]any, path string) error {
+	p, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	m, key := p.container(resource, false)
+	if m == nil {
+		return nil
+	}
+
+	if p.filter != nil {
+		items, _ := m[key].([]any)
+		kept := items[:0]
+		for _, item := range items {
+			obj, ok := item.(map[string]any)
+			if !ok || !p.filter.Match(obj) {
+				kept = append(kept, item)
+				continue
+			}
+			if p.sub != "" {
+				delete(obj, keyOf(obj, p.sub))
+				kept = append(kept, obj)
+			}
+		}
+		m[key] = kept
+		return nil
+	}
+
+	if strings.EqualFold(key, "userName") {
+		return &Error{Status: 400, ScimType: "mutability", Detail: "userName は削除できません"}
+	}
+	delete(m, key)
+	return nil
+}
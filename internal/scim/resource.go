@@ -0,0 +1,305 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/model"
+)
+
+// スキーマの URN (RFC 7643)
+const (
+	SchemaUser           = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaEnterpriseUser = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SchemaListResponse   = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp        = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError          = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// ContentType は SCIM のレスポンスの Content-Type
+const ContentType = "application/scim+json; charset=utf-8"
+
+// User は SCIM の User リソース
+type User struct {
+	Schemas      []string      `json:"schemas"`
+	ID           string        `json:"id,omitempty"`
+	ExternalID   string        `json:"externalId,omitempty"`
+	UserName     string        `json:"userName"`
+	Name         *Name         `json:"name,omitempty"`
+	DisplayName  string        `json:"displayName,omitempty"`
+	Password     string        `json:"password,omitempty"`
+	Active       *bool         `json:"active,omitempty"`
+	Emails       []MultiValued `json:"emails,omitempty"`
+	PhoneNumbers []MultiValued `json:"phoneNumbers,omitempty"`
+	Addresses    []Address     `json:"addresses,omitempty"`
+	Photos       []MultiValued `json:"photos,omitempty"`
+	Enterprise   *Enterprise   `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta         *Meta         `json:"meta,omitempty"`
+}
+
+// Name は User の name 属性
+type Name struct {
+	Formatted       string `json:"formatted,omitempty"`
+	FamilyName      string `json:"familyName,omitempty"`
+	GivenName       string `json:"givenName,omitempty"`
+	HonorificPrefix string `json:"honorificPrefix,omitempty"`
+}
+
+// MultiValued は emails や phoneNumbers などの複数値属性の要素
+type MultiValued struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Address は User の addresses 属性の要素
+type Address struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"streetAddress,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postalCode,omitempty"`
+	Country       string `json:"country,omitempty"`
+	Type          string `json:"type,omitempty"`
+	Primary       bool   `json:"primary,omitempty"`
+}
+
+// Enterprise はエンタープライズ拡張スキーマの属性
+type Enterprise struct {
+	EmployeeNumber string `json:"employeeNumber,omitempty"`
+}
+
+// Meta はリソースのメタデータ
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// ListResponse は一覧のレスポンス
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []User   `json:"Resources"`
+}
+
+// FromEntry はディレクトリのユーザーを SCIM の User に変換する。
+// location は baseURL に /Users/{id} を付けたもの
+func FromEntry(e directory.Entry, baseURL string) User {
+	u := e.User
+	active := e.Active
+	r := User{
+		Schemas:     []string{SchemaUser, SchemaEnterpriseUser},
+		ID:          e.ID,
+		ExternalID:  e.ExternalID,
+		UserName:    u.Login.Username,
+		DisplayName: strings.TrimSpace(u.Name.First + " " + u.Name.Last),
+		Active:      &active,
+		Name: &Name{
+			Formatted:       strings.TrimSpace(strings.Join([]string{u.Name.Title, u.Name.First, u.Name.Last}, " ")),
+			FamilyName:      u.Name.Last,
+			GivenName:       u.Name.First,
+			HonorificPrefix: u.Name.Title,
+		},
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      formatTime(e.Meta.Created),
+			LastModified: formatTime(e.Meta.LastModified),
+			Location:     strings.TrimSuffix(baseURL, "/") + "/Users/" + e.ID,
+			Version:      Version(e),
+		},
+	}
+	if u.Email != "" {
+		r.Emails = []MultiValued{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	if u.Phone != "" {
+		r.PhoneNumbers = append(r.PhoneNumbers, MultiValued{Value: u.Phone, Type: "work", Primary: true})
+	}
+	if u.Cell != "" {
+		r.PhoneNumbers = append(r.PhoneNumbers, MultiValued{Value: u.Cell, Type: "mobile"})
+	}
+	if loc := u.Location; loc != (model.Location{}) {
+		street := loc.Street.Name
+		if loc.Street.Number != 0 {
+			street = strconv.Itoa(loc.Street.Number) + " " + loc.Street.Name
+		}
+		r.Addresses = []Address{{
+			Formatted:     strings.Join([]string{street, loc.City + ", " + loc.State + " " + loc.Postcode, loc.Country}, "\n"),
+			StreetAddress: street,
+			Locality:      loc.City,
+			Region:        loc.State,
+			PostalCode:    loc.Postcode,
+			Country:       loc.Country,
+			Type:          "home",
+			Primary:       true,
+		}}
+	}
+	// Large と Medium は仮の画像のため、顔写真の URL だけを返す
+	if photo := u.Picture.Portrait(); photo != "" {
+		r.Photos = []MultiValued{{Value: photo, Type: "photo", Primary: true}}
+	}
+	// 従業員番号は組織図の社員番号だけを返し、国ごとの識別番号は返さない
+	if u.Employment != nil && u.Employment.EmployeeID != "" {
+		r.Enterprise = &Enterprise{EmployeeNumber: u.Employment.EmployeeID}
+	}
+	return r
+}
+
+// Version はリソースの ETag に使う弱いバージョンを返す
+func Version(e directory.Entry) string {
+	return `W/"` + strconv.Itoa(e.Meta.Version) + `"`
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Apply は SCIM の User の属性をディレクトリのユーザーに書き込む。
+// 対応していない属性は無視し、SCIM 側にない model.User のフィールドは元の値を残す
+func Apply(r User, e *directory.Entry) {
+	u := &e.User
+	e.ExternalID = r.ExternalID
+	if r.Active != nil {
+		e.Active = *r.Active
+	}
+	u.Login.Username = r.UserName
+	if r.Password != "" {
+		u.Login.Password = r.Password
+	}
+
+	if r.Name != nil {
+		u.Name.First = r.Name.GivenName
+		u.Name.Last = r.Name.FamilyName
+		u.Name.Title = r.Name.HonorificPrefix
+	} else {
+		u.Name = model.Name{}
+	}
+
+	u.Email = primary(r.Emails, "").Value
+	u.Phone = ""
+	u.Cell = ""
+	for _, p := range r.PhoneNumbers {
+		if p.Type == "mobile" {
+			if u.Cell == "" {
+				u.Cell = p.Value
+			}
+		} else if u.Phone == "" || p.Primary {
+			u.Phone = p.Value
+		}
+	}
+
+	if a, ok := primaryAddress(r.Addresses); ok {
+		u.Location.Street = parseStreet(a.StreetAddress)
+		u.Location.City = a.Locality
+		u.Location.State = a.Region
+		u.Location.Postcode = a.PostalCode
+		u.Location.Country = a.Country
+	} else {
+		u.Location = model.Location{}
+	}
+
+	// SCIM の写真は顔写真の1つだけのため、顔写真が変わった場合だけすべての大きさを揃えて書き換える
+	if p := primary(r.Photos, "photo"); p.Value == "" {
+		u.Picture = model.Picture{}
+	} else if p.Value != u.Picture.Portrait() {
+		u.Picture = model.Picture{Large: p.Value, Medium: p.Value, Thumbnail: p.Value}
+	}
+
+	var employeeID string
+	if r.Enterprise != nil {
+		employeeID = r.Enterprise.EmployeeNumber
+	}
+	setEmployeeID(u, employeeID)
+}
+
+// setEmployeeID は社員番号を書き換える。Employment は元のユーザーと共有しているため写してから書き換える
+func setEmployeeID(u *model.User, id string) {
+	var emp model.Employment
+	if u.Employment != nil {
+		emp = *u.Employment
+	}
+	if emp.EmployeeID == id {
+		return
+	}
+	emp.EmployeeID = id
+	if emp == (model.Employment{}) {
+		u.Employment = nil
+	} else {
+		u.Employment = &emp
+	}
+}
+
+// primary は primary の要素を返す。無ければ typ の要素、それも無ければ先頭の要素を返す
+func primary(values []MultiValued, typ string) MultiValued {
+	for _, v := range values {
+		if v.Primary {
+			return v
+		}
+	}
+	for _, v := range values {
+		if v.Type == typ {
+			return v
+		}
+	}
+	if len(values) > 0 {
+		return values[0]
+	}
+	return MultiValued{}
+}
+
+func primaryAddress(addresses []Address) (Address, bool) {
+	for _, a := range addresses {
+		if a.Primary {
+			return a, true
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0], true
+	}
+	return Address{}, false
+}
+
+// parseStreet は "1283 Bruce St" のような住所を番地と通りの名前に分ける
+func parseStreet(s string) model.Street {
+	s = strings.TrimSpace(s)
+	number, name, ok := strings.Cut(s, " ")
+	if n, err := strconv.Atoi(number); ok && err == nil {
+		return model.Street{Number: n, Name: strings.TrimSpace(name)}
+	}
+	return model.Street{Name: s}
+}
+
+// toMap は User を JSON と同じ形のマップに変換する。フィルターと PATCH はこの形で扱う
+func toMap(r User) (map[string]any, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func fromMap(m map[string]any) (User, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return User{}, err
+	}
+	var r User
+	if err := json.Unmarshal(b, &r); err != nil {
+		return User{}, err
+	}
+	return r, nil
+}
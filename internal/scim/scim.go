@@ -0,0 +1,50 @@
+// Package scim は生成したユーザーを SCIM 2.0 (RFC 7643, RFC 7644) の User リソースとして扱う
+package scim
+
+import (
+	"github.com/ryuhei/randomuser-go/internal/directory"
+)
+
+const (
+	// DefaultCount は count を省略した場合の1ページの件数
+	DefaultCount = 100
+	// MaxCount は1ページの最大件数
+	MaxCount = 1000
+)
+
+// List はフィルターに一致するユーザーの startIndex (1 始まり) から count 件を返す
+func List(entries []directory.Entry, filter Filter, startIndex, count int, baseURL string) (ListResponse, error) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > MaxCount {
+		count = MaxCount
+	}
+
+	res := ListResponse{
+		Schemas:    []string{SchemaListResponse},
+		StartIndex: startIndex,
+		Resources:  []User{},
+	}
+	for _, e := range entries {
+		r := FromEntry(e, baseURL)
+		if filter != nil {
+			m, err := toMap(r)
+			if err != nil {
+				return ListResponse{}, err
+			}
+			if !filter.Match(m) {
+				continue
+			}
+		}
+		res.TotalResults++
+		if res.TotalResults >= startIndex && len(res.Resources) < count {
+			res.Resources = append(res.Resources, r)
+		}
+	}
+	res.ItemsPerPage = len(res.Resources)
+	return res, nil
+}
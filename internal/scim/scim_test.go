@@ -0,0 +1,215 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ryuhei/randomuser-go/internal/directory"
+	"github.com/ryuhei/randomuser-go/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntry() directory.Entry {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	return directory.Entry{
+		ID:     "1a8b9525-e20f-4a68-927f-2b2ff836f735",
+		Active: true,
+		User: model.User{
+			Name:  model.Name{Title: "Mr", First: "Steven", Last: "Palmer"},
+			Email: "steven.palmer@example.com",
+			Phone: "(629)-092-8831",
+			Cell:  "(577)-886-5320",
+			Location: model.Location{
+				Street:      model.Street{Number: 1283, Name: "Bruce St"},
+				City:        "Victorville",
+				State:       "Idaho",
+				Postcode:    "32763",
+				Country:     "US",
+				Coordinates: model.Coordinates{Latitude: "-21.4817", Longitude: "-65.4991"},
+			},
+			Login: model.Login{UUID: "1a8b9525-e20f-4a68-927f-2b2ff836f735", Username: "stevenpalmer51", Password: "pass"},
+			ID:    model.ID{Name: "SSN", Value: "123-45-6789"},
+			Employment: &model.Employment{
+				Employer:   "Acme",
+				Department: "Engineering",
+				EmployeeID: "E1001",
+			},
+			Picture: model.Picture{Large: "https://example.com/l.jpg", Medium: "https://example.com/m.jpg", Thumbnail: "https://example.com/t.jpg"},
+		},
+		Meta: directory.Meta{Created: created, LastModified: created, Version: 3},
+	}
+}
+
+func TestFromEntry(t *testing.T) {
+	r := FromEntry(testEntry(), "https://example.com/scim/v2/")
+	assert.Equal(t, "stevenpalmer51", r.UserName)
+	assert.Equal(t, "Mr Steven Palmer", r.Name.Formatted)
+	assert.Equal(t, []MultiValued{{Value: "(629)-092-8831", Type: "work", Primary: true}, {Value: "(577)-886-5320", Type: "mobile"}}, r.PhoneNumbers)
+	assert.Equal(t, "1283 Bruce St", r.Addresses[0].StreetAddress)
+	// 従業員番号は国ごとの識別番号ではなく社員番号
+	assert.Equal(t, "E1001", r.Enterprise.EmployeeNumber)
+	// 写真は仮の画像ではなく顔写真
+	assert.Equal(t, []MultiValued{{Value: "https://example.com/t.jpg", Type: "photo", Primary: true}}, r.Photos)
+	assert.Equal(t, "https://example.com/scim/v2/Users/1a8b9525-e20f-4a68-927f-2b2ff836f735", r.Meta.Location)
+	assert.Equal(t, `W/"3"`, r.Meta.Version)
+	assert.Equal(t, "2020-01-02T03:04:05Z", r.Meta.Created)
+	assert.Empty(t, r.Password)
+}
+
+func TestApplyRoundTrip(t *testing.T) {
+	e := testEntry()
+	got := e
+	Apply(FromEntry(e, ""), &got)
+	assert.Equal(t, e, got)
+
+	// 組織図に属さないユーザーには従業員番号を付けない
+	e.User.Employment = nil
+	r := FromEntry(e, "")
+	assert.Nil(t, r.Enterprise)
+	got = e
+	Apply(r, &got)
+	assert.Equal(t, e, got)
+}
+
+func TestParseFilter(t *testing.T) {
+	resource, err := toMap(FromEntry(testEntry(), ""))
+	require.NoError(t, err)
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{filter: `userName eq "StevenPalmer51"`, want: true},
+		{filter: `userName ne "stevenpalmer51"`, want: false},
+		{filter: `name.familyName sw "pal"`, want: true},
+		{filter: `emails.value ew "@example.com"`, want: true},
+		{filter: `emails[type eq "work" and value co "steven"]`, want: true},
+		{filter: `phoneNumbers[type eq "home"]`, want: false},
+		{filter: `active eq true and not (title pr)`, want: true},
+		{filter: `userName eq "x" or name.givenName eq "steven"`, want: true},
+		{filter: `userName eq "x" or name.givenName eq "steven" and active eq false`, want: false},
+		{filter: `urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber eq "E1001"`, want: true},
+		{filter: `urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber eq "123-45-6789"`, want: false},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "stevenpalmer51"`, want: true},
+		{filter: `meta.lastModified gt "2019-12-31T00:00:00Z"`, want: true},
+		{filter: `externalId eq null`, want: true},
+		{filter: `USERNAME EQ "stevenpalmer51"`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := ParseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.Match(resource))
+		})
+	}
+}
+
+func TestParseFilterInvalid(t *testing.T) {
+	for _, s := range []string{
+		`userName`,
+		`userName eq`,
+		`userName foo "x"`,
+		`userName eq "x`,
+		`(userName eq "x"`,
+		`emails[type eq "work"`,
+		`userName eq "x" and`,
+		`userName eq "x" "y"`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			_, err := ParseFilter(s)
+			var se *Error
+			require.ErrorAs(t, err, &se)
+			assert.Equal(t, "invalidFilter", se.ScimType)
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	var ops []PatchOperation
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"op": "Replace", "path": "active", "value": "False"},
+		{"op": "replace", "value": {"name.givenName": "Steve", "externalId": "00u1"}},
+		{"op": "add", "path": "emails[type eq \"home\"].value", "value": "steve@example.org"},
+		{"op": "replace", "path": "phoneNumbers[type eq \"mobile\"].value", "value": "555-1234"},
+		{"op": "add", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", "value": "E42"},
+		{"op": "remove", "path": "addresses"}
+	]`), &ops))
+
+	e := testEntry()
+	require.NoError(t, Patch(&e, ops))
+	assert.False(t, e.Active)
+	assert.Equal(t, "00u1", e.ExternalID)
+	assert.Equal(t, "Steve", e.User.Name.First)
+	assert.Equal(t, "steven.palmer@example.com", e.User.Email)
+	assert.Equal(t, "555-1234", e.User.Cell)
+	assert.Equal(t, "E42", e.User.Employment.EmployeeID)
+	assert.Equal(t, "Engineering", e.User.Employment.Department)
+	assert.Equal(t, "123-45-6789", e.User.ID.Value)
+	assert.Equal(t, model.Location{}, e.User.Location)
+	assert.Equal(t, "https://example.com/m.jpg", e.User.Picture.Medium)
+
+	// 顔写真を変えた場合はすべての大きさを揃える
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"op": "replace", "path": "photos", "value": [{"value": "https://example.com/new.jpg", "type": "photo", "primary": true}]}
+	]`), &ops))
+	require.NoError(t, Patch(&e, ops))
+	assert.Equal(t, model.Picture{Large: "https://example.com/new.jpg", Medium: "https://example.com/new.jpg", Thumbnail: "https://example.com/new.jpg"}, e.User.Picture)
+}
+
+func TestPatchInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       PatchOperation
+		scimType string
+	}{
+		{name: "未対応の操作", op: PatchOperation{Op: "move", Path: "userName"}, scimType: "invalidValue"},
+		{name: "読み取り専用", op: PatchOperation{Op: "replace", Path: "id", Value: "x"}, scimType: "mutability"},
+		{name: "path の無い remove", op: PatchOperation{Op: "remove"}, scimType: "noTarget"},
+		{name: "一致しない複雑なフィルター", op: PatchOperation{Op: "replace", Path: `emails[value co "zzz"].type`, Value: "home"}, scimType: "noTarget"},
+		{name: "不正な値", op: PatchOperation{Op: "replace", Path: "active", Value: "maybe"}, scimType: "invalidValue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEntry()
+			err := Patch(&e, []PatchOperation{tt.op})
+			var se *Error
+			require.ErrorAs(t, err, &se)
+			assert.Equal(t, tt.scimType, se.ScimType)
+			assert.Equal(t, testEntry(), e)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	var entries []directory.Entry
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		e := testEntry()
+		e.ID = name
+		e.User.Login.Username = name
+		entries = append(entries, e)
+	}
+
+	res, err := List(entries, nil, 2, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, 5, res.TotalResults)
+	assert.Equal(t, 2, res.StartIndex)
+	assert.Equal(t, 2, res.ItemsPerPage)
+	assert.Equal(t, "bob", res.Resources[0].UserName)
+
+	f, err := ParseFilter(`userName co "r"`)
+	require.NoError(t, err)
+	res, err = List(entries, f, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.TotalResults)
+	assert.Equal(t, 1, res.StartIndex)
+	assert.Equal(t, "carol", res.Resources[0].UserName)
+
+	res, err = List(entries, nil, 1, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, 5, res.TotalResults)
+	assert.Empty(t, res.Resources)
+}